@@ -0,0 +1,34 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// loadCategoryMap reads a JSON file mapping category names to their domains,
+// e.g. {"Google": ["gmail.com", "googlemail.com"]}, and inverts it into the
+// domain to category map of customerimporter.WithCategoryMap. A domain may
+// belong to a single category only.
+func loadCategoryMap(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading category map: %v", err)
+	}
+
+	var domainsByCategory map[string][]string
+	if err := json.Unmarshal(data, &domainsByCategory); err != nil {
+		return nil, fmt.Errorf("error parsing category map %s: %v", path, err)
+	}
+
+	categories := make(map[string]string)
+	for category, domains := range domainsByCategory {
+		for _, domain := range domains {
+			if other, ok := categories[domain]; ok {
+				return nil, fmt.Errorf("domain %s is mapped to both %s and %s", domain, other, category)
+			}
+			categories[domain] = category
+		}
+	}
+	return categories, nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadCategoryMap(t *testing.T) {
+	testCases := []struct {
+		name        string
+		content     string
+		expected    map[string]string
+		expectError bool
+	}{
+		{
+			name:    "valid",
+			content: `{"Google": ["gmail.com", "googlemail.com"], "Microsoft": ["outlook.com", "hotmail.com"]}`,
+			expected: map[string]string{
+				"gmail.com":      "Google",
+				"googlemail.com": "Google",
+				"outlook.com":    "Microsoft",
+				"hotmail.com":    "Microsoft",
+			},
+		},
+		{
+			name:        "domain_in_two_categories",
+			content:     `{"Google": ["gmail.com"], "Other mail": ["gmail.com"]}`,
+			expectError: true,
+		},
+		{
+			name:        "malformed",
+			content:     `{"Google": "gmail.com"}`,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "categories.json")
+			if err := os.WriteFile(path, []byte(tc.content), 0644); err != nil {
+				t.Fatalf("error writing category map: %v", err)
+			}
+
+			categories, err := loadCategoryMap(path)
+			if tc.expectError {
+				if err == nil {
+					t.Error("error expected, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error occured: %v", err)
+			}
+			if !reflect.DeepEqual(categories, tc.expected) {
+				t.Errorf("Categories: %v, expected: %v", categories, tc.expected)
+			}
+		})
+	}
+}
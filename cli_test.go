@@ -0,0 +1,302 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// RUN_MAIN_ENV makes the test binary run main instead of the tests, so the
+// CLI can be exercised as a process with its real exit codes.
+const RUN_MAIN_ENV = "CUSTOMERIMPORTER_RUN_MAIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(RUN_MAIN_ENV) == "1" {
+		os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+	}
+	os.Exit(m.Run())
+}
+
+// runCLI runs the CLI with args and stdin, returning its stdout, stderr and
+// exit code.
+func runCLI(t *testing.T, stdin string, args ...string) (string, string, int) {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(os.Environ(), RUN_MAIN_ENV+"=1")
+	cmd.Stdin = strings.NewReader(stdin)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return stdout.String(), stderr.String(), exitErr.ExitCode()
+	}
+	if err != nil {
+		t.Fatalf("error running the CLI: %v", err)
+	}
+	return stdout.String(), stderr.String(), 0
+}
+
+func TestCLI(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "customers.csv")
+	csvInput := "first_name,last_name,email\nA,A,a@github.io\nB,B,b@cnet.com\nC,C,c@github.io\nD,D,invalid\n"
+	if err := os.WriteFile(input, []byte(csvInput), 0644); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	output := filepath.Join(dir, "out", "result.csv")
+
+	testCases := []struct {
+		name           string
+		args           []string
+		stdin          string
+		expectedCode   int
+		expectedStdout string
+		expectedStderr string
+	}{
+		{
+			name:           "text",
+			args:           []string{"-input", input},
+			expectedStdout: "Total number of customers: 3\nCustomers with invalid email: 1\nDomain: cnet.com, Customers: 1\nDomain: github.io, Customers: 2\n",
+			expectedStderr: `Invalid email address "invalid"`,
+		},
+		{
+			name:           "process_command_stdin",
+			args:           []string{"process", "-input", "-", "-names-only"},
+			stdin:          csvInput,
+			expectedStdout: "cnet.com\ngithub.io\n",
+		},
+		{
+			name:           "json",
+			args:           []string{"-input", input, "-format", "json"},
+			expectedStdout: `"total_customers": 3`,
+		},
+		{
+			name:           "summary_only",
+			args:           []string{"-input", input, "-format", "json", "-summary-only"},
+			expectedStdout: "{\n  \"total_customers\": 3,\n  \"distinct_domains\": 2,\n  \"invalid_customers\": 1\n}\n",
+		},
+		{
+			name:           "merge_from_stdin",
+			args:           []string{"-input", input, "-merge-from-stdin"},
+			stdin:          `{"summary": {"total_customers": 3}, "domains": [{"name": "github.io", "count": 1}, {"name": "zoho.com", "count": 2}]}`,
+			expectedStdout: "Total number of customers: 6\nCustomers with invalid email: 1\nDomain: cnet.com, Customers: 1\nDomain: github.io, Customers: 3\nDomain: zoho.com, Customers: 2\n",
+		},
+		{
+			name:           "merge_from_stdin_with_stdin_input",
+			args:           []string{"-input", "-", "-merge-from-stdin"},
+			expectedCode:   1,
+			expectedStderr: "-merge-from-stdin cannot be combined with -input -",
+		},
+		{
+			name:           "sort_by_count",
+			args:           []string{"-input", input, "-sort-by", "count,name", "-names-only"},
+			expectedStdout: "github.io\ncnet.com\n",
+		},
+		{
+			name:         "output_file",
+			args:         []string{"-input", input, "-format", "csv", "-output", output, "-mkdir"},
+			expectedCode: 0,
+		},
+		{
+			name:           "output_is_input",
+			args:           []string{"-input", input, "-output", input},
+			expectedCode:   1,
+			expectedStderr: "output file is an input file",
+		},
+		{
+			name:           "stream_output_is_input",
+			args:           []string{"-input", input, "-stream", "-output", input},
+			expectedCode:   1,
+			expectedStderr: "output file is an input file",
+		},
+		{
+			name:           "per_file_report",
+			args:           []string{"-input", input, "-input", filepath.Join(dir, "missing.csv"), "-per-file-report", "-names-only"},
+			expectedCode:   1,
+			expectedStdout: "cnet.com\ngithub.io\n",
+			expectedStderr: "1 of 2 inputs failed",
+		},
+		{
+			name:           "match_regex",
+			args:           []string{"-input", input, "-match-regex", `^git`, "-names-only"},
+			expectedStdout: "github.io\n",
+		},
+		{
+			name:           "exclude_regex",
+			args:           []string{"-input", input, "-exclude-regex", `\.io$`, "-names-only"},
+			expectedStdout: "cnet.com\n",
+		},
+		{
+			name:           "invalid_regex",
+			args:           []string{"-input", input, "-match-regex", "(git"},
+			expectedCode:   1,
+			expectedStderr: "invalid -match-regex",
+		},
+		{
+			name:           "tree",
+			args:           []string{"-input", input, "-tree"},
+			expectedStdout: "Domain: com, Customers: 1\n  Domain: cnet.com, Customers: 1\nDomain: io, Customers: 2\n  Domain: github.io, Customers: 2\n",
+		},
+		{
+			name:           "dir_output",
+			args:           []string{"-input", dir, "-output-dir", filepath.Join(dir, "reports"), "-output", output},
+			expectedCode:   1,
+			expectedStderr: "-output does not support a directory -input",
+		},
+		{
+			name:           "negative_weight_col",
+			args:           []string{"-input", input, "-weight-col", "-2"},
+			expectedCode:   1,
+			expectedStderr: "invalid -weight-col -2",
+		},
+		{
+			name:           "strict_weights_without_weight_col",
+			args:           []string{"-input", input, "-strict-weights"},
+			expectedCode:   1,
+			expectedStderr: "-strict-weights requires -weight-col",
+		},
+		{
+			name:           "normalize_per_align",
+			args:           []string{"-input", input, "-normalize-per", "100", "-align"},
+			expectedCode:   1,
+			expectedStderr: "-normalize-per does not support -align or -role-accounts",
+		},
+		{
+			name:           "tree_csv",
+			args:           []string{"-input", input, "-tree", "-format", "csv"},
+			expectedCode:   1,
+			expectedStderr: "-tree does not support -format csv",
+		},
+		{
+			name:           "hierarchy_csv",
+			args:           []string{"-input", input, "-group-by", "hierarchy", "-format", "csv"},
+			expectedCode:   1,
+			expectedStderr: "-group-by hierarchy does not support -format csv",
+		},
+		{
+			name:           "top",
+			args:           []string{"-input", input, "-top", "1", "-format", "csv"},
+			expectedStdout: "domain,customers\ngithub.io,2\n",
+		},
+		{
+			name:           "several_group_bys",
+			args:           []string{"-input", input, "-group-by", "domain,tld", "-names-only"},
+			expectedStdout: "== Group by: domain ==\ncnet.com\ngithub.io\n== Group by: tld ==\ncom\nio\n",
+		},
+		{
+			name:           "several_group_bys_json_stdout",
+			args:           []string{"-input", input, "-group-by", "domain,tld", "-format", "json"},
+			expectedCode:   1,
+			expectedStderr: "-group-by with several values writes only -format text to stdout",
+		},
+		{
+			name:           "dedup_key",
+			args:           []string{"-input", "-", "-dedup-key", "last_name"},
+			stdin:          "first_name,last_name,email\nA,X,a@github.io\nB,X,b@cnet.com\nC,Y,c@cnet.com\n",
+			expectedStdout: "Total number of customers: 2\nDomain: cnet.com, Customers: 1\nDomain: github.io, Customers: 1\n",
+		},
+		{
+			name:           "missing_input",
+			args:           []string{"-format", "text"},
+			expectedCode:   1,
+			expectedStderr: "-input flag is required",
+		},
+		{
+			name:           "missing_file",
+			args:           []string{"-input", filepath.Join(dir, "missing.csv")},
+			expectedCode:   1,
+			expectedStderr: "error processing file",
+		},
+		{
+			name:           "unsupported_format",
+			args:           []string{"-input", input, "-format", "xml"},
+			expectedCode:   1,
+			expectedStderr: "unsupported format: xml",
+		},
+		{
+			name:           "unknown_flag",
+			args:           []string{"-no-such-flag"},
+			expectedCode:   2,
+			expectedStderr: "flag provided but not defined: -no-such-flag",
+		},
+		{
+			name:           "validate",
+			args:           []string{"validate", "-input", input},
+			expectedCode:   1,
+			expectedStdout: "Customers: 3, Skipped rows: 1\n",
+			expectedStderr: "has 1 invalid rows",
+		},
+		{
+			name:           "help",
+			args:           []string{"help"},
+			expectedStdout: "Commands:\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			stdout, stderr, code := runCLI(t, tc.stdin, tc.args...)
+			if code != tc.expectedCode {
+				t.Errorf("exit code: %d, expected: %d (stderr: %s)", code, tc.expectedCode, stderr)
+			}
+			if !strings.Contains(stdout, tc.expectedStdout) {
+				t.Errorf("stdout %q, expected it to contain: %q", stdout, tc.expectedStdout)
+			}
+			if !strings.Contains(stderr, tc.expectedStderr) {
+				t.Errorf("stderr %q, expected it to contain: %q", stderr, tc.expectedStderr)
+			}
+		})
+	}
+
+	written, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	if expected := "domain,customers\ncnet.com,1\ngithub.io,2\n"; string(written) != expected {
+		t.Errorf("output file %q, expected: %q", written, expected)
+	}
+}
+
+func TestRun(t *testing.T) {
+	testCases := []struct {
+		name           string
+		args           []string
+		stdin          string
+		expectedCode   int
+		expectedStdout string
+		expectedStderr string
+	}{
+		{name: "help", args: []string{"help"}, expectedStdout: "Commands:\n"},
+		{name: "stdin_input", args: []string{"-input", "-", "-names-only"}, stdin: "first_name,last_name,email\nA,B,a@github.io\n", expectedStdout: "github.io\n"},
+		{name: "flag_help", args: []string{"merge", "-h"}, expectedStderr: "Usage:"},
+		{name: "missing_input", args: []string{}, expectedCode: 1, expectedStderr: "-input flag is required"},
+		{name: "bad_flag_value", args: []string{"-workers", "many"}, expectedCode: 2, expectedStderr: "invalid value"},
+		{name: "negative_flag", args: []string{"-workers", "-2"}, expectedCode: 2, expectedStderr: "invalid value -2 for flag -workers: must be 0 or more, e.g. -workers 0"},
+		{name: "negative_flag_usage", args: []string{"validate", "-skip-rows", "-1"}, expectedCode: 2, expectedStderr: "Examples:"},
+		{name: "merge_without_files", args: []string{"merge"}, expectedCode: 1, expectedStderr: "merge requires at least one JSON result file argument"},
+		{name: "diff_without_files", args: []string{"diff"}, expectedCode: 1, expectedStderr: "diff requires a before and an after JSON result file argument"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := run(tc.args, strings.NewReader(tc.stdin), &stdout, &stderr)
+			if code != tc.expectedCode {
+				t.Errorf("exit code: %d, expected: %d (stderr: %s)", code, tc.expectedCode, stderr.String())
+			}
+			if !strings.Contains(stdout.String(), tc.expectedStdout) {
+				t.Errorf("stdout %q, expected it to contain: %q", stdout.String(), tc.expectedStdout)
+			}
+			if !strings.Contains(stderr.String(), tc.expectedStderr) {
+				t.Errorf("stderr %q, expected it to contain: %q", stderr.String(), tc.expectedStderr)
+			}
+		})
+	}
+}
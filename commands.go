@@ -0,0 +1,332 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mikarwacki/TeamworkGoTests/customerimporter"
+)
+
+const COMMAND_PROCESS = "process"
+const COMMAND_MERGE = "merge"
+const COMMAND_DIFF = "diff"
+const COMMAND_VALIDATE = "validate"
+const COMMAND_HELP = "help"
+
+// command is a subcommand of the CLI with its own flag set, parsed by run.
+type command struct {
+	name    string
+	summary string
+	run     func(c *cli, args []string) error
+}
+
+var commands = []command{
+	{name: COMMAND_PROCESS, summary: "Count the customers per email domain of the input (default)", run: (*cli).runProcess},
+	{name: COMMAND_MERGE, summary: "Merge sorted JSON results into one", run: (*cli).runMerge},
+	{name: COMMAND_DIFF, summary: "Compare the domain counts of two JSON results", run: (*cli).runDiff},
+	{name: COMMAND_VALIDATE, summary: "Check that every row of the input has a valid email", run: (*cli).runValidate},
+}
+
+// cli holds the input and output streams of a run, so that tests can supply
+// and capture them.
+type cli struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+	logger *log.Logger
+}
+
+// exitError ends a run with code and no further message, e.g. after the
+// flag package already printed a usage error.
+type exitError struct {
+	code int
+}
+
+func (e exitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.code)
+}
+
+// run runs the command selected by args and returns the exit code: 0 on
+// success, 2 for invalid flags and 1 for any other error, which is written
+// to stderr.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	c := &cli{stdin: stdin, stdout: stdout, stderr: stderr, logger: log.New(stderr, "", log.LstdFlags)}
+	err := c.runCommand(args)
+	var exit exitError
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &exit):
+		return exit.code
+	default:
+		c.logger.Print(err)
+		return 1
+	}
+}
+
+// flagSet returns a flag set for the named command that reports parse
+// errors instead of exiting.
+func (c *cli) flagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	return fs
+}
+
+// nonNegativeFlags are the numeric flags of any command for which a
+// negative value makes no sense, checked by parseFlags.
+var nonNegativeFlags = []string{
+	"workers",
+	"retries",
+	"limit",
+	"skip-rows",
+	"bar-width",
+	"normalize-per",
+	"unique-memory-limit",
+	"max-email-length",
+	"top",
+}
+
+// parseFlags parses args into fs and checks the nonNegativeFlags it has. The
+// flag package already printed the usage or the parse error, so only the
+// exit code is left: 0 for -h and 2 otherwise, like flag.ExitOnError.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	err := fs.Parse(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return exitError{code: 0}
+	}
+	if err != nil {
+		return exitError{code: 2}
+	}
+	if err := checkNonNegative(fs); err != nil {
+		fmt.Fprintln(fs.Output(), err)
+		fs.Usage()
+		return exitError{code: 2}
+	}
+	return nil
+}
+
+// checkNonNegative returns an error for the first nonNegativeFlags value of
+// fs below zero.
+func checkNonNegative(fs *flag.FlagSet) error {
+	for _, name := range nonNegativeFlags {
+		f := fs.Lookup(name)
+		if f == nil {
+			continue
+		}
+		if value, ok := f.Value.(flag.Getter).Get().(int); ok && value < 0 {
+			return fmt.Errorf("invalid value %d for flag -%s: must be 0 or more, e.g. -%s %s", value, name, name, f.DefValue)
+		}
+	}
+	return nil
+}
+
+// lookupCommand returns the command named by the first argument and the
+// arguments after it, or the process command with all arguments when the
+// first one isn't a command name, so invocations without one keep working.
+func lookupCommand(args []string) (command, []string) {
+	if len(args) > 0 {
+		for _, cmd := range commands {
+			if cmd.name == args[0] {
+				return cmd, args[1:]
+			}
+		}
+	}
+	return commands[0], args
+}
+
+// runCommand runs the command selected by args, or lists the commands for
+// help.
+func (c *cli) runCommand(args []string) error {
+	if len(args) > 0 && args[0] == COMMAND_HELP {
+		writeCommands(c.stdout)
+		return nil
+	}
+	cmd, args := lookupCommand(args)
+	return cmd.run(c, args)
+}
+
+func writeCommands(w io.Writer) {
+	fmt.Fprintf(w, "Usage: %s [command] [flags]\n\nCommands:\n", os.Args[0])
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(w, "\nRun a command with -h for its flags.\n")
+}
+
+// runMerge is the merge command, the same as process -merge.
+func (c *cli) runMerge(args []string) error {
+	fs := c.flagSet(COMMAND_MERGE)
+	var (
+		format         = fs.String("format", customerimporter.FORMAT_TEXT, "Output format: "+strings.Join(customerimporter.FORMATS, ", "))
+		jsonFlat       = fs.Bool("json-flat", false, "Write -format json as the plain result struct instead of the summary report")
+		outputFilePath = fs.String("output", "", "Output file path (default stdout)")
+		fileMode       = fs.String("file-mode", "0644", "Octal permissions of a created output file, subject to the umask")
+		mkdir          = fs.Bool("mkdir", false, "Create missing parent directories of -output")
+		withMetadata   = fs.Bool("with-metadata", false, "Add a generated run ID and timestamp to the output")
+	)
+	fs.Usage = commandUsage(fs, "result.json...")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	if err := customerimporter.ValidateFormat(*format); err != nil {
+		return err
+	}
+	mode, err := parseFileMode(*fileMode)
+	if err != nil {
+		return err
+	}
+	outputOpts := customerimporter.OutputOptions{Format: *format, JSONFlat: *jsonFlat, FileMode: mode, MakeDirs: *mkdir, Stdout: c.stdout, Logger: c.logger}
+	if *withMetadata {
+		outputOpts.Metadata, err = customerimporter.NewRunMetadata()
+		if err != nil {
+			return err
+		}
+		c.logger.Printf("Run ID: %s", outputOpts.Metadata.RunID)
+	}
+	return mergeResults(fs.Args(), outputFilePath, outputOpts)
+}
+
+// mergeResults writes the merged JSON results at paths to outputFilePath.
+func mergeResults(paths []string, outputFilePath *string, outputOpts customerimporter.OutputOptions) error {
+	if len(paths) == 0 {
+		return errors.New("merge requires at least one JSON result file argument")
+	}
+	domainsCount, err := customerimporter.MergeResultFiles(paths...)
+	if err != nil {
+		return fmt.Errorf("error merging results: %v", err)
+	}
+	if err := customerimporter.WriteOutput(*domainsCount, outputFilePath, outputOpts); err != nil {
+		return outputError(err)
+	}
+	return nil
+}
+
+// runDiff is the diff command.
+func (c *cli) runDiff(args []string) error {
+	fs := c.flagSet(COMMAND_DIFF)
+	fs.Usage = commandUsage(fs, "before.json after.json")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 2 {
+		return errors.New("diff requires a before and an after JSON result file argument")
+	}
+	if err := diffResults(c.stdout, fs.Arg(0), fs.Arg(1)); err != nil {
+		return outputError(err)
+	}
+	return nil
+}
+
+// diffResults writes the changed domains between the JSON results at
+// beforePath and afterPath, which may be in any order. Grouped results are
+// rejected, as only their groups would be compared.
+func diffResults(w io.Writer, beforePath, afterPath string) error {
+	before, err := customerimporter.ReadResultFile(beforePath)
+	if err != nil {
+		return fmt.Errorf("error reading %s: %v", beforePath, err)
+	}
+	after, err := customerimporter.ReadResultFile(afterPath)
+	if err != nil {
+		return fmt.Errorf("error reading %s: %v", afterPath, err)
+	}
+	if before.Grouped() || after.Grouped() {
+		return errors.New("diff does not support grouped results with subdomains")
+	}
+	return customerimporter.WriteDiff(w, *before, *after)
+}
+
+// runValidate is the validate command. It fails when any row was skipped.
+func (c *cli) runValidate(args []string) error {
+	fs := c.flagSet(COMMAND_VALIDATE)
+	var (
+		inputFilePath = fs.String("input", "", "Input file path, http(s) URL or - for stdin")
+		inputFormat   = fs.String("input-format", "", "Input format: csv, csv.gz or jsonl for one JSON object per line (default: csv, gzip detected from the content)")
+		jsonKey       = fs.String("json-key", customerimporter.DEFAULT_JSON_KEY, "Field holding the email in -input-format jsonl objects")
+		delimiter     = fs.String("delimiter", ",", `Input field delimiter, a single character or "\t" for tab`)
+		emailCol      = fs.String("email-col", strconv.Itoa(customerimporter.EMAIL_IDX), `Zero-based index of the email column, negative to count from the end or "last"`)
+		emailHeader   = fs.String("email-header", "", "Header name of the email column, used instead of -email-col")
+		skipRows      = fs.Int("skip-rows", 0, "Number of preamble rows to discard before the header")
+	)
+	fs.Usage = commandUsage(fs, "", "-input customers.csv", "-input customers.csv -email-header email")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	if *inputFilePath == "" {
+		return errors.New("-input flag is required")
+	}
+	comma, err := parseDelimiter(*delimiter)
+	if err != nil {
+		return err
+	}
+	emailIdx, err := parseColumn(*emailCol)
+	if err != nil {
+		return err
+	}
+
+	opts := []customerimporter.Option{
+		customerimporter.WithDelimiter(comma),
+		customerimporter.WithEmailColumn(emailIdx),
+		customerimporter.WithEmailHeader(*emailHeader),
+		customerimporter.WithSkipRows(*skipRows),
+		customerimporter.WithAllowEmpty(true),
+		customerimporter.WithLogger(log.New(io.Discard, "", 0)),
+	}
+	if *inputFormat != "" {
+		opts = append(opts, customerimporter.WithInputFormat(*inputFormat), customerimporter.WithJSONKey(*jsonKey))
+	}
+	return validateInput(c.stdout, c.stdin, customerimporter.NewImporter(opts...), *inputFilePath)
+}
+
+// validateInput imports path, or stdin for "-", with importer and writes its
+// warnings to w. It returns an error when any row was skipped.
+func validateInput(w io.Writer, stdin io.Reader, importer *customerimporter.Importer, path string) error {
+	var domainsCount *customerimporter.DomainsCount
+	var err error
+	if path == "-" {
+		domainsCount, err = importer.Import(stdin)
+	} else {
+		domainsCount, err = importer.ImportFile(path)
+	}
+	if err != nil {
+		return fmt.Errorf("error processing file: %v", err)
+	}
+	if err := writeWarnings(w, *domainsCount); err != nil {
+		return err
+	}
+
+	skipped := 0
+	for _, count := range domainsCount.WarningCounts {
+		skipped += count
+	}
+	if _, err := fmt.Fprintf(w, "Customers: %d, Skipped rows: %d\n", domainsCount.TotalCount, skipped); err != nil {
+		return err
+	}
+	if skipped > 0 {
+		return fmt.Errorf("%s has %d invalid rows", path, skipped)
+	}
+	return nil
+}
+
+// commandUsage returns a usage function listing the arguments and flags of
+// fs, followed by the example invocations if any.
+func commandUsage(fs *flag.FlagSet, arguments string, examples ...string) func() {
+	return func() {
+		fmt.Fprintln(fs.Output(), strings.TrimSpace(fmt.Sprintf("Usage: %s %s [flags] %s", os.Args[0], fs.Name(), arguments)))
+		fs.PrintDefaults()
+		if len(examples) > 0 {
+			fmt.Fprintf(fs.Output(), "\nExamples:\n")
+			for _, example := range examples {
+				fmt.Fprintf(fs.Output(), "  %s %s %s\n", os.Args[0], fs.Name(), example)
+			}
+		}
+	}
+}
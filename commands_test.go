@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/mikarwacki/TeamworkGoTests/customerimporter"
+)
+
+func TestLookupCommand(t *testing.T) {
+	testCases := []struct {
+		args         []string
+		expectedName string
+		expectedArgs []string
+	}{
+		{args: nil, expectedName: COMMAND_PROCESS},
+		{args: []string{"-input", "customers.csv"}, expectedName: COMMAND_PROCESS, expectedArgs: []string{"-input", "customers.csv"}},
+		{args: []string{"process", "-input", "customers.csv"}, expectedName: COMMAND_PROCESS, expectedArgs: []string{"-input", "customers.csv"}},
+		{args: []string{"merge", "a.json", "b.json"}, expectedName: COMMAND_MERGE, expectedArgs: []string{"a.json", "b.json"}},
+		{args: []string{"diff", "a.json", "b.json"}, expectedName: COMMAND_DIFF, expectedArgs: []string{"a.json", "b.json"}},
+		{args: []string{"validate"}, expectedName: COMMAND_VALIDATE, expectedArgs: []string{}},
+	}
+
+	for _, tc := range testCases {
+		cmd, args := lookupCommand(tc.args)
+		if cmd.name != tc.expectedName {
+			t.Errorf("lookupCommand(%q): command %s, expected: %s", tc.args, cmd.name, tc.expectedName)
+		}
+		if !slices.Equal(args, tc.expectedArgs) {
+			t.Errorf("lookupCommand(%q): args %q, expected: %q", tc.args, args, tc.expectedArgs)
+		}
+	}
+}
+
+func TestDiffResults(t *testing.T) {
+	dir := t.TempDir()
+	results := map[string]customerimporter.DomainsCount{
+		"before.json":  {DomainStats: []customerimporter.DomainStat{{Name: "cnet.com", Count: 1}, {Name: "github.io", Count: 2}}, TotalCount: 3},
+		"after.json":   {DomainStats: []customerimporter.DomainStat{{Name: "github.io", Count: 4}}, TotalCount: 4},
+		"grouped.json": {DomainStats: []customerimporter.DomainStat{{Name: "io", Count: 4, Subdomains: []customerimporter.DomainStat{{Name: "github.io", Count: 4}}}}, TotalCount: 4},
+	}
+	// Ordered by count, the domains are out of name order.
+	sortKeys, err := customerimporter.ParseSortKeys("count")
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	for name, domainsCount := range results {
+		var buf bytes.Buffer
+		if err := customerimporter.WriteTo(&buf, domainsCount, customerimporter.OutputOptions{Format: customerimporter.FORMAT_JSON, SortKeys: sortKeys}); err != nil {
+			t.Fatalf("unexpected error occured: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), buf.Bytes(), 0644); err != nil {
+			t.Fatalf("unexpected error occured: %v", err)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := diffResults(&out, filepath.Join(dir, "before.json"), filepath.Join(dir, "after.json")); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	expected := "Domain: cnet.com, Before: 1, After: 0, Change: -1\n" +
+		"Domain: github.io, Before: 2, After: 4, Change: +2\n" +
+		"Total: Before: 3, After: 4, Change: +1\n"
+	if out.String() != expected {
+		t.Errorf("Output: %q, expected: %q", out.String(), expected)
+	}
+
+	if err := diffResults(&out, filepath.Join(dir, "missing.json"), filepath.Join(dir, "after.json")); err == nil {
+		t.Error("expected an error for a missing result file")
+	}
+	if err := diffResults(&out, filepath.Join(dir, "before.json"), filepath.Join(dir, "grouped.json")); err == nil {
+		t.Error("expected an error for a grouped result file")
+	}
+}
+
+func TestValidateInput(t *testing.T) {
+	testCases := []struct {
+		name        string
+		csvInput    string
+		expected    string
+		expectError bool
+	}{
+		{name: "valid", csvInput: "email\na@cnet.com\nb@github.io\n", expected: "Customers: 2, Skipped rows: 0\n"},
+		{
+			name:        "invalid",
+			csvInput:    "email\na@cnet.com\nbad\n",
+			expected:    "Warning: line 3: bad_email \"bad\"\nWarnings: 1 bad_email\nCustomers: 1, Skipped rows: 1\n",
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		path := filepath.Join(t.TempDir(), "customers.csv")
+		if err := os.WriteFile(path, []byte(tc.csvInput), 0644); err != nil {
+			t.Fatalf("%s: unexpected error occured: %v", tc.name, err)
+		}
+		importer := customerimporter.NewImporter(customerimporter.WithEmailColumn(0), customerimporter.WithAllowEmpty(true), customerimporter.WithLogger(log.New(io.Discard, "", 0)))
+
+		var out bytes.Buffer
+		err := validateInput(&out, nil, importer, path)
+		if tc.expectError && err == nil {
+			t.Errorf("%s: expected an error", tc.name)
+		}
+		if !tc.expectError && err != nil {
+			t.Errorf("%s: unexpected error occured: %v", tc.name, err)
+		}
+		if out.String() != tc.expected {
+			t.Errorf("%s: output %q, expected: %q", tc.name, out.String(), tc.expected)
+		}
+	}
+}
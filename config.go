@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mikarwacki/TeamworkGoTests/customerimporter"
+)
+
+// applyConfigFile sets flags from the JSON config file at path. Top-level keys
+// are flag names, e.g. "delimiter" or "role-accounts", and the "columns"
+// object maps column names to indexes, e.g. {"email": 2} sets -email-col.
+// Flags given explicitly on the command line take precedence over the file.
+// The "files" object maps input files to their own "email" column, an index
+// or a header name, which is returned for multi-file inputs.
+func applyConfigFile(fs *flag.FlagSet, path string) (map[string]customerimporter.EmailColumn, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading config file: %v", err)
+	}
+
+	var config map[string]any
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("error parsing config file %s: %v", path, err)
+	}
+
+	var fileColumns map[string]customerimporter.EmailColumn
+	values := make(map[string]any, len(config))
+	for key, value := range config {
+		if key == "files" {
+			if fileColumns, err = configFileColumns(value); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if key != "columns" {
+			values[key] = value
+			continue
+		}
+		columns, ok := value.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("config option \"columns\" must be an object")
+		}
+		for column, idx := range columns {
+			values[column+"-col"] = idx
+		}
+	}
+
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+
+	for name, value := range values {
+		if fs.Lookup(name) == nil || name == "config" {
+			return nil, fmt.Errorf("unknown config option %q", name)
+		}
+		if explicit[name] {
+			continue
+		}
+		str, err := configValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("config option %q: %v", name, err)
+		}
+		if err := fs.Set(name, str); err != nil {
+			return nil, fmt.Errorf("config option %q: %v", name, err)
+		}
+	}
+
+	return fileColumns, nil
+}
+
+// configFileColumns reads the "files" config option, e.g.
+// {"crm.csv": {"email": 1}, "billing.csv": {"email": "E-mail"}}.
+func configFileColumns(value any) (map[string]customerimporter.EmailColumn, error) {
+	files, ok := value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("config option \"files\" must be an object")
+	}
+	fileColumns := make(map[string]customerimporter.EmailColumn, len(files))
+	for file, columns := range files {
+		columns, ok := columns.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("config option \"files\": %s must be an object", file)
+		}
+		for column, spec := range columns {
+			if column != "email" {
+				return nil, fmt.Errorf("config option \"files\": %s: unsupported column %q", file, column)
+			}
+			switch spec := spec.(type) {
+			case float64:
+				if spec != float64(int(spec)) {
+					return nil, fmt.Errorf("config option \"files\": %s: email column %v is not an index", file, spec)
+				}
+				fileColumns[file] = customerimporter.EmailColumn{Index: int(spec)}
+			case string:
+				fileColumns[file] = customerimporter.EmailColumn{Header: spec}
+			default:
+				return nil, fmt.Errorf("config option \"files\": %s: email column must be an index or a header name", file)
+			}
+		}
+	}
+	return fileColumns, nil
+}
+
+// configValue converts a decoded JSON value to the string form accepted by
+// flag.Value.Set. Arrays become comma-separated lists.
+func configValue(value any) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			str, err := configValue(item)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, str)
+		}
+		return strings.Join(items, ","), nil
+	default:
+		return "", fmt.Errorf("unsupported value %v", value)
+	}
+}
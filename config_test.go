@@ -0,0 +1,92 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/mikarwacki/TeamworkGoTests/customerimporter"
+)
+
+func TestApplyConfigFile(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	err := os.WriteFile(configPath, []byte(`{
+	"columns": {"email": 4},
+	"delimiter": ";",
+	"natural-sort": true,
+	"workers": 3,
+	"role-accounts": ["info", "support"],
+	"files": {"crm.csv": {"email": 1}, "billing.csv": {"email": "E-mail"}}
+}`), 0644)
+	if err != nil {
+		t.Fatalf("error writing config file: %v", err)
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	emailCol := fs.Int("email-col", 2, "")
+	delimiter := fs.String("delimiter", ",", "")
+	naturalSort := fs.Bool("natural-sort", false, "")
+	workers := fs.Int("workers", 0, "")
+	roleAccounts := fs.String("role-accounts", "", "")
+	if err := fs.Parse([]string{"-delimiter", "|"}); err != nil {
+		t.Fatalf("error parsing flags: %v", err)
+	}
+
+	fileColumns, err := applyConfigFile(fs, configPath)
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+
+	if *emailCol != 4 {
+		t.Errorf("email-col: %d, expected: 4", *emailCol)
+	}
+	if *delimiter != "|" {
+		t.Errorf("delimiter: %q, expected the command line value %q", *delimiter, "|")
+	}
+	if !*naturalSort {
+		t.Error("natural-sort: false, expected: true")
+	}
+	if *workers != 3 {
+		t.Errorf("workers: %d, expected: 3", *workers)
+	}
+	if *roleAccounts != "info,support" {
+		t.Errorf("role-accounts: %q, expected: %q", *roleAccounts, "info,support")
+	}
+	expectedColumns := map[string]customerimporter.EmailColumn{"crm.csv": {Index: 1}, "billing.csv": {Header: "E-mail"}}
+	if !reflect.DeepEqual(fileColumns, expectedColumns) {
+		t.Errorf("files: %v, expected: %v", fileColumns, expectedColumns)
+	}
+}
+
+func TestApplyConfigFile_UnknownOption(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"no-such-flag": 1}`), 0644); err != nil {
+		t.Fatalf("error writing config file: %v", err)
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	if _, err := applyConfigFile(fs, configPath); err == nil {
+		t.Error("error expected, got nil")
+	}
+}
+
+func TestApplyConfigFile_InvalidFiles(t *testing.T) {
+	for _, config := range []string{
+		`{"files": ["crm.csv"]}`,
+		`{"files": {"crm.csv": 1}}`,
+		`{"files": {"crm.csv": {"name": 1}}}`,
+		`{"files": {"crm.csv": {"email": 1.5}}}`,
+		`{"files": {"crm.csv": {"email": true}}}`,
+	} {
+		configPath := filepath.Join(t.TempDir(), "config.json")
+		if err := os.WriteFile(configPath, []byte(config), 0644); err != nil {
+			t.Fatalf("error writing config file: %v", err)
+		}
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		if _, err := applyConfigFile(fs, configPath); err == nil {
+			t.Errorf("%s: error expected, got nil", config)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package customerimporter
+
+import (
+	"hash/maphash"
+	"sync"
+)
+
+const AGGREGATION_SHARDS = 64
+
+// shardedCounter counts domains from many goroutines at once. Domains are
+// spread over independently locked shards by hash, so workers rarely contend
+// and no single aggregating goroutine becomes the bottleneck.
+type shardedCounter struct {
+	seed   maphash.Seed
+	shards [AGGREGATION_SHARDS]counterShard
+}
+
+type counterShard struct {
+	mu        sync.Mutex
+	domainMap map[string]int
+	roleMap   map[string]int
+	firstSeen map[string]int
+	total     int
+}
+
+func newShardedCounter() *shardedCounter {
+	counter := &shardedCounter{seed: maphash.MakeSeed()}
+	for i := range counter.shards {
+		counter.shards[i].domainMap = make(map[string]int)
+		counter.shards[i].roleMap = make(map[string]int)
+		counter.shards[i].firstSeen = make(map[string]int)
+	}
+	return counter
+}
+
+func (c *shardedCounter) add(domain string, role bool) {
+	c.addAt(domain, role, 0, 1)
+}
+
+// addAt counts weight customers of domain like add and, unless line is 0,
+// records it with seen under the same lock.
+func (c *shardedCounter) addAt(domain string, role bool, line, weight int) {
+	shard := &c.shards[maphash.String(c.seed, domain)%AGGREGATION_SHARDS]
+	shard.mu.Lock()
+	if first, ok := shard.firstSeen[domain]; line > 0 && (!ok || line < first) {
+		shard.firstSeen[domain] = line
+	}
+	shard.domainMap[domain] += weight
+	if role {
+		shard.roleMap[domain] += weight
+	}
+	shard.total += weight
+	shard.mu.Unlock()
+}
+
+// merge combines the shards into a single domain map, role account map and
+// total. It must only be called once all writers are done.
+func (c *shardedCounter) merge() (map[string]int, map[string]int, int) {
+	size := 0
+	for i := range c.shards {
+		size += len(c.shards[i].domainMap)
+	}
+
+	domainMap := make(map[string]int, size)
+	roleMap := make(map[string]int)
+	total := 0
+	for i := range c.shards {
+		for domain, count := range c.shards[i].domainMap {
+			domainMap[domain] = count
+		}
+		for domain, count := range c.shards[i].roleMap {
+			roleMap[domain] = count
+		}
+		total += c.shards[i].total
+	}
+	return domainMap, roleMap, total
+}
+
+// seen records line as the first line of domain unless an earlier line
+// already is. Workers read rows out of order, so a later call may lower it.
+func (c *shardedCounter) seen(domain string, line int) {
+	shard := &c.shards[maphash.String(c.seed, domain)%AGGREGATION_SHARDS]
+	shard.mu.Lock()
+	if first, ok := shard.firstSeen[domain]; !ok || line < first {
+		shard.firstSeen[domain] = line
+	}
+	shard.mu.Unlock()
+}
+
+// firstSeen returns the first line of every domain passed to seen. It must
+// only be called once all writers are done.
+func (c *shardedCounter) firstSeen() map[string]int {
+	firstSeen := make(map[string]int)
+	for i := range c.shards {
+		for domain, line := range c.shards[i].firstSeen {
+			firstSeen[domain] = line
+		}
+	}
+	return firstSeen
+}
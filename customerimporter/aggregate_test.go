@@ -0,0 +1,222 @@
+package customerimporter
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func generateCsv(rows, domains int) string {
+	var sb strings.Builder
+	sb.WriteString("first_name,last_name,email,gender,ip_address\n")
+	for i := range rows {
+		email := fmt.Sprintf("user%d@Domain%d.com", i, i%domains)
+		if i%97 == 0 {
+			email = "invalid-email"
+		}
+		fmt.Fprintf(&sb, "Name%d,Surname%d,%s,Female,127.0.0.1\n", i, i, email)
+	}
+	return sb.String()
+}
+
+// serialDomainCounts is a straightforward single goroutine aggregation used
+// as the reference result for the concurrent pipeline.
+func serialDomainCounts(t *testing.T, csvInput string) (map[string]int, int) {
+	t.Helper()
+
+	csvreader := csv.NewReader(strings.NewReader(csvInput))
+	if _, err := csvreader.Read(); err != nil {
+		t.Fatalf("error reading header: %v", err)
+	}
+
+	domainMap := make(map[string]int)
+	total := 0
+	for {
+		records, err := csvreader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("error reading csv: %v", err)
+		}
+		if domain := extractDomain(strings.TrimSpace(records[EMAIL_IDX])); domain != "" {
+			domainMap[domain]++
+			total++
+		}
+	}
+	return domainMap, total
+}
+
+func TestProcessCsv_MatchesSerialAggregation(t *testing.T) {
+	csvInput := generateCsv(20_000, 150)
+	expectedMap, expectedTotal := serialDomainCounts(t, csvInput)
+
+	for _, numWorkers := range []int{1, 4, 16} {
+		t.Run(fmt.Sprintf("%d_workers", numWorkers), func(t *testing.T) {
+			imp := NewImporter(WithWorkers(numWorkers), WithLogger(log.New(io.Discard, "", 0)))
+			result, err := imp.processCsv(strings.NewReader(csvInput))
+			if err != nil {
+				t.Fatalf("unexpected error occured: %v", err)
+			}
+
+			if result.totalCustomers != expectedTotal {
+				t.Errorf("Total count: %d, expected: %d", result.totalCustomers, expectedTotal)
+			}
+			if !maps.Equal(result.domainMap, expectedMap) {
+				t.Errorf("domain counts differ from serial aggregation")
+			}
+		})
+	}
+}
+
+func TestShardedCounter_ConcurrentAdds(t *testing.T) {
+	counter := newShardedCounter()
+	domains := []string{"github.io", "cnet.com", "example.org"}
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 1000 {
+				for _, domain := range domains {
+					counter.add(domain, false)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	domainMap, _, total := counter.merge()
+	if total != 8*1000*len(domains) {
+		t.Errorf("Total count: %d, expected: %d", total, 8*1000*len(domains))
+	}
+	for _, domain := range domains {
+		if domainMap[domain] != 8*1000 {
+			t.Errorf("Domain %s count: %d, expected: %d", domain, domainMap[domain], 8*1000)
+		}
+	}
+}
+
+func BenchmarkProcessCsv(b *testing.B) {
+	csvInput := generateCsv(100_000, 1000)
+	imp := NewImporter(WithLogger(log.New(io.Discard, "", 0)))
+	b.ResetTimer()
+	for range b.N {
+		if _, err := imp.processCsv(strings.NewReader(csvInput)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkImportCSV_ReuseRecord(b *testing.B) {
+	csvInput := generateCsv(100_000, 1000)
+	imp := NewImporter(WithLogger(log.New(io.Discard, "", 0)))
+	for _, reuse := range []bool{false, true} {
+		b.Run(fmt.Sprintf("reuse_%t", reuse), func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				csvreader := csv.NewReader(strings.NewReader(csvInput))
+				csvreader.ReuseRecord = reuse
+				if _, err := imp.ImportCSV(csvreader); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// csvGenerator reads like generateCsv without holding the input in memory,
+// and samples the live heap every MEMORY_SAMPLE_ROWS rows.
+type csvGenerator struct {
+	rows, domains int
+	row           int
+	pending       []byte
+	peakHeap      uint64
+}
+
+const MEMORY_SAMPLE_ROWS = 50_000
+
+func (g *csvGenerator) Read(p []byte) (int, error) {
+	for len(g.pending) == 0 {
+		if g.row > g.rows {
+			return 0, io.EOF
+		}
+		if g.row == 0 {
+			g.pending = []byte("first_name,last_name,email,gender,ip_address\n")
+		} else {
+			g.pending = fmt.Appendf(g.pending, "Name%d,Surname%d,user%d@Domain%d.com,Female,127.0.0.1\n", g.row, g.row, g.row, g.row%g.domains)
+		}
+		if g.row%MEMORY_SAMPLE_ROWS == 0 {
+			var stats runtime.MemStats
+			runtime.GC()
+			runtime.ReadMemStats(&stats)
+			g.peakHeap = max(g.peakHeap, stats.HeapAlloc)
+		}
+		g.row++
+	}
+	n := copy(p, g.pending)
+	g.pending = g.pending[n:]
+	return n, nil
+}
+
+// TestImport_MemoryBoundedByDomains checks that the live heap doesn't grow
+// with the number of rows, as only the domain counts are kept.
+func TestImport_MemoryBoundedByDomains(t *testing.T) {
+	if testing.Short() {
+		t.Skip("generates millions of rows")
+	}
+	const DOMAINS = 1000
+	// 2M rows are ~100 MiB of input, any per row accumulation exceeds this.
+	const MAX_GROWTH = 8 << 20
+
+	testCases := []struct {
+		name string
+		opts []Option
+	}{
+		{name: "csv"},
+		{name: "fast parse", opts: []Option{WithFastParse(true)}},
+	}
+
+	for _, tc := range testCases {
+		peakHeap := func(rows int) uint64 {
+			imp := NewImporter(append([]Option{WithLogger(log.New(io.Discard, "", 0))}, tc.opts...)...)
+			generator := &csvGenerator{rows: rows, domains: DOMAINS}
+			domainsCount, err := imp.Import(generator)
+			if err != nil {
+				t.Fatalf("%s: unexpected error occured: %v", tc.name, err)
+			}
+			if domainsCount.TotalCount != rows {
+				t.Errorf("%s: total count: %d, expected: %d", tc.name, domainsCount.TotalCount, rows)
+			}
+			return generator.peakHeap
+		}
+
+		small, large := peakHeap(200_000), peakHeap(2_000_000)
+		if large > small+MAX_GROWTH {
+			t.Errorf("%s: peak heap %d for 2M rows, expected: at most %d over the %d for 200k rows", tc.name, large, MAX_GROWTH, small)
+		}
+	}
+}
+
+func TestShardedCounter_FirstSeen(t *testing.T) {
+	counter := newShardedCounter()
+	counter.addAt("github.io", false, 7, 1)
+	counter.addAt("github.io", false, 3, 1)
+	counter.addAt("github.io", false, 5, 1)
+	counter.seen("cnet.com", 4)
+	counter.seen("cnet.com", 9)
+	counter.add("zoho.com", false)
+
+	expected := map[string]int{"github.io": 3, "cnet.com": 4}
+	if firstSeen := counter.firstSeen(); !reflect.DeepEqual(firstSeen, expected) {
+		t.Errorf("First seen: %v, expected: %v", firstSeen, expected)
+	}
+}
@@ -0,0 +1,49 @@
+package customerimporter
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"path/filepath"
+)
+
+// CHECKSUM_LINE_FORMAT is the last line of text output with Checksum, the
+// SHA-256 of everything before it.
+const CHECKSUM_LINE_FORMAT = "SHA-256: %x\n"
+
+// CHECKSUM_EXTENSION is appended to the output file name for the sidecar file
+// holding the checksum of formats that can't take a trailing line.
+const CHECKSUM_EXTENSION = ".sha256"
+
+// inlineChecksum reports whether the Checksum of opts is written as a
+// trailing line, which only text reports can take without breaking parsers.
+func inlineChecksum(opts OutputOptions) bool {
+	return opts.Checksum && (opts.Format == "" || opts.Format == FORMAT_TEXT) && !opts.NamesOnly && !opts.Top
+}
+
+// writeWithChecksum writes domainsCount to w like WriteTo, followed by the
+// CHECKSUM_LINE_FORMAT line.
+func writeWithChecksum(w io.Writer, domainsCount DomainsCount, opts OutputOptions) error {
+	hash := sha256.New()
+	opts.Checksum = false
+	if err := WriteTo(io.MultiWriter(w, hash), domainsCount, opts); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, CHECKSUM_LINE_FORMAT, hash.Sum(nil))
+	return err
+}
+
+// createChecksumFile writes sum for the output file at filePath in the format
+// of sha256sum to the checksum file next to it, to be committed along with the
+// output file.
+func createChecksumFile(filePath string, sum []byte, opts OutputOptions) (*OutputFile, error) {
+	file, err := CreateOutputFile(filePath+CHECKSUM_EXTENSION, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error creating checksum file: %v", err)
+	}
+	if _, err := fmt.Fprintf(file, "%x  %s\n", sum, filepath.Base(filePath)); err != nil {
+		file.Discard()
+		return nil, fmt.Errorf("error writing checksum file: %v", err)
+	}
+	return file, nil
+}
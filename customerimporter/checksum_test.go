@@ -0,0 +1,103 @@
+package customerimporter
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var checksumDomainsCount = DomainsCount{
+	TotalCount:  3,
+	DomainStats: []DomainStat{{Name: "cnet.com", Count: 1}, {Name: "github.io", Count: 2}},
+}
+
+func TestWriteTo_Checksum(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteTo(&buf, checksumDomainsCount, OutputOptions{Checksum: true}); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+
+	output := buf.String()
+	idx := strings.LastIndex(output, "SHA-256: ")
+	if idx < 0 {
+		t.Fatalf("output %q, expected a checksum line", output)
+	}
+	expected := fmt.Sprintf(CHECKSUM_LINE_FORMAT, sha256.Sum256([]byte(output[:idx])))
+	if output[idx:] != expected {
+		t.Errorf("checksum line %q, expected: %q", output[idx:], expected)
+	}
+}
+
+func TestWriteFile_ChecksumSidecar(t *testing.T) {
+	testCases := []struct {
+		name string
+		file string
+		opts OutputOptions
+	}{
+		{name: "json", file: "result.json", opts: OutputOptions{Format: FORMAT_JSON, Checksum: true}},
+		{name: "gzip", file: "result.csv.gz", opts: OutputOptions{Format: FORMAT_CSV, Checksum: true}},
+	}
+
+	for _, tc := range testCases {
+		filePath := filepath.Join(t.TempDir(), tc.file)
+		if err := WriteOutput(checksumDomainsCount, &filePath, tc.opts); err != nil {
+			t.Fatalf("%s: unexpected error occured: %v", tc.name, err)
+		}
+
+		var content io.Reader
+		file, err := os.Open(filePath)
+		if err != nil {
+			t.Fatalf("%s: unexpected error occured: %v", tc.name, err)
+		}
+		defer file.Close()
+		content = file
+		if strings.HasSuffix(tc.file, GZIP_EXTENSION) {
+			if content, err = gzip.NewReader(file); err != nil {
+				t.Fatalf("%s: unexpected error occured: %v", tc.name, err)
+			}
+		}
+		data, err := io.ReadAll(content)
+		if err != nil {
+			t.Fatalf("%s: unexpected error occured: %v", tc.name, err)
+		}
+
+		sidecar, err := os.ReadFile(filePath + CHECKSUM_EXTENSION)
+		if err != nil {
+			t.Fatalf("%s: unexpected error occured: %v", tc.name, err)
+		}
+		expected := fmt.Sprintf("%x  %s\n", sha256.Sum256(data), tc.file)
+		if string(sidecar) != expected {
+			t.Errorf("%s: checksum file %q, expected: %q", tc.name, sidecar, expected)
+		}
+		if bytes.Contains(data, []byte("SHA-256")) {
+			t.Errorf("%s: output %q, expected no checksum line", tc.name, data)
+		}
+		entries, err := os.ReadDir(filepath.Dir(filePath))
+		if err != nil {
+			t.Fatalf("%s: unexpected error occured: %v", tc.name, err)
+		}
+		if len(entries) != 2 {
+			t.Errorf("%s: files: %d, expected: 2, the output and its checksum", tc.name, len(entries))
+		}
+	}
+}
+
+func TestWriteOutput_StdoutChecksumLogged(t *testing.T) {
+	var stdout, logs bytes.Buffer
+	opts := OutputOptions{Format: FORMAT_JSON, Checksum: true, Stdout: &stdout, Logger: log.New(&logs, "", 0)}
+	if err := WriteOutput(checksumDomainsCount, nil, opts); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+
+	expected := fmt.Sprintf("Output "+CHECKSUM_LINE_FORMAT, sha256.Sum256(stdout.Bytes()))
+	if logs.String() != expected {
+		t.Errorf("logs %q, expected: %q", logs.String(), expected)
+	}
+}
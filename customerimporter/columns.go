@@ -0,0 +1,50 @@
+package customerimporter
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// resolveColumn returns the index of the header column called name, compared
+// ignoring case and surrounding whitespace. A name that matches several
+// columns is an error, since picking either could count the wrong data.
+func resolveColumn(header []string, name string) (int, error) {
+	idx := -1
+	for i, column := range header {
+		if !strings.EqualFold(strings.TrimSpace(column), strings.TrimSpace(name)) {
+			continue
+		}
+		if idx >= 0 {
+			return 0, fmt.Errorf("duplicate header column %q at indexes %d and %d, specify the column index instead", name, idx, i)
+		}
+		idx = i
+	}
+	if idx < 0 {
+		return 0, fmt.Errorf("header column %q not found", name)
+	}
+	return idx, nil
+}
+
+// matchColumn returns the index of the first header column matching one of
+// candidates, tried in order. Names are compared ignoring case and anything
+// but letters and digits, so "E-mail" matches "email".
+func matchColumn(header []string, candidates []string) (int, error) {
+	for _, candidate := range candidates {
+		for i, column := range header {
+			if normalizeColumn(column) == normalizeColumn(candidate) {
+				return i, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("no header column matches any of %q", candidates)
+}
+
+func normalizeColumn(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, name)
+}
@@ -0,0 +1,139 @@
+package customerimporter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResolveColumn(t *testing.T) {
+	testCases := []struct {
+		name        string
+		header      []string
+		column      string
+		expectedIdx int
+		expectError bool
+	}{
+		{
+			name:        "exact",
+			header:      []string{"first_name", "last_name", "email"},
+			column:      "email",
+			expectedIdx: 2,
+		},
+		{
+			name:        "case_and_whitespace",
+			header:      []string{"first_name", " Email ", "gender"},
+			column:      "EMAIL",
+			expectedIdx: 1,
+		},
+		{
+			name:        "missing",
+			header:      []string{"first_name", "last_name"},
+			column:      "email",
+			expectError: true,
+		},
+		{
+			name:        "duplicate",
+			header:      []string{"email", "first_name", "Email"},
+			column:      "email",
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			idx, err := resolveColumn(tc.header, tc.column)
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("error expected, got index %d", idx)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error occured: %v", err)
+			}
+			if idx != tc.expectedIdx {
+				t.Errorf("resolveColumn = %d; want %d", idx, tc.expectedIdx)
+			}
+		})
+	}
+}
+
+func TestImporter_DuplicateEmailHeader(t *testing.T) {
+	csvInput := `email,first_name,email
+a@github.io,Mildred,b@cnet.com`
+
+	_, err := NewImporter(WithEmailHeader("email")).Import(strings.NewReader(csvInput))
+	if err == nil || !strings.Contains(err.Error(), "duplicate header column") {
+		t.Errorf("expected duplicate header error, got: %v", err)
+	}
+
+	domainsCount, err := NewImporter(WithEmailColumn(2)).Import(strings.NewReader(csvInput))
+	if err != nil {
+		t.Fatalf("unexpected error occured with an explicit index: %v", err)
+	}
+	if len(domainsCount.DomainStats) != 1 || domainsCount.DomainStats[0].Name != "cnet.com" {
+		t.Errorf("Domain stats: %v, expected cnet.com only", domainsCount.DomainStats)
+	}
+}
+
+func TestMatchColumn(t *testing.T) {
+	candidates := []string{"email", "email_address", "mail"}
+	testCases := []struct {
+		name        string
+		header      []string
+		expectedIdx int
+		expectError bool
+	}{
+		{
+			name:        "separator",
+			header:      []string{"first_name", "E-mail", "gender"},
+			expectedIdx: 1,
+		},
+		{
+			name:        "second_candidate",
+			header:      []string{"first_name", "Email Address"},
+			expectedIdx: 1,
+		},
+		{
+			name:        "candidate_order",
+			header:      []string{"mail", "EMAIL"},
+			expectedIdx: 1,
+		},
+		{
+			name:        "none",
+			header:      []string{"first_name", "contact"},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			idx, err := matchColumn(tc.header, candidates)
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("error expected, got index %d", idx)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error occured: %v", err)
+			}
+			if idx != tc.expectedIdx {
+				t.Errorf("matchColumn = %d; want %d", idx, tc.expectedIdx)
+			}
+		})
+	}
+}
+
+func TestImporter_EmailHeaderCandidates(t *testing.T) {
+	csvInput := `name,E_Mail
+Mildred,a@github.io`
+
+	domainsCount, err := NewImporter(WithEmailHeaderCandidates("email", "mail")).Import(strings.NewReader(csvInput))
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	if len(domainsCount.DomainStats) != 1 || domainsCount.DomainStats[0].Name != "github.io" {
+		t.Errorf("Domain stats: %v, expected github.io only", domainsCount.DomainStats)
+	}
+}
@@ -0,0 +1,97 @@
+package customerimporter
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const COVERAGE_LINE_FORMAT = "Domain: %s, Customers: %d, Status: %s\n"
+const COVERAGE_SUMMARY_FORMAT = "Coverage: %d of %d reference domains present\n"
+
+const COVERAGE_PRESENT = "present"
+const COVERAGE_MISSING = "missing"
+
+// CoverageEntry is a reference domain with its customer count, zero when it
+// is missing from the input.
+type CoverageEntry struct {
+	Domain  string `json:"domain"`
+	Count   int    `json:"count"`
+	Present bool   `json:"present"`
+}
+
+// Status returns COVERAGE_PRESENT or COVERAGE_MISSING.
+func (e CoverageEntry) Status() string {
+	if e.Present {
+		return COVERAGE_PRESENT
+	}
+	return COVERAGE_MISSING
+}
+
+type coverageReport struct {
+	Present   int             `json:"present"`
+	Missing   int             `json:"missing"`
+	Reference []CoverageEntry `json:"reference"`
+}
+
+// Coverage looks up every reference domain among domainStats, ignoring case,
+// in the order of reference. Repeated reference domains are listed once.
+func Coverage(domainStats []DomainStat, reference []string) []CoverageEntry {
+	counts := make(map[string]int, len(domainStats))
+	for _, domainStat := range domainStats {
+		counts[strings.ToLower(domainStat.Name)] += domainStat.Count
+	}
+
+	listed := make(map[string]bool, len(reference))
+	entries := make([]CoverageEntry, 0, len(reference))
+	for _, domain := range reference {
+		key := strings.ToLower(strings.TrimSpace(domain))
+		if listed[key] {
+			continue
+		}
+		listed[key] = true
+		count, ok := counts[key]
+		entries = append(entries, CoverageEntry{Domain: key, Count: count, Present: ok})
+	}
+	return entries
+}
+
+// writeCoverage writes the Coverage of opts.Coverage in opts.Format.
+func writeCoverage(w io.Writer, domainsCount DomainsCount, opts OutputOptions) error {
+	entries := Coverage(domainsCount.DomainStats, opts.Coverage)
+	present := 0
+	for _, entry := range entries {
+		if entry.Present {
+			present++
+		}
+	}
+
+	switch opts.Format {
+	case "", FORMAT_TEXT:
+		for _, entry := range entries {
+			if _, err := fmt.Fprintf(w, COVERAGE_LINE_FORMAT, entry.Domain, entry.Count, entry.Status()); err != nil {
+				return err
+			}
+		}
+		_, err := fmt.Fprintf(w, COVERAGE_SUMMARY_FORMAT, present, len(entries))
+		return err
+	case FORMAT_JSON:
+		return encodeJSON(w, coverageReport{Present: present, Missing: len(entries) - present, Reference: entries})
+	case FORMAT_CSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write([]string{"domain", "customers", "status"}); err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if err := writer.Write([]string{entry.Domain, strconv.Itoa(entry.Count), entry.Status()}); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	default:
+		return fmt.Errorf("coverage report doesn't support format: %s", opts.Format)
+	}
+}
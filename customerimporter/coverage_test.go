@@ -0,0 +1,71 @@
+package customerimporter
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestCoverage(t *testing.T) {
+	domainStats := []DomainStat{{Name: "cnet.com", Count: 2}, {Name: "github.io", Count: 5}}
+	reference := []string{"GitHub.io", "zoho.com", " cnet.com ", "github.io"}
+
+	expected := []CoverageEntry{
+		{Domain: "github.io", Count: 5, Present: true},
+		{Domain: "zoho.com"},
+		{Domain: "cnet.com", Count: 2, Present: true},
+	}
+	if entries := Coverage(domainStats, reference); !reflect.DeepEqual(entries, expected) {
+		t.Errorf("coverage %v, expected: %v", entries, expected)
+	}
+}
+
+func TestWriteTo_Coverage(t *testing.T) {
+	domainsCount := DomainsCount{TotalCount: 3, DomainStats: []DomainStat{{Name: "cnet.com", Count: 1}, {Name: "github.io", Count: 2}}}
+
+	testCases := []struct {
+		format   string
+		expected string
+	}{
+		{
+			format:   FORMAT_TEXT,
+			expected: "Domain: github.io, Customers: 2, Status: present\nDomain: zoho.com, Customers: 0, Status: missing\nCoverage: 1 of 2 reference domains present\n",
+		},
+		{
+			format:   FORMAT_CSV,
+			expected: "domain,customers,status\ngithub.io,2,present\nzoho.com,0,missing\n",
+		},
+		{
+			format: FORMAT_JSON,
+			expected: `{
+  "present": 1,
+  "missing": 1,
+  "reference": [
+    {
+      "domain": "github.io",
+      "count": 2,
+      "present": true
+    },
+    {
+      "domain": "zoho.com",
+      "count": 0,
+      "present": false
+    }
+  ]
+}
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		var buf bytes.Buffer
+		// MinCount must not hide present reference domains.
+		opts := OutputOptions{Format: tc.format, Coverage: []string{"github.io", "zoho.com"}, MinCount: 5}
+		if err := WriteTo(&buf, domainsCount, opts); err != nil {
+			t.Fatalf("%s: unexpected error occured: %v", tc.format, err)
+		}
+		if buf.String() != tc.expected {
+			t.Errorf("%s: output %q, expected: %q", tc.format, buf.String(), tc.expected)
+		}
+	}
+}
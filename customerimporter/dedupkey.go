@@ -0,0 +1,53 @@
+package customerimporter
+
+import "strings"
+
+// dedupKeys holds the WithDedupKey values of the rows sent to the workers so
+// far. It is only used by the reading goroutine and needs no locking.
+type dedupKeys map[string]struct{}
+
+// newDedupKeys returns an empty set of keys, or nil without WithDedupKey.
+func (imp *Importer) newDedupKeys() dedupKeys {
+	if imp.dedupKey == "" {
+		return nil
+	}
+	return make(dedupKeys)
+}
+
+// duplicate reports whether an earlier row had key, recording key otherwise.
+// Rows whose key is missing or blank are never duplicates.
+func (k dedupKeys) duplicate(key string, found bool) bool {
+	if k == nil {
+		return false
+	}
+	key = strings.TrimSpace(key)
+	if !found || key == "" {
+		return false
+	}
+	if _, ok := k[key]; ok {
+		return true
+	}
+	k[key] = struct{}{}
+	return false
+}
+
+// claimsKey reports whether email, checked as extractDomains does, is counted,
+// so that only such rows record their WithDedupKey key and a row with a bad
+// email doesn't hide the later rows of its customer.
+func (imp *Importer) claimsKey(email string) bool {
+	email = imp.trimEmail(email)
+	if imp.maxEmailLength > 0 && len(email) > imp.maxEmailLength {
+		return false
+	}
+	_, ok := imp.domainOf(email)
+	return ok
+}
+
+// resolveDedupIdx returns the index of the WithDedupKey column in header, or
+// -1 without a dedup key.
+func (imp *Importer) resolveDedupIdx(header []string) (int, error) {
+	if imp.dedupKey == "" {
+		return -1, nil
+	}
+	return resolveColumn(header, imp.dedupKey)
+}
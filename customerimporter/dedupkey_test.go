@@ -0,0 +1,85 @@
+package customerimporter
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestImporter_DedupKey(t *testing.T) {
+	csvInput := `email,customer_id
+a@github.io,1
+a.work@cnet.com,1
+b@cnet.com,2
+b@github.io, 2
+c@github.io,
+d@github.io,
+e@cnet.com,3`
+
+	expected := []DomainStat{
+		{Name: "cnet.com", Count: 2, FirstSeenLine: 4},
+		{Name: "github.io", Count: 3, FirstSeenLine: 2},
+	}
+
+	for _, fastParse := range []bool{false, true} {
+		imp := NewImporter(WithEmailColumn(0), WithDedupKey("customer_id"), WithFastParse(fastParse))
+		domainsCount, err := imp.Import(strings.NewReader(csvInput))
+		if err != nil {
+			t.Fatalf("unexpected error occured: %v", err)
+		}
+		if domainsCount.TotalCount != 5 {
+			t.Errorf("fast parse %v: total customers: %d, expected: %d", fastParse, domainsCount.TotalCount, 5)
+		}
+		if !reflect.DeepEqual(domainsCount.DomainStats, expected) {
+			t.Errorf("fast parse %v: domain stats %v, expected: %v", fastParse, domainsCount.DomainStats, expected)
+		}
+	}
+}
+
+func TestImporter_DedupKeyJSONL(t *testing.T) {
+	jsonInput := `{"email": "a@github.io", "id": 7}
+{"email": "a@cnet.com", "id": 7}
+{"email": "b@cnet.com", "id": 8}`
+
+	imp := NewImporter(WithInputFormat(INPUT_FORMAT_JSONL), WithDedupKey("id"))
+	domainsCount, err := imp.Import(strings.NewReader(jsonInput))
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	expected := []DomainStat{
+		{Name: "cnet.com", Count: 1, FirstSeenLine: 3},
+		{Name: "github.io", Count: 1, FirstSeenLine: 1},
+	}
+	if !reflect.DeepEqual(domainsCount.DomainStats, expected) {
+		t.Errorf("domain stats %v, expected: %v", domainsCount.DomainStats, expected)
+	}
+}
+
+func TestImporter_DedupKeyMissingColumn(t *testing.T) {
+	imp := NewImporter(WithEmailColumn(0), WithDedupKey("customer_id"))
+	if _, err := imp.Import(strings.NewReader("email,plan\na@github.io,pro")); err == nil {
+		t.Errorf("expected an error for a missing dedup key column")
+	}
+}
+
+func TestImporter_DedupKeyInvalidEmail(t *testing.T) {
+	csvInput := `email,customer_id
+not-an-email,1
+a@github.io,1
+a@cnet.com,1`
+
+	expected := []DomainStat{{Name: "github.io", Count: 1, FirstSeenLine: 3}}
+	for _, fastParse := range []bool{false, true} {
+		imp := NewImporter(WithEmailColumn(0), WithDedupKey("customer_id"), WithFastParse(fastParse))
+		domainsCount, err := imp.Import(strings.NewReader(csvInput))
+		if err != nil {
+			t.Fatalf("unexpected error occured: %v", err)
+		}
+		if !reflect.DeepEqual(domainsCount.DomainStats, expected) {
+			t.Errorf("fast parse %v: domain stats %v, expected: %v", fastParse, domainsCount.DomainStats, expected)
+		}
+		if domainsCount.InvalidCount != 1 {
+			t.Errorf("fast parse %v: invalid customers: %d, expected: %d", fastParse, domainsCount.InvalidCount, 1)
+		}
+	}
+}
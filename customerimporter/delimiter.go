@@ -0,0 +1,86 @@
+package customerimporter
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+)
+
+const DEFAULT_DELIMITER = ','
+
+var candidateDelimiters = []rune{',', '\t', ';', '|'}
+
+// peekLine returns the first line buffered by reader without consuming it. It
+// reads only as much as needed to find the line break, so it doesn't wait for
+// a full buffer on slow streams such as pipes.
+func peekLine(reader *bufio.Reader) []byte {
+	for n := 1; n <= reader.Size(); n = reader.Buffered() + 1 {
+		buf, err := reader.Peek(n)
+		if i := bytes.IndexByte(buf, '\n'); i >= 0 {
+			return buf[:i]
+		}
+		if err != nil {
+			return buf
+		}
+	}
+	buf, _ := reader.Peek(reader.Buffered())
+	return buf
+}
+
+// detectDelimiter returns the candidate delimiter occurring most often in the
+// header line, outside of quoted fields. It falls back to a comma when no
+// candidate occurs or the most frequent ones tie.
+func detectDelimiter(header []byte) rune {
+	counts := make(map[rune]int, len(candidateDelimiters))
+	inQuotes := false
+	for _, r := range string(header) {
+		if r == '"' {
+			inQuotes = !inQuotes
+			continue
+		}
+		if !inQuotes {
+			counts[r]++
+		}
+	}
+
+	best, bestCount, tie := DEFAULT_DELIMITER, 0, false
+	for _, candidate := range candidateDelimiters {
+		count := counts[candidate]
+		switch {
+		case count > bestCount:
+			best, bestCount, tie = candidate, count, false
+		case count == bestCount:
+			tie = true
+		}
+	}
+
+	if bestCount == 0 || tie {
+		return DEFAULT_DELIMITER
+	}
+	return best
+}
+
+// delimiterMismatch checks the header against the first data row. When one of
+// them parsed into a single field that contains another candidate delimiter,
+// the file likely mixes delimiters and that candidate is returned.
+func delimiterMismatch(header, firstRow []string, delimiter rune) (rune, bool) {
+	if len(header) == len(firstRow) {
+		return 0, false
+	}
+
+	var single string
+	switch {
+	case len(firstRow) == 1:
+		single = firstRow[0]
+	case len(header) == 1:
+		single = header[0]
+	default:
+		return 0, false
+	}
+
+	guess := detectDelimiter([]byte(single))
+	if guess == delimiter || !strings.ContainsRune(single, guess) {
+		return 0, false
+	}
+	return guess, true
+}
@@ -0,0 +1,157 @@
+package customerimporter
+
+import (
+	"bufio"
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestDetectDelimiter(t *testing.T) {
+	testCases := []struct {
+		name              string
+		header            string
+		expectedDelimiter rune
+	}{
+		{
+			name:              "comma",
+			header:            "first_name,last_name,email,gender,ip_address",
+			expectedDelimiter: ',',
+		},
+		{
+			name:              "tab",
+			header:            "first_name\tlast_name\temail\tgender\tip_address",
+			expectedDelimiter: '\t',
+		},
+		{
+			name:              "semicolon",
+			header:            "first_name;last_name;email;gender;ip_address",
+			expectedDelimiter: ';',
+		},
+		{
+			name:              "pipe",
+			header:            "first_name|last_name|email|gender|ip_address",
+			expectedDelimiter: '|',
+		},
+		{
+			name:              "quoted delimiters are ignored",
+			header:            `"a;b;c;d",email,gender`,
+			expectedDelimiter: ',',
+		},
+		{
+			name:              "ambiguous falls back to comma",
+			header:            "a;b|c",
+			expectedDelimiter: ',',
+		},
+		{
+			name:              "no delimiter falls back to comma",
+			header:            "email",
+			expectedDelimiter: ',',
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actualDelimiter := detectDelimiter([]byte(tc.header))
+			if actualDelimiter != tc.expectedDelimiter {
+				t.Errorf("detectDelimiter(%q) = %q; want %q", tc.header, actualDelimiter, tc.expectedDelimiter)
+			}
+		})
+	}
+}
+
+func TestPeekLine(t *testing.T) {
+	reader := bufio.NewReader(strings.NewReader("a;b;c\nd;e;f\n"))
+
+	line := peekLine(reader)
+	if string(line) != "a;b;c" {
+		t.Errorf("peekLine = %q; want %q", line, "a;b;c")
+	}
+
+	rest, _ := reader.ReadString('\n')
+	if rest != "a;b;c\n" {
+		t.Errorf("peekLine consumed input, next line = %q", rest)
+	}
+}
+
+func TestProcessCsv_DetectDelimiter(t *testing.T) {
+	csvInput := `first_name;last_name;email;gender;ip_address
+Mildred;Hernandez;mhernandez0@github.io;Female;38.194.51.128
+Bonnie;Ortiz;bortiz1@github.io;Female;197.54.209.129
+Norma;Allen;nallen8@cnet.com;Female;168.67.162.1`
+
+	imp := NewImporter(WithDetectDelimiter(true))
+	result, err := imp.processCsv(strings.NewReader(csvInput))
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+
+	if result.totalCustomers != 3 {
+		t.Errorf("Total count: %d, expected: 3", result.totalCustomers)
+	}
+	if result.domainMap["github.io"] != 2 || result.domainMap["cnet.com"] != 1 {
+		t.Errorf("unexpected domain counts: %v", result.domainMap)
+	}
+}
+
+func TestDelimiterMismatch(t *testing.T) {
+	testCases := []struct {
+		name          string
+		header        []string
+		firstRow      []string
+		expectedGuess rune
+		expectedOk    bool
+	}{
+		{
+			name:     "consistent",
+			header:   []string{"first_name", "email"},
+			firstRow: []string{"Mildred", "m@github.io"},
+		},
+		{
+			name:          "semicolon data under comma header",
+			header:        []string{"first_name", "last_name", "email"},
+			firstRow:      []string{"Mildred;Hernandez;m@github.io"},
+			expectedGuess: ';',
+			expectedOk:    true,
+		},
+		{
+			name:          "comma data under semicolon header",
+			header:        []string{"first_name;last_name;email"},
+			firstRow:      []string{"Mildred", "Hernandez", "m@github.io"},
+			expectedGuess: ';',
+			expectedOk:    true,
+		},
+		{
+			name:     "ragged row without other delimiter",
+			header:   []string{"first_name", "last_name", "email"},
+			firstRow: []string{"Mildred"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			guess, ok := delimiterMismatch(tc.header, tc.firstRow, ',')
+			if ok != tc.expectedOk || guess != tc.expectedGuess {
+				t.Errorf("delimiterMismatch = %q, %v; want %q, %v", guess, ok, tc.expectedGuess, tc.expectedOk)
+			}
+		})
+	}
+}
+
+func TestProcessCsv_WarnsOnMixedDelimiters(t *testing.T) {
+	csvInput := `first_name,last_name,email
+Mildred;Hernandez;mhernandez0@github.io
+Bonnie;Ortiz;bortiz1@github.io`
+
+	var logs bytes.Buffer
+	// Every row lacks the email column, which is otherwise an error.
+	imp := NewImporter(WithLogger(log.New(&logs, "", 0)), WithAllowEmpty(true))
+	if _, err := imp.processCsv(strings.NewReader(csvInput)); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+
+	if strings.Count(logs.String(), "may be ';'-delimited") != 1 {
+		t.Errorf("expected a single mixed delimiter warning, got: %s", logs.String())
+	}
+}
@@ -0,0 +1,62 @@
+package customerimporter
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+const DIFF_LINE_FORMAT = "Domain: %s, Before: %d, After: %d, Change: %+d\n"
+const DIFF_TOTAL_FORMAT = "Total: Before: %d, After: %d, Change: %+d\n"
+
+// DomainDiff is the customer count of a domain in two results. A domain
+// missing from one of them has a count of 0 there.
+type DomainDiff struct {
+	Name   string `json:"name"`
+	Before int    `json:"before"`
+	After  int    `json:"after"`
+}
+
+// Change is the difference of the counts, positive when the domain grew.
+func (d DomainDiff) Change() int {
+	return d.After - d.Before
+}
+
+// Diff returns the domains whose count differs between before and after,
+// ordered by name.
+func Diff(before, after DomainsCount) []DomainDiff {
+	counts := make(map[string]*DomainDiff)
+	for _, domainStat := range before.DomainStats {
+		counts[domainStat.Name] = &DomainDiff{Name: domainStat.Name, Before: domainStat.Count}
+	}
+	for _, domainStat := range after.DomainStats {
+		if diff, ok := counts[domainStat.Name]; ok {
+			diff.After = domainStat.Count
+		} else {
+			counts[domainStat.Name] = &DomainDiff{Name: domainStat.Name, After: domainStat.Count}
+		}
+	}
+
+	diffs := make([]DomainDiff, 0)
+	for _, diff := range counts {
+		if diff.Change() != 0 {
+			diffs = append(diffs, *diff)
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Name < diffs[j].Name
+	})
+	return diffs
+}
+
+// WriteDiff writes the Diff of before and after as one DIFF_LINE_FORMAT line
+// per changed domain followed by the totals.
+func WriteDiff(w io.Writer, before, after DomainsCount) error {
+	for _, diff := range Diff(before, after) {
+		if _, err := fmt.Fprintf(w, DIFF_LINE_FORMAT, diff.Name, diff.Before, diff.After, diff.Change()); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, DIFF_TOTAL_FORMAT, before.TotalCount, after.TotalCount, after.TotalCount-before.TotalCount)
+	return err
+}
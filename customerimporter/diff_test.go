@@ -0,0 +1,45 @@
+package customerimporter
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	before := DomainsCount{DomainStats: []DomainStat{
+		{Name: "cnet.com", Count: 2},
+		{Name: "github.io", Count: 3},
+		{Name: "zoho.com", Count: 1},
+	},
+		TotalCount: 6,
+	}
+	after := DomainsCount{DomainStats: []DomainStat{
+		{Name: "acquirethisname.com", Count: 1},
+		{Name: "github.io", Count: 5},
+		{Name: "zoho.com", Count: 1},
+	},
+		TotalCount: 7,
+	}
+
+	expected := []DomainDiff{
+		{Name: "acquirethisname.com", After: 1},
+		{Name: "cnet.com", Before: 2},
+		{Name: "github.io", Before: 3, After: 5},
+	}
+	if diffs := Diff(before, after); !reflect.DeepEqual(diffs, expected) {
+		t.Errorf("Diff: %v, expected: %v", diffs, expected)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteDiff(&buf, before, after); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	expectedOutput := "Domain: acquirethisname.com, Before: 0, After: 1, Change: +1\n" +
+		"Domain: cnet.com, Before: 2, After: 0, Change: -2\n" +
+		"Domain: github.io, Before: 3, After: 5, Change: +2\n" +
+		"Total: Before: 6, After: 7, Change: +1\n"
+	if buf.String() != expectedOutput {
+		t.Errorf("Output: %q, expected: %q", buf.String(), expectedOutput)
+	}
+}
@@ -0,0 +1,36 @@
+package customerimporter
+
+import "strings"
+
+// markDisposable flags the domain stats of disposable email domains, or drops
+// them when exclude is set, and returns the number of customers using them.
+// A domain is disposable when it or one of its parent domains is listed.
+func markDisposable(domainStats []DomainStat, disposable map[string]struct{}, exclude bool) ([]DomainStat, int) {
+	customers := 0
+	kept := domainStats[:0]
+	for _, domainStat := range domainStats {
+		if !isListedDomain(strings.ToLower(domainStat.Name), disposable) {
+			kept = append(kept, domainStat)
+			continue
+		}
+		customers += domainStat.Count
+		if !exclude {
+			domainStat.Disposable = true
+			kept = append(kept, domainStat)
+		}
+	}
+	return kept, customers
+}
+
+func isListedDomain(domain string, list map[string]struct{}) bool {
+	for {
+		if _, ok := list[domain]; ok {
+			return true
+		}
+		_, parent, ok := strings.Cut(domain, ".")
+		if !ok {
+			return false
+		}
+		domain = parent
+	}
+}
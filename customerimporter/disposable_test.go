@@ -0,0 +1,89 @@
+package customerimporter
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestImporter_DisposableDomains(t *testing.T) {
+	csvInput := `first_name,last_name,email
+A,A,a@github.io
+B,B,b@mailinator.com
+C,C,c@eu.mailinator.com
+D,D,d@notmailinator.com`
+
+	testCases := []struct {
+		name               string
+		exclude            bool
+		expectedStats      []DomainStat
+		expectedTotal      int
+		expectedDisposable int
+	}{
+		{
+			name: "mark",
+			expectedStats: []DomainStat{
+				{Name: "eu.mailinator.com", Count: 1, Disposable: true, FirstSeenLine: 4},
+				{Name: "github.io", Count: 1, FirstSeenLine: 2},
+				{Name: "mailinator.com", Count: 1, Disposable: true, FirstSeenLine: 3},
+				{Name: "notmailinator.com", Count: 1, FirstSeenLine: 5},
+			},
+			expectedTotal:      4,
+			expectedDisposable: 2,
+		},
+		{
+			name:    "exclude",
+			exclude: true,
+			expectedStats: []DomainStat{
+				{Name: "github.io", Count: 1, FirstSeenLine: 2},
+				{Name: "notmailinator.com", Count: 1, FirstSeenLine: 5},
+			},
+			expectedTotal:      2,
+			expectedDisposable: 2,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			imp := NewImporter(WithDisposableDomains([]string{" Mailinator.com "}, tc.exclude))
+			domainsCount, err := imp.Import(strings.NewReader(csvInput))
+			if err != nil {
+				t.Fatalf("unexpected error occured: %v", err)
+			}
+			if !reflect.DeepEqual(domainsCount.DomainStats, tc.expectedStats) {
+				t.Errorf("Domain stats: %v, expected: %v", domainsCount.DomainStats, tc.expectedStats)
+			}
+			if domainsCount.TotalCount != tc.expectedTotal {
+				t.Errorf("Total count: %d, expected: %d", domainsCount.TotalCount, tc.expectedTotal)
+			}
+			if domainsCount.DisposableCount != tc.expectedDisposable {
+				t.Errorf("Disposable count: %d, expected: %d", domainsCount.DisposableCount, tc.expectedDisposable)
+			}
+		})
+	}
+}
+
+func TestWriteTo_Disposable(t *testing.T) {
+	domainsCount := DomainsCount{
+		DomainStats: []DomainStat{
+			{Name: "github.io", Count: 2},
+			{Name: "mailinator.com", Count: 1, Disposable: true},
+		},
+		TotalCount:      3,
+		DisposableCount: 1,
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTo(&buf, domainsCount, OutputOptions{}); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	expectedOutput := `Total number of customers: 3
+Disposable email customers: 1
+Domain: github.io, Customers: 2
+Domain: mailinator.com, Customers: 1 [disposable]
+`
+	if buf.String() != expectedOutput {
+		t.Errorf("output %s, expected: %s", buf.String(), expectedOutput)
+	}
+}
@@ -0,0 +1,30 @@
+package customerimporter
+
+import (
+	"fmt"
+	"strings"
+)
+
+const EXPLAIN_FORMAT = "Explain: line %d: %q %s\n"
+const EXPLAIN_NOT_FOUND_FORMAT = "Explain: %q not found in the input\n"
+
+// explains reports whether email is the WithExplain target: the email itself,
+// ignoring case, or any email of the target domain.
+func (imp *Importer) explains(email string) bool {
+	if imp.explainTarget == "" {
+		return false
+	}
+	email = strings.TrimSpace(email)
+	if strings.Contains(imp.explainTarget, "@") {
+		return strings.EqualFold(email, imp.explainTarget)
+	}
+	at := strings.LastIndexByte(email, '@')
+	return at >= 0 && strings.EqualFold(email[at+1:], imp.explainTarget)
+}
+
+// explain logs what became of the row at line holding the WithExplain target
+// email. Every traced row is explained exactly once.
+func (imp *Importer) explain(skipped *skipCounts, line int, email string, format string, args ...any) {
+	skipped.explained.Add(1)
+	imp.logger.Printf(EXPLAIN_FORMAT, line, strings.TrimSpace(email), fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,87 @@
+package customerimporter
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestImporter_Explain(t *testing.T) {
+	csvInput := `email,plan
+admin@github.io,pro
+b@cnet.com,pro
+admin@github.io,free
+ADMIN@github.io,pro
+c@github.io,pro`
+
+	testCases := []struct {
+		name     string
+		target   string
+		opts     []Option
+		expected []string
+	}{
+		{
+			name:   "email",
+			target: "admin@github.io",
+			opts:   []Option{WithRowFilter("plan", "pro"), WithRoleAccounts([]string{"admin"})},
+			expected: []string{
+				`Explain: line 2: "admin@github.io" counted for domain github.io as a role account`,
+				`Explain: line 4: "admin@github.io" skipped as the row doesn't match the filters`,
+				`Explain: line 5: "ADMIN@github.io" counted for domain github.io as a role account`,
+			},
+		},
+		{
+			name:   "domain_filter",
+			target: "cnet.com",
+			opts:   []Option{WithDomainFilter(func(domain string) bool { return domain != "cnet.com" })},
+			expected: []string{
+				`Explain: line 3: "b@cnet.com" skipped by a domain filter of domain cnet.com`,
+			},
+		},
+		{
+			name:     "not_found",
+			target:   "zoho.com",
+			expected: []string{`Explain: "zoho.com" not found in the input`},
+		},
+	}
+
+	for _, tc := range testCases {
+		for _, fastParse := range []bool{false, true} {
+			var logs bytes.Buffer
+			opts := append([]Option{WithEmailColumn(0), WithExplain(tc.target), WithFastParse(fastParse), WithLogger(log.New(&logs, "", 0))}, tc.opts...)
+			if _, err := NewImporter(opts...).Import(strings.NewReader(csvInput)); err != nil {
+				t.Fatalf("%s: unexpected error occured: %v", tc.name, err)
+			}
+
+			var explained []string
+			for _, line := range strings.Split(logs.String(), "\n") {
+				if strings.HasPrefix(line, "Explain:") {
+					explained = append(explained, line)
+				}
+			}
+			// Workers explain rows out of order.
+			if len(explained) != len(tc.expected) {
+				t.Errorf("%s fast parse %v: explained %q, expected: %q", tc.name, fastParse, explained, tc.expected)
+				continue
+			}
+			for _, expected := range tc.expected {
+				if !strings.Contains(logs.String(), expected+"\n") {
+					t.Errorf("%s fast parse %v: logs %q, expected to contain: %q", tc.name, fastParse, logs.String(), expected)
+				}
+			}
+		}
+	}
+}
+
+func TestImporter_ExplainInvalid(t *testing.T) {
+	var logs bytes.Buffer
+	imp := NewImporter(WithEmailColumn(0), WithExplain("bad@"), WithAllowEmpty(true), WithLogger(log.New(&logs, "", 0)))
+	if _, err := imp.Import(strings.NewReader("email\nbad@")); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	expected := `Explain: line 2: "bad@" skipped as an invalid email address`
+	if !strings.Contains(logs.String(), expected) {
+		t.Errorf("logs %q, expected to contain: %q", logs.String(), expected)
+	}
+}
@@ -0,0 +1,177 @@
+package customerimporter
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// readLine returns the next line of reader without its line break. The line
+// is only valid until the following read unless it didn't fit the buffer, in
+// which case it is assembled in scratch.
+func readLine(reader *bufio.Reader, scratch *[]byte) ([]byte, error) {
+	line, err := reader.ReadSlice('\n')
+	if err == bufio.ErrBufferFull {
+		*scratch = append((*scratch)[:0], line...)
+		for err == bufio.ErrBufferFull {
+			line, err = reader.ReadSlice('\n')
+			*scratch = append(*scratch, line...)
+		}
+		line = *scratch
+	}
+	if err == io.EOF && len(line) > 0 {
+		err = nil
+	}
+	line = bytes.TrimSuffix(line, []byte{'\n'})
+	return bytes.TrimSuffix(line, []byte{'\r'}), err
+}
+
+// fieldAt returns the field at idx of a line split by delimiter, without
+// splitting the fields after it. Negative indexes count from the end like in
+// columnIndex.
+func fieldAt(line []byte, delimiter []byte, idx int) ([]byte, bool) {
+	if idx < 0 {
+		return fieldFromEnd(line, delimiter, -idx-1)
+	}
+	for range idx {
+		i := bytes.Index(line, delimiter)
+		if i < 0 {
+			return nil, false
+		}
+		line = line[i+len(delimiter):]
+	}
+	if i := bytes.Index(line, delimiter); i >= 0 {
+		line = line[:i]
+	}
+	return line, true
+}
+
+// fieldFromEnd returns the field at idx counted back from the last field.
+func fieldFromEnd(line []byte, delimiter []byte, idx int) ([]byte, bool) {
+	for range idx {
+		i := bytes.LastIndex(line, delimiter)
+		if i < 0 {
+			return nil, false
+		}
+		line = line[:i]
+	}
+	if i := bytes.LastIndex(line, delimiter); i >= 0 {
+		line = line[i+len(delimiter):]
+	}
+	return line, true
+}
+
+// readLineHeader returns the first non-blank line of reader split by
+// delimiter, like readHeader does for csv input.
+func readLineHeader(reader *bufio.Reader, delimiter rune) ([]string, error) {
+	var scratch []byte
+	for {
+		line, err := readLine(reader, &scratch)
+		if err == io.EOF {
+			return nil, fmt.Errorf("error reading the header of csv: %w", ErrEmptyInput)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading the header of csv: %w", err)
+		}
+		if len(bytes.TrimSpace(line)) > 0 {
+			return strings.Split(string(line), string(delimiter)), nil
+		}
+	}
+}
+
+// lineReader is the WithFastParse counterpart of csvReader. It splits lines
+// on delimiter up to the email column only, which saves allocating every
+// field of wide rows, and doesn't handle quoting.
+func (imp *Importer) lineReader(reader *bufio.Reader, end *lastByteReader, delimiter rune, columns rowColumns, emailChan chan emailRow, tracker *progressTracker, sampled *bool, skipped *skipCounts) error {
+	defer close(emailChan)
+	sep := utf8.AppendRune(nil, delimiter)
+	lineNum := imp.skipRows
+	emitted := 0
+	lastSkipped := 0
+	keys := imp.newDedupKeys()
+	var scratch []byte
+
+	for {
+		if imp.limit > 0 && emitted == imp.limit {
+			imp.debugf("Row limit of %d reached", imp.limit)
+			tracker.update(lineNum, true)
+			*sampled = true
+			break
+		}
+
+		line, err := readLine(reader, &scratch)
+		lineNum++
+		if err == io.EOF {
+			imp.debugf("End of file reached")
+			tracker.update(lineNum-1, true)
+			imp.warnTruncated(end, lastSkipped, lineNum)
+			break
+		}
+		if err != nil {
+			tracker.update(lineNum-1, true)
+			return fmt.Errorf("error reading csv line %d: %w", lineNum+1, err)
+		}
+		if lineNum%PROGRESS_INTERVAL == 0 {
+			tracker.update(lineNum, false)
+		}
+		if len(bytes.TrimSpace(line)) == 0 {
+			imp.debugf("Skipping blank csv line %d", lineNum+1)
+			continue
+		}
+
+		email, ok := fieldAt(line, sep, columns.email)
+		if !ok {
+			imp.logger.Printf("Line %d email column index out of range\n", lineNum)
+			skipped.addShortRow(lineNum+1, fmt.Sprintf(SHORT_ROW_FORMAT, columns.email, bytes.Count(line, sep)+1))
+			lastSkipped = lineNum + 1
+			continue
+		}
+
+		if !matchesFilters(columns.filters, func(idx int) (string, bool) {
+			field, ok := fieldAt(line, sep, idx)
+			return string(field), ok
+		}) {
+			imp.debugf("Skipping csv line %d not matching the filters", lineNum+1)
+			if imp.explains(string(email)) {
+				imp.explain(skipped, lineNum+1, string(email), "skipped as the row doesn't match the filters")
+			}
+			continue
+		}
+
+		timestamp, found := []byte(nil), false
+		if imp.timeWindow != "" {
+			timestamp, found = fieldAt(line, sep, columns.time)
+		}
+
+		weightField, weightFound := []byte(nil), false
+		if columns.weight >= 0 {
+			weightField, weightFound = fieldAt(line, sep, columns.weight)
+		}
+		weight, ok := imp.rowWeight(string(weightField), weightFound, lineNum+1)
+		if !ok {
+			skipped.warn(WARNING_MALFORMED_ROW, lineNum+1, string(weightField))
+			if imp.explains(string(email)) {
+				imp.explain(skipped, lineNum+1, string(email), "skipped for its invalid weight %q", weightField)
+			}
+			continue
+		}
+		key, keyFound := []byte(nil), false
+		if keys != nil {
+			key, keyFound = fieldAt(line, sep, columns.dedup)
+			keyFound = keyFound && imp.claimsKey(string(email))
+		}
+		if keys.duplicate(string(key), keyFound) {
+			imp.debugf("Skipping csv line %d with an earlier row of key %q", lineNum+1, key)
+			if imp.explains(string(email)) {
+				imp.explain(skipped, lineNum+1, string(email), "skipped as an earlier row has key %q", key)
+			}
+			continue
+		}
+		emailChan <- emailRow{email: imp.windowed(string(email), string(timestamp), found), line: lineNum + 1, weight: weight}
+		emitted++
+	}
+	return nil
+}
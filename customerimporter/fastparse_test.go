@@ -0,0 +1,129 @@
+package customerimporter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"strings"
+	"testing"
+)
+
+// generateWideCsv returns a csv with the email in column emailIdx of cols
+// columns.
+func generateWideCsv(rows, cols, emailIdx int) string {
+	var sb strings.Builder
+	for col := range cols {
+		if col > 0 {
+			sb.WriteByte(',')
+		}
+		if col == emailIdx {
+			sb.WriteString("email")
+		} else {
+			fmt.Fprintf(&sb, "column%d", col)
+		}
+	}
+	sb.WriteByte('\n')
+	for i := range rows {
+		for col := range cols {
+			if col > 0 {
+				sb.WriteByte(',')
+			}
+			if col == emailIdx {
+				fmt.Fprintf(&sb, "user%d@domain%d.com", i, i%100)
+			} else {
+				fmt.Fprintf(&sb, "value%d", col)
+			}
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+func TestProcessCsv_FastParseMatchesCsv(t *testing.T) {
+	testCases := []struct {
+		name     string
+		csvInput string
+		opts     []Option
+	}{
+		{
+			name:     "generated",
+			csvInput: generateCsv(20_000, 150),
+		},
+		{
+			name:     "wide",
+			csvInput: generateWideCsv(2_000, 300, 150),
+			opts:     []Option{WithEmailHeader("email")},
+		},
+		{
+			name:     "crlf_bom_and_blank_lines",
+			csvInput: "\xEF\xBB\xBF\r\nfirst_name,last_name,email\r\nMildred,Hernandez,mhernandez0@github.io\r\n\r\nBonnie,Ortiz,bortiz1@github.io",
+		},
+		{
+			name:     "semicolons",
+			csvInput: "first_name;last_name;email\nMildred;Hernandez;mhernandez0@github.io\nShort;Row\n",
+			opts:     []Option{WithDelimiter(';')},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := append([]Option{WithLogger(log.New(io.Discard, "", 0))}, tc.opts...)
+
+			expected, err := NewImporter(opts...).processCsv(strings.NewReader(tc.csvInput))
+			if err != nil {
+				t.Fatalf("unexpected error occured: %v", err)
+			}
+			fast, err := NewImporter(append(opts, WithFastParse(true))...).processCsv(strings.NewReader(tc.csvInput))
+			if err != nil {
+				t.Fatalf("unexpected error occured: %v", err)
+			}
+
+			if fast.totalCustomers != expected.totalCustomers {
+				t.Errorf("Total customers: %d, expected: %d", fast.totalCustomers, expected.totalCustomers)
+			}
+			if !maps.Equal(fast.domainMap, expected.domainMap) {
+				t.Errorf("Domain map: %v, expected: %v", fast.domainMap, expected.domainMap)
+			}
+		})
+	}
+}
+
+func TestReadLine_LongerThanBuffer(t *testing.T) {
+	long := strings.Repeat("a", 100)
+	reader := bufio.NewReaderSize(strings.NewReader(long+"\nnext"), 16)
+
+	var scratch []byte
+	line, err := readLine(reader, &scratch)
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	if string(line) != long {
+		t.Errorf("line %q, expected: %q", line, long)
+	}
+
+	line, err = readLine(reader, &scratch)
+	if err != nil || string(line) != "next" {
+		t.Errorf("line %q, err %v, expected: %q", line, err, "next")
+	}
+	if _, err := readLine(reader, &scratch); err != io.EOF {
+		t.Errorf("err %v, expected: %v", err, io.EOF)
+	}
+}
+
+func BenchmarkProcessCsv_Wide(b *testing.B) {
+	csvInput := generateWideCsv(10_000, 200, 100)
+
+	for _, fast := range []bool{false, true} {
+		b.Run(fmt.Sprintf("fast_parse_%t", fast), func(b *testing.B) {
+			imp := NewImporter(WithLogger(log.New(io.Discard, "", 0)), WithEmailColumn(100), WithFastParse(fast))
+			b.ResetTimer()
+			for range b.N {
+				if _, err := imp.processCsv(strings.NewReader(csvInput)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+//go:build unix
+
+package customerimporter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestProcessFile_Fifo(t *testing.T) {
+	fifoPath := filepath.Join(t.TempDir(), "customers.fifo")
+	if err := syscall.Mkfifo(fifoPath, 0600); err != nil {
+		t.Skipf("mkfifo not supported: %v", err)
+	}
+
+	// More rows than fit in a pipe buffer, so the writer can only finish if
+	// the pipeline consumes the FIFO while it is still being written.
+	const rows = 5000
+	writeErr := make(chan error, 1)
+	go func() {
+		fifo, err := os.OpenFile(fifoPath, os.O_WRONLY, 0)
+		if err != nil {
+			writeErr <- err
+			return
+		}
+		defer fifo.Close()
+
+		if _, err := fifo.WriteString("first_name,last_name,email,gender,ip_address\n"); err != nil {
+			writeErr <- err
+			return
+		}
+		for i := range rows {
+			line := fmt.Sprintf("Name%d,Surname%d,user%d@domain%d.com,Female,127.0.0.1\n", i, i, i, i%2)
+			if _, err := fifo.WriteString(line); err != nil {
+				writeErr <- err
+				return
+			}
+		}
+		writeErr <- nil
+	}()
+
+	domainsCount, err := ProcessFile(fifoPath)
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	if err := <-writeErr; err != nil {
+		t.Fatalf("error writing to fifo: %v", err)
+	}
+
+	if domainsCount.TotalCount != rows {
+		t.Errorf("Total count: %d, expected: %d", domainsCount.TotalCount, rows)
+	}
+	if len(domainsCount.DomainStats) != 2 {
+		t.Fatalf("Domains: %d, expected: 2", len(domainsCount.DomainStats))
+	}
+	for _, domain := range domainsCount.DomainStats {
+		if domain.Count != rows/2 {
+			t.Errorf("Domain %s count: %d, expected: %d", domain.Name, domain.Count, rows/2)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package customerimporter
+
+import "path/filepath"
+
+// EmailColumn locates the email column of one input: the header column called
+// Header when it is set, the column at Index otherwise.
+type EmailColumn struct {
+	Index  int
+	Header string
+}
+
+// WithFileEmailColumns sets the email column per input of ImportFiles, for
+// csv files exported with different schemas. Keys are matched against the
+// path as given, then against its base name. Inputs that aren't mapped use
+// the WithEmailColumn, WithEmailHeader or WithEmailHeaderCandidates setting.
+func WithFileEmailColumns(columns map[string]EmailColumn) Option {
+	return func(imp *Importer) {
+		imp.fileColumns = columns
+	}
+}
+
+// fileEmailColumn returns the email column mapped to path, or nil.
+func (imp *Importer) fileEmailColumn(path string) *EmailColumn {
+	if column, ok := imp.fileColumns[path]; ok {
+		return &column
+	}
+	if column, ok := imp.fileColumns[filepath.Base(path)]; ok {
+		return &column
+	}
+	return nil
+}
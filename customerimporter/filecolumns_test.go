@@ -0,0 +1,50 @@
+package customerimporter
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestImportFiles_FileEmailColumns(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"crm.csv":     "id,email\n1,a@github.io\n2,b@cnet.com\n",
+		"billing.csv": "E-mail,name\nc@github.io,C\n",
+		"legacy.csv":  "name,surname,email\nD,D,d@zoho.com\n",
+	}
+	var paths []string
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("unexpected error occured: %v", err)
+		}
+		paths = append(paths, path)
+	}
+
+	imp := NewImporter(WithFileEmailColumns(map[string]EmailColumn{
+		filepath.Join(dir, "crm.csv"): {Index: 1},
+		"billing.csv":                 {Header: "e-mail"},
+	}))
+	domainsCount, err := imp.ImportFiles(paths...)
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+
+	expectedStats := []DomainStat{
+		{Name: "cnet.com", Count: 1},
+		{Name: "github.io", Count: 2},
+		{Name: "zoho.com", Count: 1},
+	}
+	var stats []DomainStat
+	for _, stat := range domainsCount.DomainStats {
+		stats = append(stats, DomainStat{Name: stat.Name, Count: stat.Count})
+	}
+	if !reflect.DeepEqual(stats, expectedStats) {
+		t.Errorf("Domain stats: %v, expected: %v", stats, expectedStats)
+	}
+	if domainsCount.TotalCount != 4 {
+		t.Errorf("Total count: %d, expected: 4", domainsCount.TotalCount)
+	}
+}
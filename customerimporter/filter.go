@@ -0,0 +1,40 @@
+package customerimporter
+
+import (
+	"strings"
+)
+
+// rowFilter counts only the rows whose column holds value.
+type rowFilter struct {
+	column string
+	value  string
+	idx    int
+}
+
+// resolveRowFilters returns the WithRowFilter filters with their column
+// indexes in header.
+func (imp *Importer) resolveRowFilters(header []string) ([]rowFilter, error) {
+	filters := make([]rowFilter, 0, len(imp.rowFilters))
+	for _, filter := range imp.rowFilters {
+		idx, err := resolveColumn(header, filter.column)
+		if err != nil {
+			return nil, err
+		}
+		filter.idx = idx
+		filters = append(filters, filter)
+	}
+	return filters, nil
+}
+
+// matchesFilters reports whether the row with fields at the filter indexes,
+// looked up by field, passes all filters. Values are compared ignoring case
+// and surrounding spaces, and rows missing a filter column don't match.
+func matchesFilters(filters []rowFilter, field func(idx int) (string, bool)) bool {
+	for _, filter := range filters {
+		value, ok := field(filter.idx)
+		if !ok || !strings.EqualFold(strings.TrimSpace(value), filter.value) {
+			return false
+		}
+	}
+	return true
+}
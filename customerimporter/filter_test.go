@@ -0,0 +1,66 @@
+package customerimporter
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestImporter_RowFilter(t *testing.T) {
+	csvInput := `email,country,plan
+a@github.io,US,pro
+b@cnet.com,us ,free
+c@github.io,DE,pro
+d@cnet.com,US,Pro
+e@cnet.com,USA,pro`
+
+	testCases := []struct {
+		name     string
+		opts     []Option
+		expected []DomainStat
+	}{
+		{
+			name: "single",
+			opts: []Option{WithRowFilter("country", "us")},
+			expected: []DomainStat{
+				{Name: "cnet.com", Count: 2, FirstSeenLine: 3},
+				{Name: "github.io", Count: 1, FirstSeenLine: 2},
+			},
+		},
+		{
+			name: "combined",
+			opts: []Option{WithRowFilter("Country", "US"), WithRowFilter("plan", "PRO")},
+			expected: []DomainStat{
+				{Name: "cnet.com", Count: 1, FirstSeenLine: 5},
+				{Name: "github.io", Count: 1, FirstSeenLine: 2},
+			},
+		},
+		{
+			name: "limit_counts_matching_rows",
+			opts: []Option{WithRowFilter("plan", "pro"), WithLimit(2)},
+			expected: []DomainStat{
+				{Name: "github.io", Count: 2, FirstSeenLine: 2},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		for _, fastParse := range []bool{false, true} {
+			imp := NewImporter(append([]Option{WithEmailColumn(0), WithFastParse(fastParse)}, tc.opts...)...)
+			domainsCount, err := imp.Import(strings.NewReader(csvInput))
+			if err != nil {
+				t.Fatalf("%s: unexpected error occured: %v", tc.name, err)
+			}
+			if !reflect.DeepEqual(domainsCount.DomainStats, tc.expected) {
+				t.Errorf("%s fast parse %v: domain stats %v, expected: %v", tc.name, fastParse, domainsCount.DomainStats, tc.expected)
+			}
+		}
+	}
+}
+
+func TestImporter_RowFilterMissingColumn(t *testing.T) {
+	imp := NewImporter(WithEmailColumn(0), WithRowFilter("country", "US"))
+	if _, err := imp.Import(strings.NewReader("email,plan\na@github.io,pro")); err == nil {
+		t.Errorf("expected an error for a missing filter column")
+	}
+}
@@ -0,0 +1,253 @@
+package customerimporter
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+const GROUP_BY_DOMAIN = "domain"
+const GROUP_BY_REGISTERED = "registered"
+const GROUP_BY_TLD = "tld"
+const GROUP_BY_SLD = "sld"
+const GROUP_BY_CATEGORY = "category"
+const GROUP_BY_HIERARCHY = "hierarchy"
+
+// GROUP_BYS lists the supported groupings of WithGroupBy.
+var GROUP_BYS = []string{GROUP_BY_DOMAIN, GROUP_BY_REGISTERED, GROUP_BY_TLD, GROUP_BY_SLD, GROUP_BY_CATEGORY, GROUP_BY_HIERARCHY}
+
+// CATEGORY_OTHER is the category of domains missing from the category map.
+const CATEGORY_OTHER = "Other"
+
+// ValidateGroupBy returns an error listing the GROUP_BYS when groupBy is not
+// one of them. An empty groupBy is GROUP_BY_DOMAIN.
+func ValidateGroupBy(groupBy string) error {
+	if groupBy == "" || slices.Contains(GROUP_BYS, groupBy) {
+		return nil
+	}
+	return fmt.Errorf("unsupported group by: %s (supported: %s)", groupBy, strings.Join(GROUP_BYS, ", "))
+}
+
+// groupKeyFunc returns the function mapping a full domain to its group for
+// groupBy, or nil when domains are not grouped. categories maps domains to
+// their GROUP_BY_CATEGORY group.
+func groupKeyFunc(groupBy string, categories map[string]string) (func(domain string) string, error) {
+	switch groupBy {
+	case "", GROUP_BY_DOMAIN, GROUP_BY_HIERARCHY:
+		return nil, nil
+	case GROUP_BY_REGISTERED:
+		return registeredDomain, nil
+	case GROUP_BY_TLD:
+		return topLevelDomain, nil
+	case GROUP_BY_SLD:
+		return secondLevelLabel, nil
+	case GROUP_BY_CATEGORY:
+		if len(categories) == 0 {
+			return nil, fmt.Errorf("group by %s requires a category map", GROUP_BY_CATEGORY)
+		}
+		return func(domain string) string {
+			if category, ok := categories[strings.ToLower(domain)]; ok {
+				return category
+			}
+			return CATEGORY_OTHER
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported group by: %s", groupBy)
+	}
+}
+
+// Regroup rolls the domains of domainsCount, an import by GROUP_BY_DOMAIN
+// without time windows or top domains, up by groupBy like WithGroupBy would
+// have, using the categories, sort and collation of imp. Regrouping a single
+// import once per grouping yields reports at several levels from one read of
+// the input. domainsCount itself is left unchanged.
+func (imp *Importer) Regroup(domainsCount DomainsCount, groupBy string) (*DomainsCount, error) {
+	keyFunc, err := groupKeyFunc(groupBy, imp.categories)
+	if err != nil {
+		return &DomainsCount{}, err
+	}
+	if domainsCount.Grouped() {
+		return &DomainsCount{}, errors.New("regrouping requires ungrouped domains")
+	}
+
+	domainStats := imp.groupDomainStats(slices.Clone(domainsCount.DomainStats), groupBy, keyFunc)
+	if imp.collation != nil {
+		collateStats(domainStats, *imp.collation)
+	}
+	domainsCount.DomainStats = domainStats
+	domainsCount.Hierarchy = groupBy == GROUP_BY_HIERARCHY
+	return &domainsCount, nil
+}
+
+// Grouped reports whether the domain stats are groups or hierarchy levels
+// with Subdomains rather than plain domains.
+func (dc *DomainsCount) Grouped() bool {
+	return dc.Hierarchy || slices.ContainsFunc(dc.DomainStats, func(stat DomainStat) bool {
+		return len(stat.Subdomains) > 0
+	})
+}
+
+// groupDomainStats rolls domainStats up by groupBy, whose groupKeyFunc is
+// keyFunc.
+func (imp *Importer) groupDomainStats(domainStats []DomainStat, groupBy string, keyFunc func(domain string) string) []DomainStat {
+	if keyFunc != nil {
+		domainStats = groupStats(domainStats, keyFunc, imp.naturalSort)
+	}
+	if groupBy == GROUP_BY_HIERARCHY {
+		domainStats = hierarchyStats(domainStats, imp.naturalSort)
+	}
+	return domainStats
+}
+
+// registeredDomain returns the registrable part of domain according to the
+// public suffix list, e.g. corp.com for eng.corp.com. Domains that are a
+// public suffix themselves and address literals are returned unchanged.
+func registeredDomain(domain string) string {
+	if _, err := netip.ParseAddr(domain); err == nil {
+		return domain
+	}
+	registered, err := publicsuffix.EffectiveTLDPlusOne(domain)
+	if err != nil {
+		return domain
+	}
+	return registered
+}
+
+// topLevelDomain returns the last label of domain, e.g. com for eng.corp.com.
+// Address literals are returned unchanged.
+func topLevelDomain(domain string) string {
+	if _, err := netip.ParseAddr(domain); err == nil {
+		return domain
+	}
+	return domain[strings.LastIndexByte(domain, '.')+1:]
+}
+
+// secondLevelLabel returns the leftmost label of the registered domain of
+// domain, e.g. corp for eng.corp.com and example for example.co.uk. Single
+// labels and address literals are returned unchanged, and so is the first
+// label of a public suffix, e.g. co for co.uk.
+func secondLevelLabel(domain string) string {
+	if _, err := netip.ParseAddr(domain); err == nil {
+		return domain
+	}
+	label, _, _ := strings.Cut(registeredDomain(domain), ".")
+	return label
+}
+
+// groupStats rolls domainStats up by keyFunc. Every group is counted as the
+// sum of its members, which are kept in Subdomains in the order they had in
+// domainStats. Groups are ordered by name like createStats does.
+func groupStats(domainStats []DomainStat, keyFunc func(domain string) string, naturalSort bool) []DomainStat {
+	groupIdx := make(map[string]int)
+	groups := make([]DomainStat, 0)
+	for _, domainStat := range domainStats {
+		key := keyFunc(domainStat.Name)
+		idx, ok := groupIdx[key]
+		if !ok {
+			idx = len(groups)
+			groupIdx[key] = idx
+			groups = append(groups, DomainStat{Name: key})
+		}
+		groups[idx].Count += domainStat.Count
+		groups[idx].RoleCount += domainStat.RoleCount
+		if first := groups[idx].FirstSeenLine; first == 0 || (domainStat.FirstSeenLine > 0 && domainStat.FirstSeenLine < first) {
+			groups[idx].FirstSeenLine = domainStat.FirstSeenLine
+		}
+		groups[idx].Subdomains = append(groups[idx].Subdomains, domainStat)
+	}
+
+	sortStats(groups, naturalSort)
+	return groups
+}
+
+// hierarchyNode is a level of the GROUP_BY_HIERARCHY tree with the levels
+// below it by name.
+type hierarchyNode struct {
+	stat     DomainStat
+	children map[string]*hierarchyNode
+}
+
+// hierarchyStats counts domainStats at every level of their domain from the
+// registered domain down, so eng.corp.com counts for corp.com and
+// eng.corp.com. Each level lists the levels below it in Subdomains, ordered
+// by name like createStats does, and counts its own customers along with
+// theirs.
+func hierarchyStats(domainStats []DomainStat, naturalSort bool) []DomainStat {
+	return levelStats(domainStats, domainLevels, naturalSort)
+}
+
+// treeStats is hierarchyStats with the top-level domain above the registered
+// domains, e.g. com, corp.com and eng.corp.com for eng.corp.com, see
+// OutputOptions.Tree.
+func treeStats(domainStats []DomainStat) []DomainStat {
+	return levelStats(domainStats, treeLevels, false)
+}
+
+// levelStats builds the tree of hierarchyStats with the levels of every
+// domain given by levels, from the top down.
+func levelStats(domainStats []DomainStat, levels func(domain string) []string, naturalSort bool) []DomainStat {
+	root := &hierarchyNode{children: make(map[string]*hierarchyNode)}
+	for _, domainStat := range domainStats {
+		node := root
+		for _, level := range levels(domainStat.Name) {
+			child, ok := node.children[level]
+			if !ok {
+				child = &hierarchyNode{stat: DomainStat{Name: level}, children: make(map[string]*hierarchyNode)}
+				node.children[level] = child
+			}
+			child.stat.Count += domainStat.Count
+			child.stat.RoleCount += domainStat.RoleCount
+			if first := child.stat.FirstSeenLine; first == 0 || (domainStat.FirstSeenLine > 0 && domainStat.FirstSeenLine < first) {
+				child.stat.FirstSeenLine = domainStat.FirstSeenLine
+			}
+			node = child
+		}
+		node.stat.Disposable = domainStat.Disposable
+	}
+	return root.stats(naturalSort)
+}
+
+func (n *hierarchyNode) stats(naturalSort bool) []DomainStat {
+	if len(n.children) == 0 {
+		return nil
+	}
+	stats := make([]DomainStat, 0, len(n.children))
+	for _, child := range n.children {
+		stat := child.stat
+		stat.Subdomains = child.stats(naturalSort)
+		stats = append(stats, stat)
+	}
+	sortStats(stats, naturalSort)
+	return stats
+}
+
+// treeLevels returns the top-level domain of domain followed by its
+// domainLevels. Domains that are their own top-level domain, like address
+// literals, have a single level.
+func treeLevels(domain string) []string {
+	levels := domainLevels(domain)
+	if tld := topLevelDomain(domain); tld != levels[0] {
+		levels = append([]string{tld}, levels...)
+	}
+	return levels
+}
+
+// domainLevels returns the levels of domain from its registered domain down
+// to domain itself, e.g. corp.com and eng.corp.com for eng.corp.com.
+func domainLevels(domain string) []string {
+	registered := registeredDomain(domain)
+	subdomain, ok := strings.CutSuffix(domain, "."+registered)
+	if !ok {
+		return []string{domain}
+	}
+	levels := []string{registered}
+	labels := strings.Split(subdomain, ".")
+	for i := len(labels) - 1; i >= 0; i-- {
+		levels = append(levels, strings.Join(labels[i:], ".")+"."+registered)
+	}
+	return levels
+}
@@ -0,0 +1,347 @@
+package customerimporter
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRegisteredDomain(t *testing.T) {
+	testCases := []struct {
+		domain   string
+		expected string
+	}{
+		{domain: "corp.com", expected: "corp.com"},
+		{domain: "eng.corp.com", expected: "corp.com"},
+		{domain: "a.b.example.co.uk", expected: "example.co.uk"},
+		{domain: "mhernandez.github.io", expected: "mhernandez.github.io"},
+		{domain: "github.io", expected: "github.io"},
+		{domain: "com", expected: "com"},
+		{domain: "192.168.1.1", expected: "192.168.1.1"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.domain, func(t *testing.T) {
+			if actual := registeredDomain(tc.domain); actual != tc.expected {
+				t.Errorf("registeredDomain(%q) = %q; want %q", tc.domain, actual, tc.expected)
+			}
+		})
+	}
+}
+
+func TestSecondLevelLabel(t *testing.T) {
+	testCases := []struct {
+		domain   string
+		expected string
+	}{
+		{domain: "corp.com", expected: "corp"},
+		{domain: "eng.corp.com", expected: "corp"},
+		{domain: "a.b.example.co.uk", expected: "example"},
+		{domain: "mhernandez.github.io", expected: "mhernandez"},
+		{domain: "github.io", expected: "github"},
+		{domain: "co.uk", expected: "co"},
+		{domain: "localhost", expected: "localhost"},
+		{domain: "192.168.1.1", expected: "192.168.1.1"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.domain, func(t *testing.T) {
+			if actual := secondLevelLabel(tc.domain); actual != tc.expected {
+				t.Errorf("secondLevelLabel(%q) = %q; want %q", tc.domain, actual, tc.expected)
+			}
+		})
+	}
+}
+
+func TestImporter_GroupByRegistered(t *testing.T) {
+	csvInput := `first_name,last_name,email
+A,A,a@eng.corp.com
+B,B,b@eng.corp.com
+C,C,c@corp.com
+D,D,d@sales.corp.com
+E,E,e@cnet.com`
+
+	imp := NewImporter(WithGroupBy(GROUP_BY_REGISTERED))
+	domainsCount, err := imp.Import(strings.NewReader(csvInput))
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+
+	expectedStats := []DomainStat{
+		{Name: "cnet.com", Count: 1, FirstSeenLine: 6, Subdomains: []DomainStat{{Name: "cnet.com", Count: 1, FirstSeenLine: 6}}},
+		{Name: "corp.com", Count: 4, FirstSeenLine: 2, Subdomains: []DomainStat{
+			{Name: "corp.com", Count: 1, FirstSeenLine: 4},
+			{Name: "eng.corp.com", Count: 2, FirstSeenLine: 2},
+			{Name: "sales.corp.com", Count: 1, FirstSeenLine: 5},
+		}},
+	}
+	if !reflect.DeepEqual(domainsCount.DomainStats, expectedStats) {
+		t.Errorf("Domain stats: %v, expected: %v", domainsCount.DomainStats, expectedStats)
+	}
+	if domainsCount.TotalCount != 5 {
+		t.Errorf("Total count: %d, expected: 5", domainsCount.TotalCount)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTo(&buf, *domainsCount, OutputOptions{}); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	expectedOutput := `Total number of customers: 5
+Group: cnet.com
+  Domain: cnet.com, Customers: 1
+  Subtotal: 1
+Group: corp.com
+  Domain: corp.com, Customers: 1
+  Domain: eng.corp.com, Customers: 2
+  Domain: sales.corp.com, Customers: 1
+  Subtotal: 4
+`
+	if buf.String() != expectedOutput {
+		t.Errorf("output %s, expected: %s", buf.String(), expectedOutput)
+	}
+}
+
+func TestImporter_UnsupportedGroupBy(t *testing.T) {
+	imp := NewImporter(WithGroupBy("planet"))
+	_, err := imp.Import(strings.NewReader("email\na@b.com"))
+	if err == nil {
+		t.Error("error expected, got nil")
+	}
+}
+
+func TestImporter_GroupByTLD(t *testing.T) {
+	csvInput := `first_name,last_name,email
+A,A,a@github.io
+B,B,b@corp.com
+C,C,c@cnet.com
+D,D,d@eng.corp.com
+E,E,e@[192.168.1.1]`
+
+	imp := NewImporter(WithGroupBy(GROUP_BY_TLD))
+	domainsCount, err := imp.Import(strings.NewReader(csvInput))
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTo(&buf, *domainsCount, OutputOptions{}); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	expectedOutput := `Total number of customers: 5
+Group: 192.168.1.1
+  Domain: 192.168.1.1, Customers: 1
+  Subtotal: 1
+Group: com
+  Domain: cnet.com, Customers: 1
+  Domain: corp.com, Customers: 1
+  Domain: eng.corp.com, Customers: 1
+  Subtotal: 3
+Group: io
+  Domain: github.io, Customers: 1
+  Subtotal: 1
+`
+	if buf.String() != expectedOutput {
+		t.Errorf("output %s, expected: %s", buf.String(), expectedOutput)
+	}
+}
+
+func TestImporter_GroupBySLD(t *testing.T) {
+	csvInput := "email\na@corp.com\nb@eng.corp.com\nc@corp.co.uk\nd@github.io"
+
+	imp := NewImporter(WithEmailColumn(0), WithGroupBy(GROUP_BY_SLD))
+	domainsCount, err := imp.Import(strings.NewReader(csvInput))
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+
+	expected := []DomainStat{
+		{Name: "corp", Count: 3, FirstSeenLine: 2, Subdomains: []DomainStat{
+			{Name: "corp.co.uk", Count: 1, FirstSeenLine: 4},
+			{Name: "corp.com", Count: 1, FirstSeenLine: 2},
+			{Name: "eng.corp.com", Count: 1, FirstSeenLine: 3},
+		}},
+		{Name: "github", Count: 1, FirstSeenLine: 5, Subdomains: []DomainStat{
+			{Name: "github.io", Count: 1, FirstSeenLine: 5},
+		}},
+	}
+	if !reflect.DeepEqual(domainsCount.DomainStats, expected) {
+		t.Errorf("domain stats %v, expected: %v", domainsCount.DomainStats, expected)
+	}
+}
+
+func TestImporter_GroupByCategory(t *testing.T) {
+	csvInput := `first_name,last_name,email
+A,A,a@gmail.com
+B,B,b@GoogleMail.com
+C,C,c@outlook.com
+D,D,d@cnet.com
+E,E,e@gmail.com`
+
+	imp := NewImporter(
+		WithGroupBy(GROUP_BY_CATEGORY),
+		WithCategoryMap(map[string]string{
+			"gmail.com":      "Google",
+			"GoogleMail.com": "Google",
+			"outlook.com":    "Microsoft",
+			"hotmail.com":    "Microsoft",
+		}),
+	)
+	domainsCount, err := imp.Import(strings.NewReader(csvInput))
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+
+	expectedStats := []DomainStat{
+		{Name: "Google", Count: 3, FirstSeenLine: 2, Subdomains: []DomainStat{
+			{Name: "gmail.com", Count: 2, FirstSeenLine: 2},
+			{Name: "googlemail.com", Count: 1, FirstSeenLine: 3},
+		}},
+		{Name: "Microsoft", Count: 1, FirstSeenLine: 4, Subdomains: []DomainStat{
+			{Name: "outlook.com", Count: 1, FirstSeenLine: 4},
+		}},
+		{Name: CATEGORY_OTHER, Count: 1, FirstSeenLine: 5, Subdomains: []DomainStat{
+			{Name: "cnet.com", Count: 1, FirstSeenLine: 5},
+		}},
+	}
+	if !reflect.DeepEqual(domainsCount.DomainStats, expectedStats) {
+		t.Errorf("Domain stats: %v, expected: %v", domainsCount.DomainStats, expectedStats)
+	}
+}
+
+func TestImporter_GroupByCategoryWithoutMap(t *testing.T) {
+	imp := NewImporter(WithGroupBy(GROUP_BY_CATEGORY))
+	_, err := imp.Import(strings.NewReader("email\na@gmail.com"))
+	if err == nil {
+		t.Error("error expected, got nil")
+	}
+}
+
+func TestDomainLevels(t *testing.T) {
+	testCases := []struct {
+		domain   string
+		expected []string
+	}{
+		{domain: "corp.com", expected: []string{"corp.com"}},
+		{domain: "eng.corp.com", expected: []string{"corp.com", "eng.corp.com"}},
+		{domain: "a.eng.corp.co.uk", expected: []string{"corp.co.uk", "eng.corp.co.uk", "a.eng.corp.co.uk"}},
+		{domain: "192.168.1.1", expected: []string{"192.168.1.1"}},
+	}
+
+	for _, tc := range testCases {
+		if levels := domainLevels(tc.domain); !reflect.DeepEqual(levels, tc.expected) {
+			t.Errorf("domainLevels(%q): %v, expected: %v", tc.domain, levels, tc.expected)
+		}
+	}
+}
+
+func TestTreeLevels(t *testing.T) {
+	testCases := []struct {
+		domain   string
+		expected []string
+	}{
+		{domain: "eng.corp.com", expected: []string{"com", "corp.com", "eng.corp.com"}},
+		{domain: "corp.co.uk", expected: []string{"uk", "corp.co.uk"}},
+		{domain: "github.io", expected: []string{"io", "github.io"}},
+		{domain: "localhost", expected: []string{"localhost"}},
+		{domain: "192.168.1.1", expected: []string{"192.168.1.1"}},
+	}
+
+	for _, tc := range testCases {
+		if levels := treeLevels(tc.domain); !reflect.DeepEqual(levels, tc.expected) {
+			t.Errorf("treeLevels(%q): %v, expected: %v", tc.domain, levels, tc.expected)
+		}
+	}
+}
+
+func TestImporter_GroupByHierarchy(t *testing.T) {
+	csvInput := `first_name,last_name,email
+A,A,a@eng.corp.com
+B,B,b@eng.corp.com
+C,C,c@corp.com
+D,D,d@api.eng.corp.com
+E,E,e@cnet.com`
+
+	imp := NewImporter(WithGroupBy(GROUP_BY_HIERARCHY))
+	domainsCount, err := imp.Import(strings.NewReader(csvInput))
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+
+	expectedStats := []DomainStat{
+		{Name: "cnet.com", Count: 1, FirstSeenLine: 6},
+		{Name: "corp.com", Count: 4, FirstSeenLine: 2, Subdomains: []DomainStat{
+			{Name: "eng.corp.com", Count: 3, FirstSeenLine: 2, Subdomains: []DomainStat{
+				{Name: "api.eng.corp.com", Count: 1, FirstSeenLine: 5},
+			}},
+		}},
+	}
+	if !reflect.DeepEqual(domainsCount.DomainStats, expectedStats) {
+		t.Errorf("Domain stats: %v, expected: %v", domainsCount.DomainStats, expectedStats)
+	}
+	if domainsCount.TotalCount != 5 {
+		t.Errorf("Total count: %d, expected: 5", domainsCount.TotalCount)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTo(&buf, *domainsCount, OutputOptions{}); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	expectedOutput := `Total number of customers: 5
+Domain: cnet.com, Customers: 1
+Domain: corp.com, Customers: 4
+  Domain: eng.corp.com, Customers: 3
+    Domain: api.eng.corp.com, Customers: 1
+`
+	if buf.String() != expectedOutput {
+		t.Errorf("output %s, expected: %s", buf.String(), expectedOutput)
+	}
+
+	if err := WriteTo(&buf, *domainsCount, OutputOptions{Format: FORMAT_CSV}); err == nil {
+		t.Error("error expected writing nested levels as csv, got nil")
+	}
+}
+
+func TestImporter_Regroup(t *testing.T) {
+	csvInput := "email\na@github.io\nb@corp.com\nc@cnet.com\nd@eng.corp.com\ne@github.io"
+
+	domainsCount, err := NewImporter(WithEmailColumn(0)).Import(strings.NewReader(csvInput))
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	for _, groupBy := range []string{GROUP_BY_DOMAIN, GROUP_BY_REGISTERED, GROUP_BY_TLD, GROUP_BY_SLD, GROUP_BY_HIERARCHY} {
+		expected, err := NewImporter(WithEmailColumn(0), WithGroupBy(groupBy)).Import(strings.NewReader(csvInput))
+		if err != nil {
+			t.Fatalf("%s: unexpected error occured: %v", groupBy, err)
+		}
+		regrouped, err := NewImporter().Regroup(*domainsCount, groupBy)
+		if err != nil {
+			t.Fatalf("%s: unexpected error occured: %v", groupBy, err)
+		}
+		if !reflect.DeepEqual(regrouped, expected) {
+			t.Errorf("%s: regrouped %v, expected: %v", groupBy, regrouped, expected)
+		}
+	}
+	if len(domainsCount.DomainStats) != 4 || len(domainsCount.DomainStats[0].Subdomains) != 0 {
+		t.Errorf("domain stats changed by regrouping: %v", domainsCount.DomainStats)
+	}
+
+	grouped, err := NewImporter().Regroup(*domainsCount, GROUP_BY_TLD)
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	if _, err := NewImporter().Regroup(*grouped, GROUP_BY_REGISTERED); err == nil {
+		t.Error("error expected regrouping grouped domains, got nil")
+	}
+}
+
+func TestValidateGroupBy(t *testing.T) {
+	for _, groupBy := range append([]string{""}, GROUP_BYS...) {
+		if err := ValidateGroupBy(groupBy); err != nil {
+			t.Errorf("%q: unexpected error occured: %v", groupBy, err)
+		}
+	}
+	if err := ValidateGroupBy("country"); err == nil {
+		t.Error("error expected for an unsupported group by, got nil")
+	}
+}
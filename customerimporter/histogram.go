@@ -0,0 +1,67 @@
+package customerimporter
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// DEFAULT_HISTOGRAM_BOUNDS bins domains with 1, 2-10, 11-100, 101-1000 and
+// more than 1000 customers.
+var DEFAULT_HISTOGRAM_BOUNDS = []int{1, 10, 100, 1000}
+
+const HISTOGRAM_LINE_FORMAT = "Customers %s: %d domains, %d customers\n"
+
+// HistogramBucket counts the domains whose customer count falls between Min
+// and Max, both inclusive. Max is 0 for the open-ended last bucket.
+type HistogramBucket struct {
+	Min       int `json:"min"`
+	Max       int `json:"max,omitempty"`
+	Domains   int `json:"domains"`
+	Customers int `json:"customers"`
+}
+
+// Histogram bins domainStats by customer count. bounds are the ascending
+// upper bounds of the buckets, followed by a last bucket for the counts above
+// the highest bound.
+func Histogram(domainStats []DomainStat, bounds []int) []HistogramBucket {
+	buckets := make([]HistogramBucket, len(bounds)+1)
+	lower := 1
+	for i, bound := range bounds {
+		buckets[i] = HistogramBucket{Min: lower, Max: bound}
+		lower = bound + 1
+	}
+	buckets[len(bounds)] = HistogramBucket{Min: lower}
+
+	for _, domainStat := range domainStats {
+		i := 0
+		for i < len(bounds) && domainStat.Count > bounds[i] {
+			i++
+		}
+		buckets[i].Domains++
+		buckets[i].Customers += domainStat.Count
+	}
+	return buckets
+}
+
+func writeHistogram(w io.Writer, buckets []HistogramBucket) error {
+	for _, bucket := range buckets {
+		_, err := fmt.Fprintf(w, HISTOGRAM_LINE_FORMAT, bucket.label(), bucket.Domains, bucket.Customers)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// label returns the range of the bucket like 1, 2-10 or 1001+.
+func (b HistogramBucket) label() string {
+	switch b.Max {
+	case 0:
+		return strconv.Itoa(b.Min) + "+"
+	case b.Min:
+		return strconv.Itoa(b.Min)
+	default:
+		return strconv.Itoa(b.Min) + "-" + strconv.Itoa(b.Max)
+	}
+}
@@ -0,0 +1,84 @@
+package customerimporter
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHistogram(t *testing.T) {
+	domainStats := []DomainStat{
+		{Name: "a.com", Count: 1},
+		{Name: "b.com", Count: 1},
+		{Name: "c.com", Count: 2},
+		{Name: "d.com", Count: 10},
+		{Name: "e.com", Count: 11},
+		{Name: "f.com", Count: 250},
+	}
+
+	testCases := []struct {
+		name     string
+		bounds   []int
+		expected []HistogramBucket
+	}{
+		{
+			name:   "default bounds",
+			bounds: DEFAULT_HISTOGRAM_BOUNDS,
+			expected: []HistogramBucket{
+				{Min: 1, Max: 1, Domains: 2, Customers: 2},
+				{Min: 2, Max: 10, Domains: 2, Customers: 12},
+				{Min: 11, Max: 100, Domains: 1, Customers: 11},
+				{Min: 101, Max: 1000, Domains: 1, Customers: 250},
+				{Min: 1001},
+			},
+		},
+		{
+			name:   "single bound",
+			bounds: []int{5},
+			expected: []HistogramBucket{
+				{Min: 1, Max: 5, Domains: 3, Customers: 4},
+				{Min: 6, Domains: 3, Customers: 271},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		buckets := Histogram(domainStats, tc.bounds)
+		if !reflect.DeepEqual(buckets, tc.expected) {
+			t.Errorf("%s: buckets %v, expected: %v", tc.name, buckets, tc.expected)
+		}
+	}
+}
+
+func TestWriteTo_Histogram(t *testing.T) {
+	domainsCount := DomainsCount{DomainStats: []DomainStat{
+		{Name: "cnet.com", Count: 1},
+		{Name: "github.io", Count: 3},
+		{Name: "zoho.com", Count: 12},
+	},
+		TotalCount: 16,
+	}
+	opts := OutputOptions{HistogramBounds: []int{1, 10}}
+
+	var buf bytes.Buffer
+	if err := WriteTo(&buf, domainsCount, opts); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	expectedOutput := "Total number of customers: 16\n" +
+		"Customers 1: 1 domains, 1 customers\n" +
+		"Customers 2-10: 1 domains, 3 customers\n" +
+		"Customers 11+: 1 domains, 12 customers\n"
+	if buf.String() != expectedOutput {
+		t.Errorf("output %s, expected: %s", buf.String(), expectedOutput)
+	}
+
+	buf.Reset()
+	opts.Format = FORMAT_JSON
+	if err := WriteTo(&buf, domainsCount, opts); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"histogram": [`) || !strings.Contains(buf.String(), `"domains": [`) {
+		t.Errorf("output %s, expected the histogram alongside the domains", buf.String())
+	}
+}
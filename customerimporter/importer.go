@@ -0,0 +1,677 @@
+package customerimporter
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"runtime"
+	"strings"
+	"time"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/language"
+)
+
+// Importer counts customers per email domain. It is configured once with
+// functional options and can then import any number of inputs, one at a
+// time; use an Importer per goroutine for concurrent imports. Keeping one
+// Importer for many inputs allocates about as much as creating one per input,
+// as nearly all allocations are per row and per domain.
+type Importer struct {
+	numWorkers        int
+	emailIdx          int
+	emailHeader       string
+	fileColumns       map[string]EmailColumn
+	emailCandidates   []string
+	verbose           bool
+	logger            *log.Logger
+	retries           int
+	retryBackoff      time.Duration
+	delimiter         rune
+	detectDelimiter   bool
+	workerStats       bool
+	domainFilters     []func(domain string) bool
+	postProcess       []func(*DomainsCount) error
+	validator         func(email string) (domain string, ok bool)
+	naturalSort       bool
+	collation         *language.Tag
+	inputEncoding     encoding.Encoding
+	roleAccounts      map[string]struct{}
+	progress          func(Progress)
+	unique            bool
+	uniqueMemoryLimit int
+	spillDir          string
+	groupBy           string
+	categories        map[string]string
+	disposable        map[string]struct{}
+	excludeDisposable bool
+	skipRows          int
+	limit             int
+	fastParse         bool
+	stream            func(DomainStat) error
+	caseSensitive     bool
+	timeColumn        string
+	timeWindow        string
+	topDomains        int
+	inputFormat       string
+	rowFilters        []rowFilter
+	dedupKey          string
+	jsonKey           string
+	allowEmpty        bool
+	explainTarget     string
+	trimChars         string
+	maxEmailLength    int
+	stripMailto       bool
+	weighted          bool
+	weightIdx         int
+	strictWeights     bool
+}
+
+// Option configures an Importer.
+type Option func(*Importer)
+
+// NewImporter returns an Importer using one worker per CPU, reading emails
+// from column EMAIL_IDX of comma-separated input and logging through the
+// standard logger, unless overridden by opts.
+func NewImporter(opts ...Option) *Importer {
+	imp := &Importer{
+		numWorkers:     runtime.NumCPU(),
+		emailIdx:       EMAIL_IDX,
+		logger:         log.Default(),
+		retryBackoff:   DEFAULT_RETRY_BACKOFF,
+		delimiter:      DEFAULT_DELIMITER,
+		jsonKey:        DEFAULT_JSON_KEY,
+		maxEmailLength: DEFAULT_MAX_EMAIL_LENGTH,
+	}
+	for _, opt := range opts {
+		opt(imp)
+	}
+	return imp
+}
+
+// validate reports options that can't be combined, before any input is read.
+// Every import entry point calls it.
+func (imp *Importer) validate() error {
+	if _, err := groupKeyFunc(imp.groupBy, imp.categories); err != nil {
+		return err
+	}
+	if err := imp.validateStream(); err != nil {
+		return err
+	}
+	if err := imp.validateTimeWindow(); err != nil {
+		return err
+	}
+	if err := imp.validateTopDomain(); err != nil {
+		return err
+	}
+	return imp.validateWeights()
+}
+
+// Import reads CSV data from reader and counts customers per email domain.
+// reader is left open for the caller to close, whether or not Import fails.
+func (imp *Importer) Import(reader io.Reader) (*DomainsCount, error) {
+	if err := imp.validate(); err != nil {
+		return &DomainsCount{}, err
+	}
+
+	result, err := imp.processCsv(reader)
+	if err != nil {
+		return &DomainsCount{}, err
+	}
+
+	return imp.newDomainsCount(result)
+}
+
+// ImportCSV counts customers per email domain in the records of csvreader,
+// which the caller configured, e.g. with LazyQuotes or a custom Comma. The
+// header is read from csvreader like from any input, but the options
+// preparing raw input, such as WithDelimiter, WithSkipRows, WithInputEncoding,
+// WithFastParse and WithProgress, don't apply. Setting ReuseRecord saves an
+// allocation per row.
+func (imp *Importer) ImportCSV(csvreader *csv.Reader) (*DomainsCount, error) {
+	if err := imp.validate(); err != nil {
+		return &DomainsCount{}, err
+	}
+
+	result, err := imp.processCsvReader(csvreader)
+	if err != nil {
+		return &DomainsCount{}, err
+	}
+
+	return imp.newDomainsCount(result)
+}
+
+// ImportFile opens the file, http(s) URL or, when built with the s3 tag,
+// s3://bucket/key URL at path and imports it. The input is closed before
+// ImportFile returns, on errors too.
+func (imp *Importer) ImportFile(path string) (*DomainsCount, error) {
+	file, err := imp.openInput(path)
+	if err != nil {
+		return &DomainsCount{}, err
+	}
+	defer file.Close()
+
+	return imp.Import(file)
+}
+
+// ImportFiles imports every file or http(s) URL in paths and sums their
+// counts into a single result. With WithUnique emails are deduplicated within
+// each input but not across inputs.
+func (imp *Importer) ImportFiles(paths ...string) (*DomainsCount, error) {
+	return imp.importFiles(paths, nil)
+}
+
+// importFiles is ImportFiles. With reports, a file that fails is recorded
+// there instead of failing the import, and so is every other file.
+func (imp *Importer) importFiles(paths []string, reports *[]FileReport) (*DomainsCount, error) {
+	if err := imp.validate(); err != nil {
+		return &DomainsCount{}, err
+	}
+	if imp.stream != nil && len(paths) > 1 {
+		return &DomainsCount{}, fmt.Errorf("streaming supports a single input, got %d", len(paths))
+	}
+
+	combined := &csvResult{domainMap: make(map[string]int), roleMap: make(map[string]int), firstSeen: make(map[string]int), warningCounts: make(map[WarningCategory]int)}
+	for _, path := range paths {
+		result, err := imp.importPath(path)
+		if err != nil && reports != nil {
+			*reports = append(*reports, FileReport{Path: path, Err: err})
+			continue
+		}
+		if err != nil {
+			return &DomainsCount{}, fmt.Errorf("error importing %s: %w", path, err)
+		}
+		for i := range result.warnings {
+			result.warnings[i].Input = path
+		}
+		if reports != nil {
+			report := FileReport{Path: path, Customers: result.totalCustomers}
+			for _, count := range result.warningCounts {
+				report.Skipped += count
+			}
+			*reports = append(*reports, report)
+		}
+		combined.add(result)
+	}
+
+	return imp.newDomainsCount(combined)
+}
+
+func (imp *Importer) importPath(path string) (*csvResult, error) {
+	file, err := imp.openInput(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return imp.processCsvColumn(file, imp.fileEmailColumn(path))
+}
+
+// newDomainsCount builds the result of an import from its counts and runs the
+// WithPostProcess hooks on it.
+func (imp *Importer) newDomainsCount(result *csvResult) (*DomainsCount, error) {
+	keyFunc, err := groupKeyFunc(imp.groupBy, imp.categories)
+	if err != nil {
+		return &DomainsCount{}, err
+	}
+	var domainStats []DomainStat
+	if imp.topDomains > 0 {
+		domainStats = topStats(result.domainMap, result.roleMap, imp.topDomains)
+	} else {
+		domainStats = createStats(result.domainMap, result.roleMap, imp.naturalSort)
+	}
+	for i := range domainStats {
+		domainStats[i].FirstSeenLine = result.firstSeen[domainStats[i].Name]
+	}
+	disposableCount := 0
+	if len(imp.disposable) > 0 {
+		domainStats, disposableCount = markDisposable(domainStats, imp.disposable, imp.excludeDisposable)
+		if imp.excludeDisposable {
+			result.totalCustomers -= disposableCount
+		}
+	}
+	domainStats = imp.groupDomainStats(domainStats, imp.groupBy, keyFunc)
+	if imp.timeWindow != "" {
+		domainStats = windowStats(domainStats, imp.naturalSort)
+	}
+	// The top domains are ordered by count rather than by name.
+	if imp.collation != nil && imp.topDomains <= 0 {
+		collateStats(domainStats, *imp.collation)
+	}
+
+	domainsCount := &DomainsCount{
+		DomainStats:     domainStats,
+		TotalCount:      result.totalCustomers,
+		WorkerCounts:    result.workerCounts,
+		DisposableCount: disposableCount,
+		Warnings:        result.warnings,
+		WarningCounts:   result.warningCounts,
+		Hierarchy:       imp.groupBy == GROUP_BY_HIERARCHY,
+		InvalidCount:    result.warningCounts[WARNING_BAD_EMAIL] + result.warningCounts[WARNING_EMPTY_EMAIL] + result.warningCounts[WARNING_LONG_EMAIL],
+	}
+	if result.sampled {
+		domainsCount.SampleRows = imp.limit
+	}
+	for _, hook := range imp.postProcess {
+		if err := hook(domainsCount); err != nil {
+			return &DomainsCount{}, fmt.Errorf("error post-processing the result: %w", err)
+		}
+	}
+	return domainsCount, nil
+}
+
+// WithWorkers sets the number of goroutines extracting domains. Values below
+// one are ignored. Inputs too small to keep them busy, going by their size
+// or the WithLimit, get fewer workers.
+func WithWorkers(numWorkers int) Option {
+	return func(imp *Importer) {
+		if numWorkers > 0 {
+			imp.numWorkers = numWorkers
+		}
+	}
+}
+
+// WithEmailColumn sets the zero-based index of the column holding the email
+// address. It defaults to EMAIL_IDX. Negative indexes count from the end of
+// each row, with LAST_COLUMN selecting the last field even in ragged files.
+func WithEmailColumn(emailIdx int) Option {
+	return func(imp *Importer) {
+		imp.emailIdx = emailIdx
+	}
+}
+
+// WithEmailHeader finds the email column by its header name instead of by
+// index, ignoring case. It fails the import when no column or more than one
+// column has that name.
+func WithEmailHeader(name string) Option {
+	return func(imp *Importer) {
+		imp.emailHeader = name
+	}
+}
+
+// WithEmailHeaderCandidates finds the email column as the first header column
+// matching one of names, tried in order, ignoring case and separators such as
+// "-" and "_". It fails the import when no column matches.
+func WithEmailHeaderCandidates(names ...string) Option {
+	return func(imp *Importer) {
+		imp.emailCandidates = names
+	}
+}
+
+// WithDomainFilter adds a filter that every extracted domain has to pass to be
+// counted. Filters are called concurrently from several workers.
+func WithDomainFilter(filter func(domain string) bool) Option {
+	return func(imp *Importer) {
+		imp.domainFilters = append(imp.domainFilters, filter)
+	}
+}
+
+// WithPostProcess adds a hook that may change the final result, e.g. rename
+// or merge domains, before it is returned. Hooks run in the order added and
+// an error aborts the import.
+func WithPostProcess(hook func(*DomainsCount) error) Option {
+	return func(imp *Importer) {
+		imp.postProcess = append(imp.postProcess, hook)
+	}
+}
+
+// WithValidator replaces the built-in email validation. validator returns the
+// domain to count email under, or false to skip email as invalid, e.g. to
+// require a dot in the domain or to reject disposable email domains. It is
+// called concurrently from several workers, with surrounding whitespace
+// already trimmed from email.
+func WithValidator(validator func(email string) (domain string, ok bool)) Option {
+	return func(imp *Importer) {
+		imp.validator = validator
+	}
+}
+
+// WithLogger sets the logger for warnings about skipped rows and debug
+// messages. Pass a logger writing to io.Discard to silence the import.
+func WithLogger(logger *log.Logger) Option {
+	return func(imp *Importer) {
+		imp.logger = logger
+	}
+}
+
+// WithVerbose enables debug-level log messages, such as the end of file
+// notice, which are silenced by default.
+func WithVerbose(verbose bool) Option {
+	return func(imp *Importer) {
+		imp.verbose = verbose
+	}
+}
+
+func (imp *Importer) debugf(format string, v ...any) {
+	if imp.verbose {
+		imp.logger.Printf(format, v...)
+	}
+}
+
+// WithRetries sets how many times fetching a URL input is retried after a
+// connection error or a 5xx response.
+func WithRetries(retries int) Option {
+	return func(imp *Importer) {
+		imp.retries = retries
+	}
+}
+
+// WithDelimiter sets the field delimiter of the CSV input. It defaults to a
+// comma.
+func WithDelimiter(delimiter rune) Option {
+	return func(imp *Importer) {
+		imp.delimiter = delimiter
+	}
+}
+
+// WithDetectDelimiter makes the delimiter be sniffed from the header line,
+// choosing between comma, tab, semicolon and pipe. It takes precedence over
+// WithDelimiter.
+func WithDetectDelimiter(detect bool) Option {
+	return func(imp *Importer) {
+		imp.detectDelimiter = detect
+	}
+}
+
+// WithWorkerStats records how many emails each worker processed in
+// DomainsCount.WorkerCounts, which helps spotting unbalanced work.
+func WithWorkerStats(enabled bool) Option {
+	return func(imp *Importer) {
+		imp.workerStats = enabled
+	}
+}
+
+// WithNaturalSort orders domains with embedded numbers in human order, e.g.
+// site2.com before site10.com, instead of plain lexical order.
+func WithNaturalSort(natural bool) Option {
+	return func(imp *Importer) {
+		imp.naturalSort = natural
+	}
+}
+
+// WithCollation orders domains, e.g. internationalized ones, by the
+// collation rules of locale instead of byte by byte, which is slower. It
+// takes precedence over WithNaturalSort.
+func WithCollation(locale language.Tag) Option {
+	return func(imp *Importer) {
+		imp.collation = &locale
+	}
+}
+
+// WithInputFormat sets the compression of the input, INPUT_FORMAT_CSV for
+// plain or INPUT_FORMAT_CSV_GZIP for gzip-compressed CSV, for inputs like
+// stdin without a telling name. By default gzip input is recognized by its
+// leading magic bytes. INPUT_FORMAT_JSONL reads one JSON object per line
+// instead, taking the email from its WithJSONKey field.
+func WithInputFormat(format string) Option {
+	return func(imp *Importer) {
+		imp.inputFormat = format
+	}
+}
+
+// WithWeightColumn counts every row as the number of customers in the
+// column at the zero-based weightIdx, e.g. a quantity column of pre-aggregated
+// input, instead of once. See WithStrictWeights for invalid weights.
+func WithWeightColumn(weightIdx int) Option {
+	return func(imp *Importer) {
+		imp.weighted = true
+		imp.weightIdx = weightIdx
+	}
+}
+
+// WithStrictWeights skips the rows whose WithWeightColumn weight is missing
+// or not a positive integer instead of counting them once.
+func WithStrictWeights(strict bool) Option {
+	return func(imp *Importer) {
+		imp.strictWeights = strict
+	}
+}
+
+// WithTrimChars strips the characters of cutset, e.g. quotes or backticks
+// wrapping the cells of some exports, from both ends of every email in
+// addition to whitespace.
+func WithTrimChars(cutset string) Option {
+	return func(imp *Importer) {
+		imp.trimChars = cutset
+	}
+}
+
+// WithMaxEmailLength skips emails longer than length bytes as
+// WARNING_LONG_EMAIL before looking for their domain, so that a blob stuffed
+// into the email column isn't counted as an absurd domain. It defaults to
+// DEFAULT_MAX_EMAIL_LENGTH; zero or less disables the limit.
+func WithMaxEmailLength(length int) Option {
+	return func(imp *Importer) {
+		imp.maxEmailLength = length
+	}
+}
+
+// WithExplain logs, for every row of target, an email or a domain, whether it
+// was counted and why not otherwise, to debug missing customers. Only target
+// is traced, so the cost is bounded by its rows.
+func WithExplain(target string) Option {
+	return func(imp *Importer) {
+		imp.explainTarget = strings.TrimSpace(target)
+	}
+}
+
+// WithAllowEmpty returns an empty result for inputs whose emails all lack a
+// domain, or whose rows all lack the email column, instead of failing with
+// ErrNoValidEmails.
+func WithAllowEmpty(allow bool) Option {
+	return func(imp *Importer) {
+		imp.allowEmpty = allow
+	}
+}
+
+// WithJSONKey sets the field holding the email in INPUT_FORMAT_JSONL objects,
+// DEFAULT_JSON_KEY by default.
+func WithJSONKey(key string) Option {
+	return func(imp *Importer) {
+		imp.jsonKey = key
+	}
+}
+
+// WithInputEncoding decodes the input from enc, e.g. charmap.Windows1252 for
+// legacy Latin-1 exports, before parsing it. A nil enc reads the input as
+// UTF-8, which is the default.
+func WithInputEncoding(enc encoding.Encoding) Option {
+	return func(imp *Importer) {
+		imp.inputEncoding = enc
+	}
+}
+
+// WithRoleAccounts counts, per domain, the addresses whose local part is one
+// of localParts, such as info, support or noreply, in DomainStat.RoleCount.
+// Local parts are matched ignoring case.
+func WithRoleAccounts(localParts []string) Option {
+	return func(imp *Importer) {
+		imp.roleAccounts = make(map[string]struct{}, len(localParts))
+		for _, localPart := range localParts {
+			imp.roleAccounts[strings.ToLower(strings.TrimSpace(localPart))] = struct{}{}
+		}
+	}
+}
+
+// WithProgress calls report every PROGRESS_INTERVAL rows and once more when
+// the input is exhausted. Progress includes the share of bytes read when the
+// input size is known, as it is for regular files. report is called from the
+// reading goroutine and should return quickly.
+func WithProgress(report func(Progress)) Option {
+	return func(imp *Importer) {
+		imp.progress = report
+	}
+}
+
+// WithUnique counts every distinct email address once, comparing addresses
+// ignoring case, so customers listed several times aren't counted twice.
+func WithUnique(unique bool) Option {
+	return func(imp *Importer) {
+		imp.unique = unique
+	}
+}
+
+// WithUniqueMemoryLimit sets how many distinct emails WithUnique holds in
+// memory before spilling them, sorted, to temporary files in dir, which are
+// merged at the end. An empty dir uses os.TempDir. The limit defaults to
+// DEFAULT_UNIQUE_MEMORY_LIMIT.
+func WithUniqueMemoryLimit(limit int, dir string) Option {
+	return func(imp *Importer) {
+		imp.uniqueMemoryLimit = limit
+		imp.spillDir = dir
+	}
+}
+
+// WithGroupBy rolls the counts up to coarser groups, with the domains that
+// contributed to each group listed in DomainStat.Subdomains.
+// GROUP_BY_REGISTERED groups by registrable domain, like corp.com,
+// GROUP_BY_TLD by top-level domain, like com, GROUP_BY_SLD by the first label
+// of the registrable domain, like corp whatever the suffix, and
+// GROUP_BY_CATEGORY by the provider categories of WithCategoryMap.
+// GROUP_BY_HIERARCHY counts every level of a domain from its registered
+// domain down, like corp.com and eng.corp.com, nesting the levels below in
+// Subdomains; mind that this adds a count per level. The default,
+// GROUP_BY_DOMAIN, counts full domains.
+func WithGroupBy(groupBy string) Option {
+	return func(imp *Importer) {
+		imp.groupBy = groupBy
+	}
+}
+
+// WithCategoryMap sets the categories of GROUP_BY_CATEGORY, mapping domains
+// like gmail.com and googlemail.com to a provider like Google. Domains are
+// matched ignoring case; unmapped domains are counted as CATEGORY_OTHER.
+func WithCategoryMap(categories map[string]string) Option {
+	return func(imp *Importer) {
+		imp.categories = make(map[string]string, len(categories))
+		for domain, category := range categories {
+			imp.categories[strings.ToLower(strings.TrimSpace(domain))] = category
+		}
+	}
+}
+
+// WithDisposableDomains marks the domains of disposable email providers, like
+// mailinator.com, and their subdomains in DomainStat.Disposable and counts
+// their customers in DomainsCount.DisposableCount. With exclude those domains
+// are left out of the stats and the total instead of being marked.
+func WithDisposableDomains(domains []string, exclude bool) Option {
+	return func(imp *Importer) {
+		imp.disposable = make(map[string]struct{}, len(domains))
+		for _, domain := range domains {
+			imp.disposable[strings.ToLower(strings.TrimSpace(domain))] = struct{}{}
+		}
+		imp.excludeDisposable = exclude
+	}
+}
+
+// WithSkipRows discards n preamble lines, like export titles or metadata,
+// before the header. Line numbers in log messages count the skipped lines.
+func WithSkipRows(n int) Option {
+	return func(imp *Importer) {
+		imp.skipRows = n
+	}
+}
+
+// WithLimit stops the import after the emails of the first n data rows, for
+// quickly profiling a large input. When the limit is reached the result is
+// marked as a sample in DomainsCount.SampleRows. Values below one read the
+// whole input.
+func WithLimit(n int) Option {
+	return func(imp *Importer) {
+		imp.limit = n
+	}
+}
+
+// WithFastParse splits lines on the delimiter up to the email column instead
+// of parsing them with encoding/csv, which is considerably cheaper for wide
+// files. Quoted fields are not supported, so it is only correct for input
+// known to contain no quotes. Comparing the header and row field counts is
+// skipped as well.
+func WithFastParse(fast bool) Option {
+	return func(imp *Importer) {
+		imp.fastParse = fast
+	}
+}
+
+// WithStream makes an import pass every domain to stream, in name order,
+// instead of collecting them in DomainsCount.DomainStats, which stays empty.
+// Only the total is returned. The domains are streamed once the input was
+// read, from the domain counts and a sorted slice of their names, so the
+// domain stats are never held all at once. A WithUnique import streams every
+// domain as soon as all of its distinct emails are known. An error returned
+// by stream aborts the import. Grouping, natural sort, time windows and
+// disposable domains need all domains at once and are not supported.
+func WithStream(stream func(DomainStat) error) Option {
+	return func(imp *Importer) {
+		imp.stream = stream
+	}
+}
+
+// WithCaseSensitive counts domains differing in case, like GitHub.io and
+// github.io, separately instead of lowercasing them. Category maps, role
+// accounts and disposable domains are still matched in lowercase.
+func WithCaseSensitive(caseSensitive bool) Option {
+	return func(imp *Importer) {
+		imp.caseSensitive = caseSensitive
+	}
+}
+
+// WithTimeWindow counts domains per window of the timestamps in the header
+// column called column, bucketed by granularity, WINDOW_HOUR or WINDOW_DAY.
+// The result has a group per window with its domains as Subdomains. Rows
+// whose timestamp is missing or neither RFC 3339, a date and time like
+// "2006-01-02 15:04:05" nor a date are counted in the WINDOW_UNKNOWN window.
+func WithTimeWindow(column, granularity string) Option {
+	return func(imp *Importer) {
+		imp.timeColumn = column
+		imp.timeWindow = granularity
+	}
+}
+
+// WithRowFilter counts only the rows whose header column called column holds
+// value, compared ignoring case. Every filter added must match.
+func WithRowFilter(column, value string) Option {
+	return func(imp *Importer) {
+		imp.rowFilters = append(imp.rowFilters, rowFilter{column: column, value: strings.TrimSpace(value)})
+	}
+}
+
+// WithDedupKey counts the rows sharing a value of the header column called
+// column as one customer, the email of the first such row, for exports with a
+// row per contact method of a customer. Keys are compared exactly after
+// trimming spaces, and rows without a key are counted individually. Only rows
+// with a valid email take a key, so the first valid email of a customer is
+// counted even after rows of the customer with invalid ones. Every
+// distinct key of an input stays in memory until the input is read, unbounded
+// by WithUniqueMemoryLimit, at roughly 50 to 100 bytes per short key like a
+// customer ID, so a million distinct keys take tens of megabytes.
+func WithDedupKey(column string) Option {
+	return func(imp *Importer) {
+		imp.dedupKey = column
+	}
+}
+
+// WithTopDomain keeps only the domain with the most customers, ties going to
+// the alphabetically first, found without sorting all domains.
+func WithTopDomain(top bool) Option {
+	return func(imp *Importer) {
+		imp.topDomains = 0
+		if top {
+			imp.topDomains = 1
+		}
+	}
+}
+
+// WithTopDomains keeps only the n domains with the most customers, ordered by
+// count, largest first, with ties in name order. They are selected while the
+// domain stats are built, keeping n of them at a time, so neither the stats
+// of all domains nor their sort are needed, which matters for inputs with
+// millions of domains. Values below one keep all domains. The result only
+// holds these n domains, so its distinct domains are n at most, and its count
+// order isn't the name order MergeResults needs.
+func WithTopDomains(n int) Option {
+	return func(imp *Importer) {
+		imp.topDomains = n
+	}
+}
@@ -0,0 +1,402 @@
+package customerimporter
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+func TestImporter_Import(t *testing.T) {
+	csvInput := `email,first_name
+mhernandez0@github.io,Mildred
+bortiz1@github.io,Bonnie
+nallen8@cnet.com,Norma
+dhenry2@example.org,Dennis`
+
+	var logs bytes.Buffer
+	imp := NewImporter(
+		WithWorkers(2),
+		WithEmailColumn(0),
+		WithDomainFilter(func(domain string) bool { return domain != "example.org" }),
+		WithLogger(log.New(&logs, "", 0)),
+	)
+
+	domainsCount, err := imp.Import(strings.NewReader(csvInput))
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+
+	expectedStats := []DomainStat{
+		{Name: "cnet.com", Count: 1, FirstSeenLine: 4},
+		{Name: "github.io", Count: 2, FirstSeenLine: 2},
+	}
+	if domainsCount.TotalCount != 3 {
+		t.Errorf("Total count: %d, expected: 3", domainsCount.TotalCount)
+	}
+	if len(domainsCount.DomainStats) != len(expectedStats) {
+		t.Fatalf("Domain stats: %v, expected: %v", domainsCount.DomainStats, expectedStats)
+	}
+	for i, domain := range domainsCount.DomainStats {
+		if !reflect.DeepEqual(domain, expectedStats[i]) {
+			t.Errorf("Domain stat: %v, expected: %v", domain, expectedStats[i])
+		}
+	}
+}
+
+func TestImporter_Logger(t *testing.T) {
+	csvInput := `first_name,last_name,email
+Mildred,Hernandez,not-an-email
+Bonnie,Ortiz,bortiz1@cyberchimps.com`
+
+	var logs bytes.Buffer
+	imp := NewImporter(WithLogger(log.New(&logs, "", 0)))
+
+	_, err := imp.Import(strings.NewReader(csvInput))
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+
+	if !strings.Contains(logs.String(), "Invalid email address") {
+		t.Errorf("expected invalid email warning in logs, got: %q", logs.String())
+	}
+}
+
+func TestImporter_Verbose(t *testing.T) {
+	testCases := []struct {
+		name        string
+		opts        []Option
+		input       string
+		expectedLog bool
+	}{
+		{name: "quiet", input: "email\na@github.io\n"},
+		{name: "verbose", opts: []Option{WithVerbose(true)}, input: "email\na@github.io\n", expectedLog: true},
+		{name: "verbose_fast_parse", opts: []Option{WithVerbose(true), WithFastParse(true)}, input: "email\na@github.io\n", expectedLog: true},
+		{name: "verbose_jsonl", opts: []Option{WithVerbose(true), WithInputFormat(INPUT_FORMAT_JSONL)}, input: `{"email": "a@github.io"}` + "\n", expectedLog: true},
+	}
+
+	for _, tc := range testCases {
+		var logs bytes.Buffer
+		imp := NewImporter(append([]Option{WithEmailColumn(0), WithLogger(log.New(&logs, "", 0))}, tc.opts...)...)
+		if _, err := imp.Import(strings.NewReader(tc.input)); err != nil {
+			t.Fatalf("%s: unexpected error occured: %v", tc.name, err)
+		}
+		if logged := strings.Contains(logs.String(), "End of file reached"); logged != tc.expectedLog {
+			t.Errorf("%s: end of file logged: %v, expected: %v", tc.name, logged, tc.expectedLog)
+		}
+	}
+}
+
+func TestWithWorkers_IgnoresNonPositive(t *testing.T) {
+	imp := NewImporter(WithWorkers(3), WithWorkers(0), WithWorkers(-1))
+	if imp.numWorkers != 3 {
+		t.Errorf("numWorkers: %d, expected: 3", imp.numWorkers)
+	}
+}
+
+func TestImporter_InputEncoding(t *testing.T) {
+	csvInput, err := charmap.Windows1252.NewEncoder().String(`first_name,last_name,email
+José,Müller,jose@münchen.de
+Zoë,Brontë,zoe@münchen.de`)
+	if err != nil {
+		t.Fatalf("error encoding test input: %v", err)
+	}
+
+	imp := NewImporter(WithInputEncoding(charmap.Windows1252))
+	domainsCount, err := imp.Import(strings.NewReader(csvInput))
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+
+	if len(domainsCount.DomainStats) != 1 {
+		t.Fatalf("Domain stats: %v, expected a single domain", domainsCount.DomainStats)
+	}
+	if domainsCount.DomainStats[0].Name != "münchen.de" || domainsCount.DomainStats[0].Count != 2 {
+		t.Errorf("Domain stat: %v, expected: {münchen.de 2}", domainsCount.DomainStats[0])
+	}
+}
+
+func TestImporter_RoleAccounts(t *testing.T) {
+	csvInput := `first_name,last_name,email
+Mildred,Hernandez,mhernandez0@github.io
+Help,Desk,Support@github.io
+No,Reply,noreply@github.io
+Norma,Allen,nallen8@cnet.com
+Info,Desk,info@cnet.com`
+
+	imp := NewImporter(WithRoleAccounts([]string{"info", " support", "NoReply"}))
+	domainsCount, err := imp.Import(strings.NewReader(csvInput))
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+
+	expectedStats := []DomainStat{
+		{Name: "cnet.com", Count: 2, RoleCount: 1, FirstSeenLine: 5},
+		{Name: "github.io", Count: 3, RoleCount: 2, FirstSeenLine: 2},
+	}
+	if len(domainsCount.DomainStats) != len(expectedStats) {
+		t.Fatalf("Domain stats: %v, expected: %v", domainsCount.DomainStats, expectedStats)
+	}
+	for i, domain := range domainsCount.DomainStats {
+		if !reflect.DeepEqual(domain, expectedStats[i]) {
+			t.Errorf("Domain stat: %v, expected: %v", domain, expectedStats[i])
+		}
+	}
+}
+
+func TestDomainStatsFromReader(t *testing.T) {
+	csvInput := `first_name,last_name,email
+Mildred,Hernandez,mhernandez0@github.io
+Norma,Allen,nallen8@cnet.com
+Bonnie,Ortiz,bortiz1@github.io`
+
+	domainStats, err := DomainStatsFromReader(strings.NewReader(csvInput), WithWorkers(2))
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+
+	expectedStats := []DomainStat{
+		{Name: "cnet.com", Count: 1, FirstSeenLine: 3},
+		{Name: "github.io", Count: 2, FirstSeenLine: 2},
+	}
+	if !reflect.DeepEqual(domainStats, expectedStats) {
+		t.Errorf("Domain stats: %v, expected: %v", domainStats, expectedStats)
+	}
+
+	if _, err := DomainStatsFromReader(strings.NewReader("")); err == nil {
+		t.Error("error expected for empty input, got nil")
+	}
+}
+
+func TestImporter_Limit(t *testing.T) {
+	csvInput := `first_name,last_name,email
+Mildred,Hernandez,mhernandez0@github.io
+Norma,Allen,nallen8@cnet.com
+Bonnie,Ortiz,bortiz1@github.io
+Dennis,Henry,dhenry2@hubpages.com`
+
+	testCases := []struct {
+		name               string
+		limit              int
+		expectedTotal      int
+		expectedSampleRows int
+	}{
+		{name: "sample", limit: 2, expectedTotal: 2, expectedSampleRows: 2},
+		{name: "above_row_count", limit: 10, expectedTotal: 4, expectedSampleRows: 0},
+		{name: "disabled", limit: 0, expectedTotal: 4, expectedSampleRows: 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			domainsCount, err := NewImporter(WithWorkers(2), WithLimit(tc.limit)).Import(strings.NewReader(csvInput))
+			if err != nil {
+				t.Fatalf("unexpected error occured: %v", err)
+			}
+			if domainsCount.TotalCount != tc.expectedTotal {
+				t.Errorf("Total count: %d, expected: %d", domainsCount.TotalCount, tc.expectedTotal)
+			}
+			if domainsCount.SampleRows != tc.expectedSampleRows {
+				t.Errorf("Sample rows: %d, expected: %d", domainsCount.SampleRows, tc.expectedSampleRows)
+			}
+		})
+	}
+}
+
+func TestImporter_Validator(t *testing.T) {
+	csvInput := `first_name,last_name,email
+Mildred,Hernandez,mhernandez0@github.io
+Norma,Allen,nallen8@localhost
+Bonnie,Ortiz,bortiz1@mailinator.com
+Dennis,Henry,dhenry2@GitHub.io`
+
+	requireDot := func(email string) (string, bool) {
+		domain := extractDomain(email)
+		if !strings.Contains(domain, ".") || domain == "mailinator.com" {
+			return "", false
+		}
+		return domain, true
+	}
+
+	var logs bytes.Buffer
+	imp := NewImporter(WithValidator(requireDot), WithLogger(log.New(&logs, "", 0)))
+	domainsCount, err := imp.Import(strings.NewReader(csvInput))
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+
+	expectedStats := []DomainStat{{Name: "github.io", Count: 2, FirstSeenLine: 2}}
+	if !reflect.DeepEqual(domainsCount.DomainStats, expectedStats) {
+		t.Errorf("Domain stats: %v, expected: %v", domainsCount.DomainStats, expectedStats)
+	}
+	if !strings.Contains(logs.String(), "2 bad email") {
+		t.Errorf("expected two bad emails in the skip summary, got: %s", logs.String())
+	}
+}
+
+func TestImporter_ImportFiles(t *testing.T) {
+	dir := t.TempDir()
+	inputs := map[string]string{
+		"2024-01.csv": "first_name,last_name,email\nMildred,Hernandez,mhernandez0@github.io\nNorma,Allen,nallen8@cnet.com",
+		"2024-02.csv": "first_name,last_name,email\nBonnie,Ortiz,bortiz1@github.io",
+	}
+	paths := make([]string, 0, len(inputs))
+	for name, content := range inputs {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("error writing input file: %v", err)
+		}
+		paths = append(paths, path)
+	}
+
+	domainsCount, err := NewImporter(WithWorkers(2)).ImportFiles(paths...)
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+
+	expectedStats := []DomainStat{
+		{Name: "cnet.com", Count: 1, FirstSeenLine: 3},
+		{Name: "github.io", Count: 2, FirstSeenLine: 2},
+	}
+	if !reflect.DeepEqual(domainsCount.DomainStats, expectedStats) {
+		t.Errorf("Domain stats: %v, expected: %v", domainsCount.DomainStats, expectedStats)
+	}
+	if domainsCount.TotalCount != 3 {
+		t.Errorf("Total count: %d, expected: 3", domainsCount.TotalCount)
+	}
+
+	_, err = NewImporter().ImportFiles(paths[0], filepath.Join(dir, "missing.csv"))
+	if err == nil || !strings.Contains(err.Error(), "missing.csv") {
+		t.Errorf("expected an error naming the missing file, got: %v", err)
+	}
+}
+
+func TestImporter_ImportCSV(t *testing.T) {
+	csvInput := `email;note
+mhernandez0@github.io;"quoted "inner" value"
+bortiz1@github.io;ok
+nallen8@cnet.com;ok`
+
+	csvreader := csv.NewReader(strings.NewReader(csvInput))
+	csvreader.Comma = ';'
+	csvreader.LazyQuotes = true
+
+	domainsCount, err := NewImporter(WithEmailColumn(0)).ImportCSV(csvreader)
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+
+	expectedStats := []DomainStat{
+		{Name: "cnet.com", Count: 1, FirstSeenLine: 4},
+		{Name: "github.io", Count: 2, FirstSeenLine: 2},
+	}
+	if !reflect.DeepEqual(domainsCount.DomainStats, expectedStats) {
+		t.Errorf("Domain stats: %v, expected: %v", domainsCount.DomainStats, expectedStats)
+	}
+	if domainsCount.TotalCount != 3 {
+		t.Errorf("Total count: %d, expected: 3", domainsCount.TotalCount)
+	}
+}
+
+func TestImporter_CaseSensitive(t *testing.T) {
+	csvInput := `first_name,last_name,email
+Mildred,Hernandez,mhernandez0@GitHub.io
+Bonnie,Ortiz,bortiz1@github.io
+Norma,Allen,nallen8@github.io.`
+
+	testCases := []struct {
+		name          string
+		caseSensitive bool
+		expectedStats []DomainStat
+	}{
+		{
+			name:          "default",
+			expectedStats: []DomainStat{{Name: "github.io", Count: 3, FirstSeenLine: 2}},
+		},
+		{
+			name:          "case_sensitive",
+			caseSensitive: true,
+			expectedStats: []DomainStat{
+				{Name: "GitHub.io", Count: 1, FirstSeenLine: 2},
+				{Name: "github.io", Count: 2, FirstSeenLine: 3},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			domainsCount, err := NewImporter(WithCaseSensitive(tc.caseSensitive)).Import(strings.NewReader(csvInput))
+			if err != nil {
+				t.Fatalf("unexpected error occured: %v", err)
+			}
+			if !reflect.DeepEqual(domainsCount.DomainStats, tc.expectedStats) {
+				t.Errorf("Domain stats: %v, expected: %v", domainsCount.DomainStats, tc.expectedStats)
+			}
+		})
+	}
+}
+
+func TestImporter_PostProcess(t *testing.T) {
+	csvInput := "email\na@github.io\nb@cnet.com\nc@github.io"
+
+	merge := func(domainsCount *DomainsCount) error {
+		merged := DomainStat{Name: "all"}
+		for _, stat := range domainsCount.DomainStats {
+			merged.Count += stat.Count
+		}
+		domainsCount.DomainStats = []DomainStat{merged}
+		return nil
+	}
+	rename := func(domainsCount *DomainsCount) error {
+		domainsCount.DomainStats[0].Name += ".example"
+		return nil
+	}
+	domainsCount, err := NewImporter(WithEmailColumn(0), WithPostProcess(merge), WithPostProcess(rename)).Import(strings.NewReader(csvInput))
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	expected := []DomainStat{{Name: "all.example", Count: 3}}
+	if !reflect.DeepEqual(domainsCount.DomainStats, expected) {
+		t.Errorf("domain stats %v, expected: %v", domainsCount.DomainStats, expected)
+	}
+
+	errHook := errors.New("hook failed")
+	_, err = NewImporter(WithEmailColumn(0), WithPostProcess(func(*DomainsCount) error { return errHook })).Import(strings.NewReader(csvInput))
+	if !errors.Is(err, errHook) {
+		t.Errorf("error %v, expected: %v", err, errHook)
+	}
+}
+
+func TestImporter_ValidateEntryPoints(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "customers.csv")
+	if err := os.WriteFile(path, []byte("email\na@github.io\n"), 0644); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	testCases := []struct {
+		name string
+		opts []Option
+	}{
+		{name: "group_by", opts: []Option{WithGroupBy("bogus")}},
+		{name: "top_domain", opts: []Option{WithTopDomain(true), WithGroupBy(GROUP_BY_TLD)}},
+		{name: "weights", opts: []Option{WithWeightColumn(-2)}},
+	}
+
+	for _, tc := range testCases {
+		imp := NewImporter(append([]Option{WithEmailColumn(0)}, tc.opts...)...)
+		if _, err := imp.Import(strings.NewReader("email\na@github.io\n")); err == nil {
+			t.Errorf("%s: Import: error expected, got nil", tc.name)
+		}
+		if _, err := imp.ImportCSV(csv.NewReader(strings.NewReader("email\na@github.io\n"))); err == nil {
+			t.Errorf("%s: ImportCSV: error expected, got nil", tc.name)
+		}
+		if _, err := imp.ImportFiles(path); err == nil {
+			t.Errorf("%s: ImportFiles: error expected, got nil", tc.name)
+		}
+	}
+}
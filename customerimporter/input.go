@@ -0,0 +1,147 @@
+package customerimporter
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const DEFAULT_RETRY_BACKOFF = 500 * time.Millisecond
+
+// HTTP_TIMEOUT bounds a fetch of an http(s) input, reading the body included,
+// so that a stalled server fails the attempt instead of hanging the import.
+const HTTP_TIMEOUT = 5 * time.Minute
+
+// httpClient fetches http(s) inputs.
+var httpClient = &http.Client{Timeout: HTTP_TIMEOUT}
+
+const INPUT_FORMAT_CSV = "csv"
+const INPUT_FORMAT_CSV_GZIP = "csv.gz"
+const INPUT_FORMAT_JSONL = "jsonl"
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+var gzipMagic = []byte{0x1f, 0x8b}
+
+const S3_SCHEME = "s3://"
+
+// openS3 streams the object at an s3://bucket/key URL, set when built with
+// the s3 tag.
+var openS3 func(rawURL string) (io.ReadCloser, error)
+
+func isURL(path string) bool {
+	return strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://")
+}
+
+func (imp *Importer) openInput(path string) (io.ReadCloser, error) {
+	if isURL(path) {
+		return imp.fetchURL(path)
+	}
+	if strings.HasPrefix(path, S3_SCHEME) {
+		if openS3 == nil {
+			return nil, fmt.Errorf("s3 input requires building with -tags s3: %s", path)
+		}
+		return openS3(path)
+	}
+	return os.Open(path)
+}
+
+// parseS3URL splits an s3://bucket/key URL into its bucket and key.
+func parseS3URL(rawURL string) (string, string, error) {
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(rawURL, S3_SCHEME), "/")
+	if !strings.HasPrefix(rawURL, S3_SCHEME) || bucket == "" || key == "" {
+		return "", "", fmt.Errorf("invalid s3 URL, expected s3://bucket/key: %s", rawURL)
+	}
+	return bucket, key, nil
+}
+
+// fetchURL issues a GET request for rawURL, retrying connection errors and
+// 5xx responses up to imp.retries times with exponential backoff. 4xx
+// responses are returned as errors straight away.
+func (imp *Importer) fetchURL(rawURL string) (io.ReadCloser, error) {
+	backoff := imp.retryBackoff
+	for attempt := 0; ; attempt++ {
+		resp, err := httpClient.Get(rawURL)
+		if err == nil && resp.StatusCode < 300 {
+			return sizedReadCloser{ReadCloser: resp.Body, size: resp.ContentLength}, nil
+		}
+
+		retryable := false
+		if err != nil {
+			var netErr net.Error
+			retryable = errors.As(err, &netErr)
+			err = fmt.Errorf("error fetching %s: %v", rawURL, err)
+		} else {
+			resp.Body.Close()
+			retryable = resp.StatusCode >= 500
+			err = fmt.Errorf("error fetching %s: unexpected status %s", rawURL, resp.Status)
+		}
+
+		if !retryable || attempt >= imp.retries {
+			return nil, err
+		}
+
+		imp.logger.Printf("%v, retrying in %s\n", err, backoff)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// decompress returns reader decompressed as the WithInputFormat says or, when
+// it isn't set, as the leading magic bytes suggest. JSON Lines input is
+// decompressed by its magic bytes too.
+func (imp *Importer) decompress(reader io.Reader) (io.Reader, error) {
+	switch imp.inputFormat {
+	case INPUT_FORMAT_CSV:
+		return reader, nil
+	case INPUT_FORMAT_CSV_GZIP:
+	case "", INPUT_FORMAT_JSONL:
+		buffered := bufio.NewReader(reader)
+		if magic, _ := buffered.Peek(len(gzipMagic)); !bytes.Equal(magic, gzipMagic) {
+			return buffered, nil
+		}
+		reader = buffered
+	default:
+		return nil, fmt.Errorf("unsupported input format: %s", imp.inputFormat)
+	}
+
+	gz, err := gzip.NewReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("error reading gzip input: %v", err)
+	}
+	return gz, nil
+}
+
+// stripBOM discards a leading UTF-8 byte order mark, which would otherwise end
+// up in the first header field.
+func stripBOM(reader *bufio.Reader) *bufio.Reader {
+	if prefix, _ := reader.Peek(len(utf8BOM)); bytes.Equal(prefix, utf8BOM) {
+		reader.Discard(len(utf8BOM))
+	}
+	return reader
+}
+
+// skipLines discards the first n lines of reader, such as export titles
+// preceding the header. It fails when the input ends before that.
+func skipLines(reader *bufio.Reader, n int) error {
+	for i := range n {
+		_, err := reader.ReadSlice('\n')
+		for err == bufio.ErrBufferFull {
+			_, err = reader.ReadSlice('\n')
+		}
+		if err == io.EOF {
+			return fmt.Errorf("error skipping %d rows: input ended after %d rows", n, i)
+		}
+		if err != nil {
+			return fmt.Errorf("error skipping %d rows: %v", n, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package customerimporter
+
+import (
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// openFileCount returns the number of file descriptors held by the process.
+func openFileCount(t *testing.T) int {
+	t.Helper()
+	entries, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		t.Fatalf("error listing open files: %v", err)
+	}
+	return len(entries)
+}
+
+func TestImportFile_ClosesFile(t *testing.T) {
+	testCases := []struct {
+		name        string
+		csvInput    string
+		opts        []Option
+		expectError bool
+	}{
+		{
+			name:     "success",
+			csvInput: "first_name,last_name,email\nMildred,Hernandez,mhernandez0@github.io",
+		},
+		{
+			name:        "empty_input",
+			expectError: true,
+		},
+		{
+			name:        "skip_past_end",
+			csvInput:    "first_name,last_name,email",
+			opts:        []Option{WithSkipRows(3)},
+			expectError: true,
+		},
+		{
+			name:        "invalid_group_by",
+			csvInput:    "first_name,last_name,email\nMildred,Hernandez,mhernandez0@github.io",
+			opts:        []Option{WithGroupBy("country")},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "customers.csv")
+			if err := os.WriteFile(path, []byte(tc.csvInput), 0644); err != nil {
+				t.Fatalf("error writing input file: %v", err)
+			}
+
+			before := openFileCount(t)
+			opts := append([]Option{WithLogger(log.New(io.Discard, "", 0))}, tc.opts...)
+			_, err := NewImporter(opts...).ImportFile(path)
+			if tc.expectError && err == nil {
+				t.Error("error expected, got nil")
+			}
+			if !tc.expectError && err != nil {
+				t.Fatalf("unexpected error occured: %v", err)
+			}
+
+			if after := openFileCount(t); after != before {
+				t.Errorf("Open files: %d, expected: %d", after, before)
+			}
+		})
+	}
+}
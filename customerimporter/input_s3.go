@@ -0,0 +1,44 @@
+//go:build s3
+
+package customerimporter
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+func init() {
+	openS3 = fetchS3
+}
+
+// fetchS3 streams the object at rawURL with the credentials and region found
+// in the environment, shared config files or instance role, like the AWS CLI.
+// A custom AWS_ENDPOINT_URL, as used for S3-compatible stores, is addressed
+// path-style.
+func fetchS3(rawURL string) (io.ReadCloser, error) {
+	bucket, key, err := parseS3URL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error loading AWS config: %v", err)
+	}
+	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		o.UsePathStyle = os.Getenv("AWS_ENDPOINT_URL") != "" || os.Getenv("AWS_ENDPOINT_URL_S3") != ""
+	})
+
+	object, err := client.GetObject(ctx, &s3.GetObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+	if err != nil {
+		return nil, fmt.Errorf("error fetching %s: %v", rawURL, err)
+	}
+	return sizedReadCloser{ReadCloser: object.Body, size: aws.ToInt64(object.ContentLength)}, nil
+}
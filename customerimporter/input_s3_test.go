@@ -0,0 +1,54 @@
+//go:build s3
+
+package customerimporter
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestImportFile_S3(t *testing.T) {
+	csvInput := "first_name,last_name,email\nMildred,Hernandez,mhernandez0@github.io\nBonnie,Ortiz,bortiz1@cnet.com\n"
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	gz.Write([]byte(csvInput))
+	gz.Close()
+
+	objects := map[string][]byte{
+		"/exports/customers.csv":    []byte(csvInput),
+		"/exports/customers.csv.gz": compressed.Bytes(),
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		object, ok := objects[r.URL.Path]
+		if !ok {
+			http.Error(w, "<Error><Code>NoSuchKey</Code></Error>", http.StatusNotFound)
+			return
+		}
+		w.Write(object)
+	}))
+	defer server.Close()
+
+	t.Setenv("AWS_ENDPOINT_URL", server.URL)
+	t.Setenv("AWS_REGION", "us-east-1")
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+	t.Setenv("AWS_CONFIG_FILE", "/dev/null")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", "/dev/null")
+
+	for _, key := range []string{"customers.csv", "customers.csv.gz"} {
+		domainsCount, err := NewImporter().ImportFile("s3://exports/" + key)
+		if err != nil {
+			t.Fatalf("%s: unexpected error occured: %v", key, err)
+		}
+		if domainsCount.TotalCount != 2 {
+			t.Errorf("%s: total customers: %d, expected: %d", key, domainsCount.TotalCount, 2)
+		}
+	}
+
+	if _, err := NewImporter().ImportFile("s3://exports/missing.csv"); err == nil {
+		t.Errorf("expected an error for a missing object")
+	}
+}
@@ -0,0 +1,343 @@
+package customerimporter
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func withFastBackoff() Option {
+	return func(imp *Importer) {
+		imp.retryBackoff = time.Millisecond
+	}
+}
+
+func TestProcessFile_URLRetries(t *testing.T) {
+	csvInput := `first_name,last_name,email,gender,ip_address
+Mildred,Hernandez,mhernandez0@github.io,Female,38.194.51.128
+Norma,Allen,nallen8@cnet.com,Female,168.67.162.1`
+
+	testCases := []struct {
+		name             string
+		failures         int
+		failureStatus    int
+		retries          int
+		expectedRequests int32
+		expectError      bool
+	}{
+		{
+			name:             "no_failures",
+			retries:          3,
+			expectedRequests: 1,
+		},
+		{
+			name:             "recovers_after_5xx",
+			failures:         2,
+			failureStatus:    http.StatusServiceUnavailable,
+			retries:          3,
+			expectedRequests: 3,
+		},
+		{
+			name:             "gives_up_after_retries",
+			failures:         10,
+			failureStatus:    http.StatusInternalServerError,
+			retries:          2,
+			expectedRequests: 3,
+			expectError:      true,
+		},
+		{
+			name:             "no_retry_on_4xx",
+			failures:         10,
+			failureStatus:    http.StatusNotFound,
+			retries:          3,
+			expectedRequests: 1,
+			expectError:      true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if int(requests.Add(1)) <= tc.failures {
+					w.WriteHeader(tc.failureStatus)
+					return
+				}
+				w.Write([]byte(csvInput))
+			}))
+			defer server.Close()
+
+			domainsCount, err := ProcessFile(server.URL, WithRetries(tc.retries), withFastBackoff())
+			if tc.expectError && err == nil {
+				t.Error("error expected, got nil")
+			}
+			if !tc.expectError {
+				if err != nil {
+					t.Fatalf("unexpected error occured: %v", err)
+				}
+				if domainsCount.TotalCount != 2 {
+					t.Errorf("Total count: %d, expected: 2", domainsCount.TotalCount)
+				}
+			}
+
+			if requests.Load() != tc.expectedRequests {
+				t.Errorf("requests: %d, expected: %d", requests.Load(), tc.expectedRequests)
+			}
+		})
+	}
+}
+
+func TestProcessFile_URLConnectionError(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	_, err := ProcessFile(url, WithRetries(1), withFastBackoff())
+	if err == nil {
+		t.Error("error expected, got nil")
+	}
+}
+
+func TestProcessFile_URLStalledServer(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	defaultTimeout := httpClient.Timeout
+	httpClient.Timeout = 50 * time.Millisecond
+	defer func() { httpClient.Timeout = defaultTimeout }()
+
+	_, err := ProcessFile(server.URL, WithRetries(1), withFastBackoff(), WithLogger(log.New(io.Discard, "", 0)))
+	if err == nil {
+		t.Error("error expected, got nil")
+	}
+	if requests.Load() != 2 {
+		t.Errorf("requests: %d, expected: %d", requests.Load(), 2)
+	}
+}
+
+func TestProcessCsv_SkipRows(t *testing.T) {
+	csvInput := `Customer export
+Generated 2024-01-01, "all regions
+first_name,last_name,email
+Mildred,Hernandez,mhernandez0@github.io
+Norma,Allen,nallen8@cnet.com`
+
+	result, err := NewImporter(WithSkipRows(2)).processCsv(strings.NewReader(csvInput))
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	if result.totalCustomers != 2 {
+		t.Errorf("Total count: %d, expected: 2", result.totalCustomers)
+	}
+	if result.domainMap["github.io"] != 1 || result.domainMap["cnet.com"] != 1 {
+		t.Errorf("unexpected domain counts: %v", result.domainMap)
+	}
+}
+
+func TestProcessCsv_SkipRowsPastEnd(t *testing.T) {
+	testCases := []struct {
+		name     string
+		csvInput string
+	}{
+		{name: "empty", csvInput: ""},
+		{name: "too_few_rows", csvInput: "title\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewImporter(WithSkipRows(2)).processCsv(strings.NewReader(tc.csvInput))
+			if err == nil || !strings.HasPrefix(err.Error(), "error skipping 2 rows") {
+				t.Errorf("expected skipping error, got: %v", err)
+			}
+		})
+	}
+}
+
+// closeTrackingTransport wraps every response body to count how many of them
+// are left open.
+type closeTrackingTransport struct {
+	open atomic.Int32
+}
+
+func (tr *closeTrackingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	tr.open.Add(1)
+	resp.Body = &trackedBody{ReadCloser: resp.Body, transport: tr}
+	return resp, nil
+}
+
+type trackedBody struct {
+	io.ReadCloser
+	transport *closeTrackingTransport
+	closed    atomic.Bool
+}
+
+func (b *trackedBody) Close() error {
+	if b.closed.CompareAndSwap(false, true) {
+		b.transport.open.Add(-1)
+	}
+	return b.ReadCloser.Close()
+}
+
+func TestImportFile_URLClosesBodies(t *testing.T) {
+	testCases := []struct {
+		name        string
+		status      int
+		body        string
+		opts        []Option
+		expectError bool
+	}{
+		{
+			name:   "success",
+			status: http.StatusOK,
+			body:   "first_name,last_name,email\nMildred,Hernandez,mhernandez0@github.io",
+		},
+		{
+			name:        "empty_body",
+			status:      http.StatusOK,
+			expectError: true,
+		},
+		{
+			name:        "missing_header_column",
+			status:      http.StatusOK,
+			body:        "first_name,last_name,email\nMildred,Hernandez,mhernandez0@github.io",
+			opts:        []Option{WithEmailHeader("mail")},
+			expectError: true,
+		},
+		{
+			name:        "retries_exhausted",
+			status:      http.StatusServiceUnavailable,
+			opts:        []Option{WithRetries(2)},
+			expectError: true,
+		},
+		{
+			name:        "client_error",
+			status:      http.StatusNotFound,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.status)
+				io.WriteString(w, tc.body)
+			}))
+			defer server.Close()
+
+			transport := &closeTrackingTransport{}
+			defaultTransport := httpClient.Transport
+			httpClient.Transport = transport
+			defer func() { httpClient.Transport = defaultTransport }()
+
+			opts := append([]Option{withFastBackoff(), WithLogger(log.New(io.Discard, "", 0))}, tc.opts...)
+			_, err := NewImporter(opts...).ImportFile(server.URL)
+			if tc.expectError && err == nil {
+				t.Error("error expected, got nil")
+			}
+			if !tc.expectError && err != nil {
+				t.Fatalf("unexpected error occured: %v", err)
+			}
+
+			if open := transport.open.Load(); open != 0 {
+				t.Errorf("Open response bodies: %d, expected: 0", open)
+			}
+		})
+	}
+}
+
+func TestImport_InputFormat(t *testing.T) {
+	csvInput := "first_name,last_name,email\nMildred,Hernandez,mhernandez0@github.io\nBonnie,Ortiz,bortiz1@github.io"
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	gz.Write([]byte(csvInput))
+	gz.Close()
+
+	testCases := []struct {
+		name        string
+		input       []byte
+		format      string
+		expectError bool
+	}{
+		{name: "sniffed_gzip", input: compressed.Bytes()},
+		{name: "sniffed_plain", input: []byte(csvInput)},
+		{name: "gzip_hint", input: compressed.Bytes(), format: INPUT_FORMAT_CSV_GZIP},
+		{name: "csv_hint", input: []byte(csvInput), format: INPUT_FORMAT_CSV},
+		{name: "gzip_hint_plain_input", input: []byte(csvInput), format: INPUT_FORMAT_CSV_GZIP, expectError: true},
+		{name: "unsupported_hint", input: []byte(csvInput), format: "zip", expectError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			domainsCount, err := NewImporter(WithInputFormat(tc.format)).Import(bytes.NewReader(tc.input))
+			if tc.expectError {
+				if err == nil {
+					t.Error("error expected, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error occured: %v", err)
+			}
+			if domainsCount.TotalCount != 2 {
+				t.Errorf("Total customers: %d, expected: %d", domainsCount.TotalCount, 2)
+			}
+		})
+	}
+}
+
+func TestParseS3URL(t *testing.T) {
+	testCases := []struct {
+		url            string
+		expectedBucket string
+		expectedKey    string
+		expectError    bool
+	}{
+		{url: "s3://exports/customers.csv", expectedBucket: "exports", expectedKey: "customers.csv"},
+		{url: "s3://exports/2024/01/customers.csv.gz", expectedBucket: "exports", expectedKey: "2024/01/customers.csv.gz"},
+		{url: "s3://exports", expectError: true},
+		{url: "s3://exports/", expectError: true},
+		{url: "s3:///customers.csv", expectError: true},
+		{url: "customers.csv", expectError: true},
+	}
+
+	for _, tc := range testCases {
+		bucket, key, err := parseS3URL(tc.url)
+		if tc.expectError {
+			if err == nil {
+				t.Errorf("parseS3URL(%q): error expected, got %s %s", tc.url, bucket, key)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseS3URL(%q): unexpected error occured: %v", tc.url, err)
+			continue
+		}
+		if bucket != tc.expectedBucket || key != tc.expectedKey {
+			t.Errorf("parseS3URL(%q) = %s, %s; want %s, %s", tc.url, bucket, key, tc.expectedBucket, tc.expectedKey)
+		}
+	}
+}
+
+func TestImportFile_S3WithoutTag(t *testing.T) {
+	if openS3 != nil {
+		t.Skip("built with the s3 tag")
+	}
+	if _, err := NewImporter().ImportFile("s3://exports/customers.csv"); err == nil || !strings.Contains(err.Error(), "-tags s3") {
+		t.Errorf("error %v, expected to mention the s3 tag", err)
+	}
+}
@@ -8,205 +8,1101 @@ package customerimporter
 
 import (
 	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
 	"encoding/csv"
+	"errors"
 	"fmt"
+	"hash"
 	"io"
+	"io/fs"
 	"log"
+	"math/rand/v2"
+	"net/netip"
 	"os"
-	"runtime"
+	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
 )
 
 const EMAIL_IDX = 2
-const OUTPUT_LINE_FORMAT = "Domain: %s, Customers: %d\n"
+
+// LAST_COLUMN selects the last field of every row as the email column, see
+// WithEmailColumn.
+const LAST_COLUMN = -1
+
+// DEFAULT_MAX_EMAIL_LENGTH is the longest email counted by default, the
+// limit RFC 5321 puts on an address in practice. See WithMaxEmailLength.
+const DEFAULT_MAX_EMAIL_LENGTH = 254
+
+// MIN_BYTES_PER_WORKER is the input size that warrants another worker.
+const MIN_BYTES_PER_WORKER = 4096
+
+const DEFAULT_FILE_MODE os.FileMode = 0644
+const GZIP_EXTENSION = ".gz"
+
+// ErrEmptyInput is returned, wrapped, when the input has no content besides a
+// byte order mark and whitespace.
+var ErrEmptyInput = errors.New("input is empty after trimming BOM and whitespace")
 
 type DomainStat struct {
-	Name  string
-	Count int
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+	// RoleCount is the number of Count addresses whose local part is a role
+	// account, see WithRoleAccounts.
+	RoleCount int `json:"role_count,omitempty"`
+	// Subdomains breaks a grouped domain down into the domains it was rolled
+	// up from, see WithGroupBy.
+	Subdomains []DomainStat `json:"subdomains,omitempty"`
+	// Disposable marks a disposable email domain, see WithDisposableDomains.
+	Disposable bool `json:"disposable,omitempty"`
+	// FirstSeenLine is the CSV line, counting the header, the domain first
+	// appeared on, or 0 when unknown. Importing several files keeps the line
+	// of the first file the domain appeared in.
+	FirstSeenLine int `json:"first_seen_line,omitempty"`
 }
 
 type DomainsCount struct {
-	DomainStats []DomainStat
-	TotalCount  int
+	DomainStats []DomainStat `json:"domain_stats"`
+	TotalCount  int          `json:"total_count"`
+	// WorkerCounts holds the number of emails each worker processed. It is
+	// only populated when processing with WithWorkerStats.
+	WorkerCounts []int `json:"worker_counts,omitempty"`
+	// SampleRows is set to the limit of WithLimit when the import stopped
+	// there, so the counts describe a sample of that many rows.
+	SampleRows int `json:"sample_rows,omitempty"`
+	// DisposableCount is the number of customers with an email on a
+	// disposable domain, see WithDisposableDomains.
+	DisposableCount int `json:"disposable_count,omitempty"`
+	// Hierarchy is set by GROUP_BY_HIERARCHY, whose DomainStats nest every
+	// domain level in the one above instead of listing group members.
+	Hierarchy bool `json:"hierarchy,omitempty"`
+	// InvalidCount is the number of rows skipped for a bad, empty or too
+	// long email, which TotalCount leaves out.
+	InvalidCount int `json:"invalid_count,omitempty"`
+	// Warnings lists the first MAX_WARNINGS rows the import skipped by line,
+	// and WarningCounts counts all of them per category. They hold the raw
+	// rejected values, like emails, so they are left out of JSON output.
+	Warnings      []Warning               `json:"-"`
+	WarningCounts map[WarningCategory]int `json:"-"`
 }
 
-func WriteOutput(domainsCount DomainsCount, filePath *string) error {
-	if filePath != nil && *filePath != "" {
-		return writeFile(domainsCount, filePath)
-	} else {
-		return writeStdOut(domainsCount)
+// ForEach calls fn with every domain and its customer count, in the order of
+// DomainStats, e.g. to emit one metric per domain. Mind that a label or metric
+// per domain can reach a high cardinality on large customer bases; consider
+// grouping or a MinCount style cut-off before exporting.
+func (dc *DomainsCount) ForEach(fn func(domain string, count int)) {
+	for _, domainStat := range dc.DomainStats {
+		fn(domainStat.Name, domainStat.Count)
+	}
+}
+
+// OutputOptions controls how WriteOutput renders a DomainsCount. The zero
+// value renders the plain domain and customer count lines.
+type OutputOptions struct {
+	// Format is the output format, FORMAT_TEXT when empty.
+	Format string
+	// JSONFlat writes FORMAT_JSON as the plain DomainsCount struct instead of
+	// the summary and domains document.
+	JSONFlat bool
+	// SummaryOnly writes FORMAT_JSON as just the summary object, without the
+	// domains, for monitoring that only charts the totals.
+	SummaryOnly bool
+	// TotalLabel replaces DEFAULT_TOTAL_LABEL in the text header line.
+	TotalLabel string
+	// RoleCounts adds the role and personal account counts to each line.
+	RoleCounts bool
+	// NormalizePer, when positive, writes each domain's count as customers
+	// per NormalizePer customers, rounded to two decimals, so that reports of
+	// differently sized inputs are comparable. The text lines then take
+	// neither Align nor RoleCounts.
+	NormalizePer int
+	// Tree writes the domains as a tree of their levels, the top-level domain,
+	// the registered domain and the subdomains below it, each counting the
+	// customers of the levels below like GROUP_BY_HIERARCHY. It expects
+	// ungrouped domains.
+	Tree bool
+	// Align pads the domain names and counts of the text lines to the widest
+	// of the output, so the columns line up.
+	Align bool
+	// nameWidth and countWidth are the widths Align pads to, set by
+	// writeText.
+	nameWidth, countWidth int
+	// Top writes just the first domain and its count, like "github.io 3",
+	// see WithTopDomain.
+	Top bool
+	// Cumulative orders the domains by count, largest first, and adds the
+	// running share of all customers the domains so far cover to each text
+	// line and JSON domain, for Pareto analysis.
+	Cumulative bool
+	// Gzip compresses the output. Output files whose name ends in
+	// GZIP_EXTENSION are always compressed.
+	Gzip bool
+	// Checksum adds the SHA-256 of the uncompressed output, as a trailing
+	// CHECKSUM_LINE_FORMAT line of text reports and otherwise in a
+	// CHECKSUM_EXTENSION file next to the output file, or logged for stdout.
+	Checksum bool
+	// MinCount and MaxCount, when positive, limit the output to domains whose
+	// customer count falls within them. The total is not affected.
+	MinCount int
+	MaxCount int
+	// SortKeys, when set, reorders the domains by these keys, see
+	// ParseSortKeys.
+	SortKeys []SortKey
+	// Coverage, when set, replaces the report with the Coverage of these
+	// reference domains, listing the missing ones too.
+	Coverage []string
+	// NamesOnly writes just the domain names, one per line, in place of
+	// Format, e.g. to generate allow-lists.
+	NamesOnly bool
+	// Bars writes every text line as the domain, its count, a bar scaled to
+	// the largest count and its share of all customers. Width is the line
+	// width the bars are fitted to, DEFAULT_BAR_WIDTH when zero.
+	Bars  bool
+	Width int
+	// HistogramBounds, when set, replaces the domain lines of text output
+	// with the Histogram buckets of these bounds, and adds them to the JSON
+	// report.
+	HistogramBounds []int
+	// FileMode is the permission of a newly created output file, subject to
+	// the umask. It defaults to DEFAULT_FILE_MODE.
+	FileMode os.FileMode
+	// MakeDirs creates missing parent directories of the output file instead
+	// of failing.
+	MakeDirs bool
+	// Metadata, when set, is written before the text header and as the
+	// metadata object of the JSON report.
+	Metadata *RunMetadata
+	// Stdout is where WriteOutput writes without a file path, os.Stdout when
+	// nil.
+	Stdout io.Writer
+	// Logger receives the errors of writing an output file and the checksum
+	// of stdout output, the standard logger when nil.
+	Logger *log.Logger
+	// Inputs are the input file paths, which CreateOutputFile refuses to
+	// overwrite with ErrOutputIsInput.
+	Inputs []string
+}
+
+// logger returns the Logger of opts, or the standard logger.
+func (opts OutputOptions) logger() *log.Logger {
+	if opts.Logger != nil {
+		return opts.Logger
+	}
+	return log.Default()
+}
+
+// ErrOutputIsInput is returned when the output file is one of the inputs,
+// as truncating it would destroy the input.
+var ErrOutputIsInput = errors.New("output file is an input file")
+
+// WriteOutput writes domainsCount to the file at filePath, or to stdout when
+// it is nil or empty, through the sink of NewOutputSink.
+func WriteOutput(domainsCount DomainsCount, filePath *string, opts OutputOptions) error {
+	path := ""
+	if filePath != nil {
+		path = *filePath
 	}
+	return NewOutputSink(path, opts).Write(domainsCount)
 }
 
-func writeFile(domainsCount DomainsCount, filePath *string) error {
-	file, err := os.OpenFile(*filePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+// writeFile writes domainsCount to a temporary file next to filePath and
+// renames it over filePath once complete, so that a failed write, e.g. on a
+// full disk, leaves any previous report in place instead of a partial one.
+func writeFile(domainsCount DomainsCount, filePath *string, opts OutputOptions) error {
+	file, err := CreateOutputFile(*filePath, opts)
 	if err != nil {
-		log.Printf("Error opening file: %v", err)
+		opts.logger().Printf("Error opening file: %v", err)
 		return err
 	}
-	defer file.Close()
+	defer file.Discard()
+
+	var out io.Writer = file
+	var gz *gzip.Writer
+	if opts.Gzip || strings.HasSuffix(*filePath, GZIP_EXTENSION) {
+		gz = gzip.NewWriter(file)
+		out = gz
+	}
+	var digest hash.Hash
+	if opts.Checksum && !inlineChecksum(opts) {
+		digest = sha256.New()
+		out = io.MultiWriter(out, digest)
+	}
 
-	writer := bufio.NewWriter(file)
-	header := fmt.Sprintf("Total number of customers: %d\n", domainsCount.TotalCount)
-	_, err = writer.WriteString(header)
+	writer := bufio.NewWriter(out)
+	err = WriteTo(writer, domainsCount, opts)
 	if err != nil {
-		log.Printf("Error writing to file: %v\n", err)
+		opts.logger().Printf("Error writing to file: %v\n", err)
 		return fmt.Errorf("error writing to file: %s, %v", *filePath, err)
 	}
-	for _, domainStat := range domainsCount.DomainStats {
-		line := fmt.Sprintf(OUTPUT_LINE_FORMAT, domainStat.Name, domainStat.Count)
 
-		_, err := writer.WriteString(line)
+	err = writer.Flush()
+	if err != nil {
+		opts.logger().Printf("Error flushing the buffer: %v", err)
+		return err
+	}
+
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			opts.logger().Printf("Error closing the gzip stream: %v", err)
+			return err
+		}
+	}
+	// The checksum file is written in full before either file is replaced,
+	// so a failure leaves the previous report and checksum in place.
+	var checksumFile *OutputFile
+	if digest != nil {
+		checksumFile, err = createChecksumFile(*filePath, digest.Sum(nil), opts)
 		if err != nil {
-			log.Printf("Error writing to file: %v\n", err)
-			return fmt.Errorf("error writing to file: %s, %v", *filePath, err)
+			return err
 		}
+		defer checksumFile.Discard()
 	}
-
-	err = writer.Flush()
-	if err != nil {
-		log.Printf("Error flushing the buffer: %v", err)
+	if err := file.Commit(); err != nil {
+		opts.logger().Printf("Error closing file: %v", err)
 		return err
 	}
+	if checksumFile != nil {
+		return checksumFile.Commit()
+	}
+	return nil
+}
 
+// OutputFile is a temporary file, created by CreateOutputFile, that replaces
+// the output file only once it is complete, so that a failed write leaves any
+// previous output in place instead of a partial one.
+type OutputFile struct {
+	*os.File
+	path      string
+	committed bool
+}
+
+// CreateOutputFile creates an OutputFile for the output file at filePath with
+// the FileMode of opts, hidden in the same directory until Commit. A missing
+// parent directory is created with MakeDirs and reported as such otherwise.
+func CreateOutputFile(filePath string, opts OutputOptions) (*OutputFile, error) {
+	if err := prepareOutput(filePath, opts); err != nil {
+		return nil, err
+	}
+	dir, base := filepath.Split(filePath)
+	for {
+		tempPath := filepath.Join(dir, fmt.Sprintf(".%s.%d.tmp", base, rand.Uint32()))
+		// Unlike os.CreateTemp, this applies the FileMode subject to the umask.
+		file, err := os.OpenFile(tempPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, outputFileMode(opts))
+		if err == nil {
+			return &OutputFile{File: file, path: filePath}, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, err
+		}
+	}
+}
+
+// Commit closes the file and renames it over the output file.
+func (f *OutputFile) Commit() error {
+	if err := f.File.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(f.Name(), f.path); err != nil {
+		return fmt.Errorf("error replacing output file: %v", err)
+	}
+	f.committed = true
 	return nil
 }
 
-func writeStdOut(domainsCount DomainsCount) error {
-	fmt.Printf("Total number of customers: %d\n", domainsCount.TotalCount)
-	for _, domainStat := range domainsCount.DomainStats {
-		fmt.Printf(OUTPUT_LINE_FORMAT, domainStat.Name, domainStat.Count)
+// Discard closes and removes the file unless it was committed, leaving the
+// output file as it was. It is meant to be deferred.
+func (f *OutputFile) Discard() {
+	if f.committed {
+		return
+	}
+	f.File.Close()
+	os.Remove(f.Name())
+}
+
+// prepareOutput checks that filePath is not an input and creates its missing
+// parent directory with MakeDirs, reporting it as missing otherwise.
+func prepareOutput(filePath string, opts OutputOptions) error {
+	if err := checkNotInput(filePath, opts.Inputs); err != nil {
+		return err
+	}
+	dir := filepath.Dir(filePath)
+	if _, err := os.Stat(dir); errors.Is(err, fs.ErrNotExist) {
+		if !opts.MakeDirs {
+			return fmt.Errorf("output directory does not exist: %s", dir)
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("error creating output directory: %v", err)
+		}
 	}
 	return nil
 }
 
-func ProcessFile(filePath string) (*DomainsCount, error) {
-	file, err := os.Open(filePath)
+func outputFileMode(opts OutputOptions) os.FileMode {
+	if opts.FileMode == 0 {
+		return DEFAULT_FILE_MODE
+	}
+	return opts.FileMode
+}
+
+// checkNotInput returns ErrOutputIsInput when filePath is one of inputs, by
+// absolute path or, for links, by the file it refers to.
+func checkNotInput(filePath string, inputs []string) error {
+	if len(inputs) == 0 {
+		return nil
+	}
+	outputPath, err := filepath.Abs(filePath)
 	if err != nil {
-		return &DomainsCount{}, err
+		return err
+	}
+	outputInfo, statErr := os.Stat(filePath)
+	for _, input := range inputs {
+		inputPath, err := filepath.Abs(input)
+		if err != nil {
+			continue
+		}
+		if inputPath == outputPath {
+			return fmt.Errorf("%w: %s", ErrOutputIsInput, filePath)
+		}
+		if statErr != nil {
+			continue
+		}
+		if inputInfo, err := os.Stat(input); err == nil && os.SameFile(inputInfo, outputInfo) {
+			return fmt.Errorf("%w: %s", ErrOutputIsInput, filePath)
+		}
 	}
-	defer file.Close()
+	return nil
+}
 
-	numWorkers := runtime.NumCPU()
+func writeStdOut(domainsCount DomainsCount, opts OutputOptions) error {
+	var out io.Writer = os.Stdout
+	if opts.Stdout != nil {
+		out = opts.Stdout
+	}
+	var gz *gzip.Writer
+	if opts.Gzip {
+		gz = gzip.NewWriter(out)
+		out = gz
+	}
+	var digest hash.Hash
+	if opts.Checksum && !inlineChecksum(opts) {
+		digest = sha256.New()
+		out = io.MultiWriter(out, digest)
+	}
 
-	domainMap, totalcustomers, err := processCsv(file, numWorkers)
+	writer := bufio.NewWriter(out)
+	err := WriteTo(writer, domainsCount, opts)
 	if err != nil {
-		return &DomainsCount{}, err
+		return err
+	}
+	if err := writer.Flush(); err != nil {
+		return err
 	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			return err
+		}
+	}
+	if digest != nil {
+		// Keep stdout parseable.
+		opts.logger().Printf("Output "+CHECKSUM_LINE_FORMAT, digest.Sum(nil))
+	}
+	return nil
+}
 
-	domainStats := createStats(domainMap)
+// ProcessFile reads the CSV file at filePath and counts customers per email
+// domain. It is a shorthand for NewImporter(opts...).ImportFile(filePath).
+// filePath may also be an http(s) URL, in which case the response body is
+// processed; see WithRetries. The file is read once, front to back, so named
+// pipes (FIFOs) are supported as well as regular files: rows are processed as
+// the producer writes them and the call returns once the writing end is
+// closed. FIFOs are only available on Unix-like systems.
+func ProcessFile(filePath string, opts ...Option) (*DomainsCount, error) {
+	return NewImporter(opts...).ImportFile(filePath)
+}
 
-	return &DomainsCount{
-		DomainStats: domainStats,
-		TotalCount:  totalcustomers,
-	}, nil
+// DomainStatsFromReader reads CSV data from reader and returns just the sorted
+// domain stats, for callers that need neither the total nor file handling.
+func DomainStatsFromReader(reader io.Reader, opts ...Option) ([]DomainStat, error) {
+	domainsCount, err := NewImporter(opts...).Import(reader)
+	if err != nil {
+		return nil, err
+	}
+	return domainsCount.DomainStats, nil
 }
 
-func createStats(domainMap map[string]int) []DomainStat {
+func createStats(domainMap map[string]int, roleMap map[string]int, naturalSort bool) []DomainStat {
 	domainStats := make([]DomainStat, 0, len(domainMap))
 	for domain, customers := range domainMap {
 		domainStats = append(domainStats, DomainStat{
-			Name:  domain,
-			Count: customers,
+			Name:      domain,
+			Count:     customers,
+			RoleCount: roleMap[domain],
 		})
 	}
 
+	sortStats(domainStats, naturalSort)
+
+	return domainStats
+}
+
+func sortStats(domainStats []DomainStat, naturalSort bool) {
 	sort.Slice(domainStats, func(i, j int) bool {
-		return domainStats[i].Name < domainStats[j].Name
+		return statLess(domainStats[i], domainStats[j], naturalSort)
 	})
+}
 
-	return domainStats
+// statLess is a total order of domain stats: by name, naturally when
+// naturalSort is set, then by plain name and count, so that the output is
+// reproducible byte for byte.
+func statLess(a, b DomainStat, naturalSort bool) bool {
+	if naturalSort {
+		if naturalLess(a.Name, b.Name) {
+			return true
+		}
+		if naturalLess(b.Name, a.Name) {
+			return false
+		}
+	}
+	if a.Name != b.Name {
+		return a.Name < b.Name
+	}
+	return a.Count < b.Count
+}
+
+type csvResult struct {
+	domainMap      map[string]int
+	roleMap        map[string]int
+	totalCustomers int
+	workerCounts   []int
+	sampled        bool
+	firstSeen      map[string]int
+	warnings       []Warning
+	warningCounts  map[WarningCategory]int
+}
+
+// add sums other into r.
+func (r *csvResult) add(other *csvResult) {
+	for domain, customers := range other.domainMap {
+		r.domainMap[domain] += customers
+	}
+	for domain, roles := range other.roleMap {
+		r.roleMap[domain] += roles
+	}
+	r.totalCustomers += other.totalCustomers
+	if len(r.workerCounts) < len(other.workerCounts) {
+		r.workerCounts = append(r.workerCounts, make([]int, len(other.workerCounts)-len(r.workerCounts))...)
+	}
+	for i, processed := range other.workerCounts {
+		r.workerCounts[i] += processed
+	}
+	for domain, line := range other.firstSeen {
+		if _, ok := r.firstSeen[domain]; !ok {
+			r.firstSeen[domain] = line
+		}
+	}
+	r.sampled = r.sampled || other.sampled
+	r.warnings = append(r.warnings, other.warnings[:min(len(other.warnings), MAX_WARNINGS-len(r.warnings))]...)
+	for category, count := range other.warningCounts {
+		r.warningCounts[category] += count
+	}
+}
+
+func (imp *Importer) processCsv(reader io.Reader) (*csvResult, error) {
+	return imp.processCsvColumn(reader, nil)
+}
+
+// processCsvColumn is processCsv reading the emails from emailColumn instead
+// of the configured column when it is set.
+func (imp *Importer) processCsvColumn(reader io.Reader, emailColumn *EmailColumn) (*csvResult, error) {
+	numWorkers := imp.workersFor(inputSize(reader))
+
+	var tracker *progressTracker
+	if imp.progress != nil {
+		tracker = newProgressTracker(reader, imp.progress)
+		reader = tracker.counter
+	}
+
+	reader, err := imp.decompress(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	if imp.inputEncoding != nil {
+		reader = imp.inputEncoding.NewDecoder().Reader(reader)
+	}
+
+	end := &lastByteReader{reader: reader}
+	buffered := stripBOM(bufio.NewReader(end))
+	reader = buffered
+
+	if err := skipLines(buffered, imp.skipRows); err != nil {
+		return nil, err
+	}
+
+	if imp.inputFormat == INPUT_FORMAT_JSONL {
+		return imp.aggregate(numWorkers, func(emailChan chan emailRow, sampled *bool, skipped *skipCounts) error {
+			return imp.jsonlReader(buffered, emailChan, tracker, sampled, skipped)
+		})
+	}
+
+	delimiter := imp.delimiter
+	if imp.detectDelimiter {
+		delimiter = detectDelimiter(peekLine(buffered))
+		imp.debugf("Detected delimiter %q", delimiter)
+	}
+
+	var csvreader *csv.Reader
+	var header []string
+	if imp.fastParse {
+		header, err = readLineHeader(buffered, delimiter)
+	} else {
+		csvreader = csv.NewReader(reader)
+		csvreader.Comma = delimiter
+		// The fields are strings of their own, only the slice is reused,
+		// which saves an allocation per row.
+		csvreader.ReuseRecord = true
+		header, err = readHeader(csvreader)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns, err := imp.resolveColumns(header, emailColumn)
+	if err != nil {
+		return nil, err
+	}
+	if columns.email < 0 && csvreader != nil {
+		// Counting from the end is meant for ragged rows, so accept them.
+		csvreader.FieldsPerRecord = -1
+	}
+
+	return imp.aggregate(numWorkers, func(emailChan chan emailRow, sampled *bool, skipped *skipCounts) error {
+		if imp.fastParse {
+			return imp.lineReader(buffered, end, delimiter, columns, emailChan, tracker, sampled, skipped)
+		}
+		return imp.csvReader(csvreader, end, header, columns, emailChan, tracker, sampled, skipped)
+	})
+}
+
+// processCsvReader runs the pipeline on a csv.Reader configured by the
+// caller, see ImportCSV.
+func (imp *Importer) processCsvReader(csvreader *csv.Reader) (*csvResult, error) {
+	header, err := readHeader(csvreader)
+	if err != nil {
+		return nil, err
+	}
+	columns, err := imp.resolveColumns(header, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return imp.aggregate(imp.workersFor(-1), func(emailChan chan emailRow, sampled *bool, skipped *skipCounts) error {
+		return imp.csvReader(csvreader, nil, header, columns, emailChan, nil, sampled, skipped)
+	})
 }
 
-func processCsv(reader io.Reader, numWorkers int) (map[string]int, int, error) {
-	csvreader := csv.NewReader(reader)
+// rowColumns are the indexes of the columns the readers look at in every row.
+type rowColumns struct {
+	email int
+	// time is the WithTimeWindow column, or -1.
+	time int
+	// weight is the WithWeightColumn column, or -1.
+	weight int
+	// dedup is the WithDedupKey column, or -1.
+	dedup   int
+	filters []rowFilter
+}
 
-	_, err := csvreader.Read()
+// resolveColumns looks up the configured columns in header, taking the email
+// column from emailColumn when it is set.
+func (imp *Importer) resolveColumns(header []string, emailColumn *EmailColumn) (rowColumns, error) {
+	emailIdx, err := imp.resolveEmailIdx(header, emailColumn)
+	if err != nil {
+		return rowColumns{}, err
+	}
+	timeIdx, err := imp.resolveTimeIdx(header)
+	if err != nil {
+		return rowColumns{}, err
+	}
+	weightIdx, err := imp.resolveWeightIdx(header, emailIdx)
+	if err != nil {
+		return rowColumns{}, err
+	}
+	dedupIdx, err := imp.resolveDedupIdx(header)
+	if err != nil {
+		return rowColumns{}, err
+	}
+	filters, err := imp.resolveRowFilters(header)
 	if err != nil {
-		return nil, 0, fmt.Errorf("error reading the header of csv: %v", err)
+		return rowColumns{}, err
+	}
+	return rowColumns{email: emailIdx, time: timeIdx, weight: weightIdx, dedup: dedupIdx, filters: filters}, nil
+}
+
+// resolveEmailIdx returns the email column, looked up in header when it is
+// configured by name.
+func (imp *Importer) resolveEmailIdx(header []string, emailColumn *EmailColumn) (int, error) {
+	if emailColumn != nil {
+		if emailColumn.Header != "" {
+			return resolveColumn(header, emailColumn.Header)
+		}
+		return emailColumn.Index, nil
+	}
+	if imp.emailHeader != "" {
+		return resolveColumn(header, imp.emailHeader)
 	}
+	if len(imp.emailCandidates) > 0 {
+		return matchColumn(header, imp.emailCandidates)
+	}
+	return imp.emailIdx, nil
+}
 
-	emailChan := make(chan string, numWorkers)
-	domains := make(chan string, numWorkers)
+// workersFor returns the number of domain extraction workers for an input of
+// size bytes, or of unknown size when negative. Small inputs and a row limit
+// don't have the rows to keep all configured workers busy.
+func (imp *Importer) workersFor(size int64) int {
+	numWorkers := imp.numWorkers
+	if imp.limit > 0 {
+		numWorkers = min(numWorkers, imp.limit)
+	}
+	if size >= 0 {
+		numWorkers = min(numWorkers, int(size/MIN_BYTES_PER_WORKER)+1)
+	}
+	return numWorkers
+}
+
+// aggregate runs readRows in its own goroutine, feeding the emails it sends
+// to numWorkers domain extraction workers, and collects their counts.
+// readRows closes emailChan once done; the error it returns
+// for unreadable input fails the import, as the counts would be incomplete.
+func (imp *Importer) aggregate(numWorkers int, readRows func(emailChan chan emailRow, sampled *bool, skipped *skipCounts) error) (*csvResult, error) {
+	emailChan := make(chan emailRow, numWorkers)
+	counter := newShardedCounter()
+	var unique *uniqueSet
+	if imp.unique {
+		unique = newUniqueSet(imp.uniqueMemoryLimit, imp.spillDir)
+		defer unique.cleanup()
+	}
 	var wg sync.WaitGroup
+	var sampled bool
+	var skipped skipCounts
 
+	var readErr error
 	wg.Add(1)
-	go csvReader(csvreader, emailChan, &wg)
+	go func() {
+		defer wg.Done()
+		readErr = readRows(emailChan, &sampled, &skipped)
+	}()
+
+	var workerCounts []int
+	if imp.workerStats {
+		workerCounts = make([]int, numWorkers)
+	}
 
-	for range numWorkers {
+	for i := range numWorkers {
+		var processed *int
+		if workerCounts != nil {
+			processed = &workerCounts[i]
+		}
 		wg.Add(1)
-		go extractDomains(domains, emailChan, &wg)
+		go imp.extractDomains(counter, unique, emailChan, processed, &skipped, &wg)
 	}
 
-	domainMap := make(map[string]int)
-	totalCustomers := 0
-	doneAggregating := make(chan struct{})
+	wg.Wait()
+	if readErr != nil {
+		return nil, readErr
+	}
 
-	go aggregateDomains(domains, domainMap, &totalCustomers, doneAggregating)
+	if skipped.total() > 0 {
+		imp.logger.Print(skipped.summary())
+	}
+	if suppressed := skipped.suppressed(); suppressed > 0 {
+		imp.logger.Printf(SUPPRESSED_INVALID_FORMAT, suppressed)
+	}
+	if imp.explainTarget != "" && skipped.explained.Load() == 0 {
+		imp.logger.Printf(EXPLAIN_NOT_FOUND_FORMAT, imp.explainTarget)
+	}
+	if !imp.allowEmpty {
+		if err := skipped.noValidEmails(); err != nil {
+			return nil, err
+		}
+	}
 
-	wg.Wait()
-	close(domains)
+	streamed := 0
+	if unique != nil {
+		var err error
+		streamed, err = imp.countUnique(unique, counter)
+		if err != nil {
+			return nil, fmt.Errorf("error deduplicating emails: %v", err)
+		}
+	}
 
-	<-doneAggregating
+	domainMap, roleMap, totalCustomers := counter.merge()
+	firstSeen := counter.firstSeen()
+	if imp.stream != nil && unique != nil {
+		totalCustomers = streamed
+	} else if imp.stream != nil {
+		if err := imp.streamCounts(domainMap, roleMap, firstSeen); err != nil {
+			return nil, err
+		}
+		domainMap, roleMap, firstSeen = map[string]int{}, map[string]int{}, map[string]int{}
+	}
 
-	return domainMap, totalCustomers, nil
+	return &csvResult{
+		domainMap:      domainMap,
+		roleMap:        roleMap,
+		totalCustomers: totalCustomers,
+		workerCounts:   workerCounts,
+		sampled:        sampled,
+		firstSeen:      firstSeen,
+		warnings:       skipped.sortedWarnings(),
+		warningCounts:  skipped.warningCounts(),
+	}, nil
 }
 
-func csvReader(csvreader *csv.Reader, emailChan chan string, wg *sync.WaitGroup) {
-	defer wg.Done()
+// readHeader returns the first non-blank record. Input that holds nothing but
+// blank lines is reported as ErrEmptyInput rather than as a malformed header.
+func readHeader(csvreader *csv.Reader) ([]string, error) {
+	for {
+		header, err := csvreader.Read()
+		if err == io.EOF {
+			return nil, fmt.Errorf("error reading the header of csv: %w", ErrEmptyInput)
+		}
+		if isBlankRecord(header) {
+			// Let the real header set the expected number of fields.
+			csvreader.FieldsPerRecord = 0
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading the header of csv: %w", err)
+		}
+		// The rows overwrite the record with ReuseRecord.
+		return slices.Clone(header), nil
+	}
+}
+
+// csvReader sends the email of every data row to emailChan, counting the rows
+// it can't parse in skipped. With WithLimit it stops once limit emails were
+// sent and sets sampled. end, when set, is the raw input checked by
+// warnTruncated. An error reading the input, like a truncated gzip stream,
+// stops it and is returned.
+func (imp *Importer) csvReader(csvreader *csv.Reader, end *lastByteReader, header []string, columns rowColumns, emailChan chan emailRow, tracker *progressTracker, sampled *bool, skipped *skipCounts) error {
 	defer close(emailChan)
-	lineNum := 0
+	lineNum := imp.skipRows
+	emitted := 0
+	lastSkipped := 0
+	keys := imp.newDedupKeys()
 
 	for {
+		if imp.limit > 0 && emitted == imp.limit {
+			imp.debugf("Row limit of %d reached", imp.limit)
+			tracker.update(lineNum, true)
+			*sampled = true
+			break
+		}
+
 		records, err := csvreader.Read()
 		lineNum++
 		if err == io.EOF {
-			log.Println("End of file reached")
+			imp.debugf("End of file reached")
+			tracker.update(lineNum-1, true)
+			imp.warnTruncated(end, lastSkipped, lineNum)
 			break
 		}
+		var parseErr *csv.ParseError
+		if err != nil && !errors.As(err, &parseErr) {
+			// Unlike parse errors, read errors of the input would repeat on
+			// every further read.
+			tracker.update(lineNum-1, true)
+			return fmt.Errorf("error reading csv line %d: %w", lineNum+1, err)
+		}
+		if lineNum%PROGRESS_INTERVAL == 0 {
+			tracker.update(lineNum, false)
+		}
+		if isBlankRecord(records) {
+			imp.debugf("Skipping blank csv line %d", lineNum+1)
+			continue
+		}
+		if header != nil && records != nil {
+			if guess, ok := delimiterMismatch(header, records, csvreader.Comma); ok {
+				imp.logger.Printf("Warning: header has %d fields but line %d has %d, the file may be %q-delimited rather than %q-delimited\n",
+					len(header), lineNum+1, len(records), guess, csvreader.Comma)
+			}
+			header = nil
+		}
 		if err != nil {
-			log.Printf("Error reading csv line %d: %v\n", lineNum+1, err)
+			// Like the emails below, rows are numbered by the input line
+			// they start on rather than by record.
+			line := parseErr.StartLine + imp.skipRows
+			imp.logger.Printf("Error reading csv line %d: %v\n", line, err)
+			if _, ok := columnIndex(columns.email, len(records)); errors.Is(err, csv.ErrFieldCount) && !ok {
+				skipped.addShortRow(line, fmt.Sprintf(SHORT_ROW_FORMAT, columns.email, len(records)))
+			} else {
+				skipped.warn(WARNING_MALFORMED_ROW, line, "")
+			}
+			lastSkipped = lineNum + 1
 			continue
 		}
 
-		if len(records) <= EMAIL_IDX {
-			log.Printf("Line %d email column index out of range\n", lineNum)
+		idx, ok := columnIndex(columns.email, len(records))
+		if !ok {
+			line, _ := csvreader.FieldPos(0)
+			line += imp.skipRows
+			imp.logger.Printf("Line %d email column index out of range\n", line)
+			skipped.addShortRow(line, fmt.Sprintf(SHORT_ROW_FORMAT, columns.email, len(records)))
+			lastSkipped = lineNum + 1
+			continue
+		}
+		// Unlike lineNum, the position accounts for the blank lines and line
+		// breaks in quoted fields the csv.Reader passes over.
+		line, _ := csvreader.FieldPos(idx)
+		line += imp.skipRows
+
+		if !matchesFilters(columns.filters, func(fi int) (string, bool) {
+			if fi >= len(records) {
+				return "", false
+			}
+			return records[fi], true
+		}) {
+			imp.debugf("Skipping csv line %d not matching the filters", line)
+			if imp.explains(records[idx]) {
+				imp.explain(skipped, line, records[idx], "skipped as the row doesn't match the filters")
+			}
+			continue
+		}
+
+		timestamp, found := "", false
+		if ti, ok := columnIndex(columns.time, len(records)); ok && imp.timeWindow != "" {
+			timestamp, found = records[ti], true
+		}
+		weightField, weightFound := "", columns.weight >= 0 && columns.weight < len(records)
+		if weightFound {
+			weightField = records[columns.weight]
+		}
+		weight, ok := imp.rowWeight(weightField, weightFound, line)
+		if !ok {
+			skipped.warn(WARNING_MALFORMED_ROW, line, weightField)
+			if imp.explains(records[idx]) {
+				imp.explain(skipped, line, records[idx], "skipped for its invalid weight %q", weightField)
+			}
 			continue
 		}
+		key, keyFound := "", false
+		if di, ok := columnIndex(columns.dedup, len(records)); ok && keys != nil {
+			key, keyFound = records[di], imp.claimsKey(records[idx])
+		}
+		if keys.duplicate(key, keyFound) {
+			imp.debugf("Skipping csv line %d with an earlier row of key %q", line, key)
+			if imp.explains(records[idx]) {
+				imp.explain(skipped, line, records[idx], "skipped as an earlier row has key %q", key)
+			}
+			continue
+		}
+		emailChan <- emailRow{email: imp.windowed(records[idx], timestamp, found), line: line, weight: weight}
+		emitted++
+	}
+	return nil
+}
+
+// emailRow is an email on its way from the reader to the workers with the
+// CSV line it was read from and the number of customers it stands for.
+type emailRow struct {
+	email  string
+	line   int
+	weight int
+}
 
-		emailChan <- records[EMAIL_IDX]
+// columnIndex resolves idx against a row of n fields, counting negative
+// indexes from the end, so -1 is the last field. It reports false when the
+// row is too short to have the field.
+func columnIndex(idx, n int) (int, bool) {
+	if idx < 0 {
+		idx += n
 	}
+	return idx, idx >= 0 && idx < n
 }
 
-func extractDomains(domains chan string, emailChan chan string, wg *sync.WaitGroup) {
+// isBlankRecord reports whether every field of the record is empty or
+// whitespace, as with the padding lines some exports end with. Such records
+// are not customers and are skipped without logging an error, even when their
+// field count doesn't match the header.
+func isBlankRecord(records []string) bool {
+	if len(records) == 0 {
+		return false
+	}
+	for _, field := range records {
+		if strings.TrimSpace(field) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// extractDomains counts the domain of every valid email received on
+// emailChan, counting invalid emails in skipped. When unique is not nil the
+// emails are collected there instead, to be counted once all duplicates are
+// known. When processed is not nil it counts the emails handled by this
+// worker; each worker owns its counter, so no synchronisation is needed.
+func (imp *Importer) extractDomains(counter *shardedCounter, unique *uniqueSet, emailChan chan emailRow, processed *int, skipped *skipCounts, wg *sync.WaitGroup) {
 	defer wg.Done()
 
-	for email := range emailChan {
-		domain := extractDomain(strings.TrimSpace(email))
-		if domain == "" {
-			log.Println("Invalid email address, doesn't contain domain name")
-		} else {
-			domains <- domain
+	for row := range emailChan {
+		if processed != nil {
+			*processed++
+		}
+		email := row.email
+		window := ""
+		if imp.timeWindow != "" {
+			window, email, _ = strings.Cut(email, windowSeparator)
+			window += windowSeparator
+		}
+		email = imp.trimEmail(email)
+		explained := imp.explains(email)
+		if imp.maxEmailLength > 0 && len(email) > imp.maxEmailLength {
+			imp.debugf("Skipping email of %d characters on line %d", len(email), row.line)
+			skipped.warn(WARNING_LONG_EMAIL, row.line, email)
+			if explained {
+				imp.explain(skipped, row.line, email, "skipped as longer than %d characters", imp.maxEmailLength)
+			}
+			continue
+		}
+		domain, ok := imp.domainOf(email)
+		if !ok {
+			if skipped.addInvalid(email, row.line) {
+				imp.logger.Printf(INVALID_EMAIL_FORMAT, truncateEmail(email))
+			}
+			if explained {
+				imp.explain(skipped, row.line, email, "skipped as an invalid email address")
+			}
+			continue
+		}
+		skipped.valid.Add(1)
+		switch {
+		case !imp.acceptDomain(domain):
+			if explained {
+				imp.explain(skipped, row.line, email, "skipped by a domain filter of domain %s", domain)
+			}
+		case unique != nil:
+			counter.seen(domain, row.line)
+			unique.add(uniqueKey(domain, email))
+			if explained {
+				imp.explain(skipped, row.line, email, "counted for domain %s once per unique email", domain)
+			}
+		default:
+			role := imp.isRoleAccount(email)
+			counter.addAt(window+domain, role, row.line, row.weight)
+			if explained {
+				outcome := "counted for domain " + domain
+				if row.weight != 1 {
+					outcome = fmt.Sprintf("counted %d times for domain %s", row.weight, domain)
+				}
+				if role {
+					outcome += " as a role account"
+				}
+				imp.explain(skipped, row.line, email, "%s", outcome)
+			}
+		}
+	}
+}
+
+// trimEmail strips surrounding whitespace from email and, with WithTrimChars,
+// the characters of the cutset along with the whitespace they enclose. With
+// WithStripMailto the address of a mailto: link is taken after that.
+func (imp *Importer) trimEmail(email string) string {
+	email = strings.TrimSpace(email)
+	if imp.trimChars != "" {
+		email = strings.TrimSpace(strings.Trim(email, imp.trimChars))
+	}
+	if imp.stripMailto {
+		email = stripMailto(email)
+	}
+	return email
+}
+
+// domainOf returns the domain of email using the validator of WithValidator,
+// or extractDomain by default.
+func (imp *Importer) domainOf(email string) (string, bool) {
+	if imp.validator != nil {
+		return imp.validator(email)
+	}
+	domain := extractDomain(email)
+	if imp.caseSensitive {
+		domain = extractDomainPreservingCase(email)
+	}
+	return domain, domain != ""
+}
+
+func (imp *Importer) acceptDomain(domain string) bool {
+	for _, filter := range imp.domainFilters {
+		if !filter(domain) {
+			return false
 		}
 	}
+	return true
+}
+
+// isRoleAccount reports whether the local part of email is one of the
+// configured role accounts, ignoring case.
+func (imp *Importer) isRoleAccount(email string) bool {
+	if len(imp.roleAccounts) == 0 {
+		return false
+	}
+	localPart, _, _ := strings.Cut(email, "@")
+	_, ok := imp.roleAccounts[strings.ToLower(localPart)]
+	return ok
 }
 
 func extractDomain(email string) string {
+	return strings.ToLower(extractDomainPreservingCase(email))
+}
+
+// extractDomainPreservingCase is extractDomain without lowercasing the
+// domain, see WithCaseSensitive.
+func extractDomainPreservingCase(email string) string {
 	emailSplit := strings.SplitN(email, "@", 2)
 	if len(emailSplit) != 2 || strings.Contains(emailSplit[1], "@") {
 		return ""
 	}
 
-	return strings.ToLower(emailSplit[1])
+	if strings.HasPrefix(emailSplit[1], "[") {
+		return extractAddressLiteral(emailSplit[1])
+	}
+
+	// A fully qualified domain with its trailing dot is the same domain.
+	return strings.TrimSuffix(emailSplit[1], ".")
 }
 
-func aggregateDomains(domains chan string, domainMap map[string]int, totalCustomers *int, doneAggregating chan struct{}) {
-	defer close(doneAggregating)
+// extractAddressLiteral normalizes a bracketed IP address literal domain, like
+// [192.168.1.1] or [IPv6:2001:db8::1], to the canonical form of the address
+// without brackets, so differently written literals of one address are
+// counted together. It returns "" for malformed literals.
+func extractAddressLiteral(domain string) string {
+	literal, ok := strings.CutSuffix(domain[1:], "]")
+	if !ok {
+		return ""
+	}
+
+	if ipv6, ok := cutPrefixFold(literal, "IPv6:"); ok {
+		addr, err := netip.ParseAddr(ipv6)
+		if err != nil || !addr.Is6() {
+			return ""
+		}
+		return addr.String()
+	}
+
+	addr, err := netip.ParseAddr(literal)
+	if err != nil || !addr.Is4() {
+		return ""
+	}
+	return addr.String()
+}
 
-	for domain := range domains {
-		domainMap[domain]++
-		*totalCustomers++
+func cutPrefixFold(s, prefix string) (string, bool) {
+	if len(s) < len(prefix) || !strings.EqualFold(s[:len(prefix)], prefix) {
+		return s, false
 	}
+	return s[len(prefix):], true
 }
@@ -1,10 +1,20 @@
 package customerimporter
 
 import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
 	"io"
+	"log"
 	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
 	"strings"
 	"testing"
+	"testing/iotest"
+	"time"
 )
 
 func TestExtractDomain(t *testing.T) {
@@ -43,6 +53,46 @@ func TestExtractDomain(t *testing.T) {
 			inputEmail:     "",
 			expectedDomain: "",
 		},
+		{
+			name:           "Fully qualified domain with trailing dot",
+			inputEmail:     "user@GitHub.io.",
+			expectedDomain: "github.io",
+		},
+		{
+			name:           "Invalid email - only a dot as domain",
+			inputEmail:     "user@.",
+			expectedDomain: "",
+		},
+		{
+			name:           "IPv4 address literal",
+			inputEmail:     "user@[192.168.1.1]",
+			expectedDomain: "192.168.1.1",
+		},
+		{
+			name:           "IPv6 address literal",
+			inputEmail:     "user@[IPv6:2001:DB8:0:0:0:0:0:1]",
+			expectedDomain: "2001:db8::1",
+		},
+		{
+			name:           "IPv6 address literal with lowercase tag",
+			inputEmail:     "user@[ipv6:2001:db8::1]",
+			expectedDomain: "2001:db8::1",
+		},
+		{
+			name:           "Invalid address literal - missing bracket",
+			inputEmail:     "user@[192.168.1.1",
+			expectedDomain: "",
+		},
+		{
+			name:           "Invalid address literal - not an IP",
+			inputEmail:     "user@[example.com]",
+			expectedDomain: "",
+		},
+		{
+			name:           "Invalid address literal - IPv6 without tag",
+			inputEmail:     "user@[2001:db8::1]",
+			expectedDomain: "",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -166,6 +216,7 @@ func TestWriteFile(t *testing.T) {
 	testCases := []struct {
 		name                string
 		domainsCount        DomainsCount
+		opts                OutputOptions
 		expectedFileContent string
 	}{
 		{
@@ -196,6 +247,27 @@ Domain: github.io, Customers: 3` + "\n",
 			domainsCount:        DomainsCount{},
 			expectedFileContent: "Total number of customers: 0\n",
 		},
+		{
+			name:                "custom_total_label",
+			domainsCount:        DomainsCount{TotalCount: 2},
+			opts:                OutputOptions{TotalLabel: "Kunden insgesamt"},
+			expectedFileContent: "Kunden insgesamt: 2\n",
+		},
+		{
+			name: "role_counts",
+			domainsCount: DomainsCount{DomainStats: []DomainStat{
+				{
+					Name:      "github.io",
+					Count:     3,
+					RoleCount: 1,
+				},
+			},
+				TotalCount: 3,
+			},
+			opts: OutputOptions{RoleCounts: true},
+			expectedFileContent: `Total number of customers: 3
+Domain: github.io, Customers: 3, Role accounts: 1, Personal: 2` + "\n",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -208,7 +280,7 @@ Domain: github.io, Customers: 3` + "\n",
 			defer os.Remove(file.Name())
 
 			filePath := file.Name()
-			err = writeFile(tc.domainsCount, &filePath)
+			err = writeFile(tc.domainsCount, &filePath, tc.opts)
 			if err != nil {
 				t.Fatalf("unexpected error occured: %v", err)
 			}
@@ -224,3 +296,663 @@ Domain: github.io, Customers: 3` + "\n",
 		})
 	}
 }
+
+func TestProcessCsv_WorkerStats(t *testing.T) {
+	csvInput := `first_name,last_name,email,gender,ip_address
+Mildred,Hernandez,mhernandez0@github.io,Female,38.194.51.128
+Bonnie,Ortiz,bortiz1@github.io,Female,197.54.209.129
+Dennis,Henry,invalid-email,Male,155.75.186.217
+Norma,Allen,nallen8@cnet.com,Female,168.67.162.1`
+
+	t.Run("enabled", func(t *testing.T) {
+		imp := NewImporter(WithWorkerStats(true), WithWorkers(4))
+		// Hide the size so that the tiny input still gets all workers.
+		result, err := imp.processCsv(io.MultiReader(strings.NewReader(csvInput)))
+		if err != nil {
+			t.Fatalf("unexpected error occured: %v", err)
+		}
+
+		if len(result.workerCounts) != 4 {
+			t.Fatalf("worker counts: %d, expected: %d", len(result.workerCounts), 4)
+		}
+		processed := 0
+		for _, count := range result.workerCounts {
+			processed += count
+		}
+		if processed != 4 {
+			t.Errorf("processed emails: %d, expected: 4", processed)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		imp := NewImporter()
+		result, err := imp.processCsv(strings.NewReader(csvInput))
+		if err != nil {
+			t.Fatalf("unexpected error occured: %v", err)
+		}
+
+		if result.workerCounts != nil {
+			t.Errorf("expected no worker counts, got: %v", result.workerCounts)
+		}
+	})
+}
+
+func TestProcessCsv_TrailingEmptyLines(t *testing.T) {
+	csvInput := `first_name,last_name,email,gender,ip_address
+Mildred,Hernandez,mhernandez0@github.io,Female,38.194.51.128
+Norma,Allen,nallen8@cnet.com,Female,168.67.162.1
+
+
+   
+,,,,
+` + "\t\n\n"
+
+	var logs bytes.Buffer
+	imp := NewImporter(WithLogger(log.New(&logs, "", 0)))
+	result, err := imp.processCsv(strings.NewReader(csvInput))
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+
+	if result.totalCustomers != 2 {
+		t.Errorf("Total count: %d, expected: 2", result.totalCustomers)
+	}
+	if logs.Len() != 0 {
+		t.Errorf("expected no log output, got: %s", logs.String())
+	}
+}
+
+func TestProcessCsv_EmptyInputs(t *testing.T) {
+	testCases := []struct {
+		name          string
+		csvInput      string
+		expectedEmpty bool
+	}{
+		{name: "empty", csvInput: "", expectedEmpty: true},
+		{name: "bom_only", csvInput: "\xEF\xBB\xBF", expectedEmpty: true},
+		{name: "whitespace_only", csvInput: "  \n\t\n\n   ", expectedEmpty: true},
+		{name: "bom_and_whitespace", csvInput: "\xEF\xBB\xBF \n \n", expectedEmpty: true},
+		{name: "malformed_header", csvInput: "first_name,\"email\nrow", expectedEmpty: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewImporter().processCsv(strings.NewReader(tc.csvInput))
+			if err == nil {
+				t.Fatal("error expected, got nil")
+			}
+			if !strings.HasPrefix(err.Error(), "error reading the header of csv:") {
+				t.Errorf("expected header error, got: %v", err)
+			}
+			if errors.Is(err, ErrEmptyInput) != tc.expectedEmpty {
+				t.Errorf("errors.Is(err, ErrEmptyInput) = %v; want %v, err: %v", !tc.expectedEmpty, tc.expectedEmpty, err)
+			}
+		})
+	}
+}
+
+func TestProcessCsv_BOMAndLeadingBlankLines(t *testing.T) {
+	csvInput := "\xEF\xBB\xBF\n   \nemail,first_name\nmhernandez0@github.io,Mildred\nnallen8@cnet.com,Norma\n"
+
+	result, err := NewImporter(WithEmailColumn(0)).processCsv(strings.NewReader(csvInput))
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	if result.totalCustomers != 2 {
+		t.Errorf("Total count: %d, expected: 2", result.totalCustomers)
+	}
+}
+
+func TestProcessCsv_NoGoroutineLeaks(t *testing.T) {
+	testCases := []struct {
+		name        string
+		csvInput    string
+		opts        []Option
+		expectError bool
+	}{
+		{
+			name:     "valid",
+			csvInput: "first_name,last_name,email\nMildred,Hernandez,mhernandez0@github.io\nBonnie,Ortiz,bortiz1@cyberchimps.com",
+		},
+		{
+			name:     "malformed_rows",
+			csvInput: "first_name,last_name,email\nMildred,Hernandez\n\"unterminated,Ortiz,bortiz1@cyberchimps.com",
+			opts:     []Option{WithAllowEmpty(true)},
+		},
+		{
+			name:     "limit",
+			csvInput: "first_name,last_name,email\nMildred,Hernandez,mhernandez0@github.io\nBonnie,Ortiz,bortiz1@cyberchimps.com",
+			opts:     []Option{WithLimit(1)},
+		},
+		{
+			name:     "unique",
+			csvInput: "first_name,last_name,email\nMildred,Hernandez,mhernandez0@github.io\nMildred,Hernandez,mhernandez0@github.io",
+			opts:     []Option{WithUnique(true)},
+		},
+		{
+			name:        "empty_input",
+			csvInput:    "",
+			expectError: true,
+		},
+		{
+			name:        "missing_header_column",
+			csvInput:    "first_name,last_name,email\nMildred,Hernandez,mhernandez0@github.io",
+			opts:        []Option{WithEmailHeader("mail")},
+			expectError: true,
+		},
+		{
+			name:        "skip_past_end",
+			csvInput:    "first_name,last_name,email",
+			opts:        []Option{WithSkipRows(5)},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			before := runtime.NumGoroutine()
+
+			opts := append([]Option{WithWorkers(4), WithLogger(log.New(io.Discard, "", 0))}, tc.opts...)
+			_, err := NewImporter(opts...).processCsv(strings.NewReader(tc.csvInput))
+			if tc.expectError && err == nil {
+				t.Error("error expected, got nil")
+			}
+			if !tc.expectError && err != nil {
+				t.Fatalf("unexpected error occured: %v", err)
+			}
+
+			if after := waitForGoroutines(before); after > before {
+				t.Errorf("Goroutines: %d, expected at most: %d", after, before)
+			}
+		})
+	}
+}
+
+// waitForGoroutines gives exiting goroutines a moment to finish and returns
+// the number of goroutines once it drops to limit or the wait times out.
+func waitForGoroutines(limit int) int {
+	deadline := time.Now().Add(time.Second)
+	for {
+		count := runtime.NumGoroutine()
+		if count <= limit || time.Now().After(deadline) {
+			return count
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWriteFile_FileMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file permissions are not supported on windows")
+	}
+
+	testCases := []struct {
+		name         string
+		mode         os.FileMode
+		expectedMode os.FileMode
+	}{
+		{name: "default", expectedMode: DEFAULT_FILE_MODE},
+		{name: "owner_only", mode: 0600, expectedMode: 0600},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), "report.txt")
+			if err := writeFile(DomainsCount{}, &filePath, OutputOptions{FileMode: tc.mode}); err != nil {
+				t.Fatalf("unexpected error occured: %v", err)
+			}
+
+			info, err := os.Stat(filePath)
+			if err != nil {
+				t.Fatalf("error reading output file info: %v", err)
+			}
+			// The umask can only remove permissions.
+			if perm := info.Mode().Perm(); perm&^tc.expectedMode != 0 {
+				t.Errorf("File mode: %v, expected at most: %v", perm, tc.expectedMode)
+			}
+		})
+	}
+}
+
+func TestProcessCsv_NegativeEmailColumn(t *testing.T) {
+	csvInput := `first_name,last_name,email
+Mildred,Hernandez,mhernandez0@github.io
+Bonnie,Ortiz,Female,bortiz1@cyberchimps.com
+Dennis,dhenry2@hubpages.com
+Lonely`
+
+	testCases := []struct {
+		name          string
+		emailIdx      int
+		fastParse     bool
+		expectedStats []DomainStat
+	}{
+		{
+			name:     "last",
+			emailIdx: LAST_COLUMN,
+			expectedStats: []DomainStat{
+				{Name: "cyberchimps.com", Count: 1, FirstSeenLine: 3},
+				{Name: "github.io", Count: 1, FirstSeenLine: 2},
+				{Name: "hubpages.com", Count: 1, FirstSeenLine: 4},
+			},
+		},
+		{
+			name:      "last_fast_parse",
+			emailIdx:  LAST_COLUMN,
+			fastParse: true,
+			expectedStats: []DomainStat{
+				{Name: "cyberchimps.com", Count: 1, FirstSeenLine: 3},
+				{Name: "github.io", Count: 1, FirstSeenLine: 2},
+				{Name: "hubpages.com", Count: 1, FirstSeenLine: 4},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			imp := NewImporter(WithEmailColumn(tc.emailIdx), WithFastParse(tc.fastParse), WithLogger(log.New(&logs, "", 0)))
+			domainsCount, err := imp.Import(strings.NewReader(csvInput))
+			if err != nil {
+				t.Fatalf("unexpected error occured: %v", err)
+			}
+			if !reflect.DeepEqual(domainsCount.DomainStats, tc.expectedStats) {
+				t.Errorf("Domain stats: %v, expected: %v", domainsCount.DomainStats, tc.expectedStats)
+			}
+			if !strings.Contains(logs.String(), "Skipped 1 rows (1 bad email") {
+				t.Errorf("expected the single field row to be skipped, got: %s", logs.String())
+			}
+		})
+	}
+}
+
+func TestColumnIndex(t *testing.T) {
+	testCases := []struct {
+		idx         int
+		n           int
+		expectedIdx int
+		expectedOk  bool
+	}{
+		{idx: 2, n: 5, expectedIdx: 2, expectedOk: true},
+		{idx: 5, n: 5, expectedIdx: 5, expectedOk: false},
+		{idx: LAST_COLUMN, n: 5, expectedIdx: 4, expectedOk: true},
+		{idx: -5, n: 5, expectedIdx: 0, expectedOk: true},
+		{idx: -6, n: 5, expectedIdx: -1, expectedOk: false},
+		{idx: LAST_COLUMN, n: 0, expectedIdx: -1, expectedOk: false},
+	}
+
+	for _, tc := range testCases {
+		idx, ok := columnIndex(tc.idx, tc.n)
+		if idx != tc.expectedIdx || ok != tc.expectedOk {
+			t.Errorf("columnIndex(%d, %d) = %d, %t; want %d, %t", tc.idx, tc.n, idx, ok, tc.expectedIdx, tc.expectedOk)
+		}
+	}
+}
+
+func TestDomainsCount_ForEach(t *testing.T) {
+	domainsCount := &DomainsCount{DomainStats: []DomainStat{
+		{Name: "cnet.com", Count: 1},
+		{Name: "github.io", Count: 3},
+	}}
+
+	var visited []string
+	domainsCount.ForEach(func(domain string, count int) {
+		visited = append(visited, fmt.Sprintf("%s=%d", domain, count))
+	})
+
+	expected := []string{"cnet.com=1", "github.io=3"}
+	if !reflect.DeepEqual(visited, expected) {
+		t.Errorf("Visited: %v, expected: %v", visited, expected)
+	}
+}
+
+func TestWriteFile_MissingDirectory(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "reports", "2024")
+	filePath := filepath.Join(dir, "report.txt")
+
+	err := writeFile(DomainsCount{}, &filePath, OutputOptions{})
+	if err == nil || !strings.Contains(err.Error(), "output directory does not exist: "+dir) {
+		t.Errorf("expected a missing directory error, got: %v", err)
+	}
+
+	if err := writeFile(DomainsCount{TotalCount: 1}, &filePath, OutputOptions{MakeDirs: true}); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	fileContents, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("error reading the contents of output file: %v", err)
+	}
+	if string(fileContents) != "Total number of customers: 1\n" {
+		t.Errorf("file contents %s, expected: %s", fileContents, "Total number of customers: 1\n")
+	}
+}
+
+func TestWriteFile_FailedWriteKeepsPreviousReport(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "report.txt")
+	previous := "cnet.com 2\n"
+	if err := os.WriteFile(filePath, []byte(previous), 0644); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+
+	// An unsupported format fails inside WriteTo, after the output is opened.
+	if err := writeFile(DomainsCount{TotalCount: 1}, &filePath, OutputOptions{Format: "xml"}); err == nil {
+		t.Fatalf("expected an error writing the report")
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	if string(content) != previous {
+		t.Errorf("output file: %q, expected: %q", content, previous)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("files in output directory: %d, expected: %d", len(entries), 1)
+	}
+}
+
+func TestWriteFile_OutputIsInput(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "customers.csv")
+	link := filepath.Join(dir, "link.csv")
+	csvInput := "email\na@github.io\n"
+	if err := os.WriteFile(input, []byte(csvInput), 0644); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	if err := os.Symlink(input, link); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+
+	relative, err := filepath.Rel(".", input)
+	if err != nil {
+		relative = input
+	}
+	for _, filePath := range []string{input, filepath.Join(dir, ".", "customers.csv"), link, relative} {
+		err := writeFile(DomainsCount{TotalCount: 1}, &filePath, OutputOptions{Inputs: []string{input}})
+		if !errors.Is(err, ErrOutputIsInput) {
+			t.Errorf("%s: expected ErrOutputIsInput, got: %v", filePath, err)
+		}
+	}
+	fileContents, err := os.ReadFile(input)
+	if err != nil {
+		t.Fatalf("error reading the contents of input file: %v", err)
+	}
+	if string(fileContents) != csvInput {
+		t.Errorf("input contents %q, expected: %q", fileContents, csvInput)
+	}
+
+	other := filepath.Join(dir, "report.txt")
+	if err := writeFile(DomainsCount{TotalCount: 1}, &other, OutputOptions{Inputs: []string{input}}); err != nil {
+		t.Errorf("unexpected error occured: %v", err)
+	}
+}
+
+func TestWorkersFor(t *testing.T) {
+	testCases := []struct {
+		name     string
+		opts     []Option
+		size     int64
+		expected int
+	}{
+		{name: "unknown_size", size: -1, expected: 8},
+		{name: "tiny_input", size: 200, expected: 1},
+		{name: "small_input", size: 3*MIN_BYTES_PER_WORKER + 1, expected: 4},
+		{name: "large_input", size: 1 << 30, expected: 8},
+		{name: "limit", opts: []Option{WithLimit(3)}, size: -1, expected: 3},
+	}
+
+	for _, tc := range testCases {
+		imp := NewImporter(append([]Option{WithWorkers(8)}, tc.opts...)...)
+		if actual := imp.workersFor(tc.size); actual != tc.expected {
+			t.Errorf("%s: workers %d, expected: %d", tc.name, actual, tc.expected)
+		}
+	}
+}
+
+func TestProcessCsv_MoreWorkersThanRows(t *testing.T) {
+	csvInput := "first_name,last_name,email\nMildred,Hernandez,mhernandez0@github.io"
+	before := runtime.NumGoroutine()
+
+	// Hide the size so that every worker is started for the single row.
+	imp := NewImporter(WithWorkers(256), WithWorkerStats(true))
+	result, err := imp.processCsv(io.MultiReader(strings.NewReader(csvInput)))
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+
+	if len(result.workerCounts) != 256 {
+		t.Errorf("worker counts: %d, expected: %d", len(result.workerCounts), 256)
+	}
+	if result.totalCustomers != 1 || result.domainMap["github.io"] != 1 {
+		t.Errorf("Total customers: %d, domains: %v, expected one github.io customer", result.totalCustomers, result.domainMap)
+	}
+	if after := waitForGoroutines(before); after > before {
+		t.Errorf("Goroutines: %d, expected at most: %d", after, before)
+	}
+}
+
+func TestWriteFile_Gzip(t *testing.T) {
+	domainsCount := DomainsCount{DomainStats: []DomainStat{{Name: "github.io", Count: 2}}, TotalCount: 2}
+	expectedContent := "Total number of customers: 2\nDomain: github.io, Customers: 2\n"
+
+	testCases := []struct {
+		name     string
+		fileName string
+		opts     OutputOptions
+	}{
+		{name: "extension", fileName: "result.txt.gz"},
+		{name: "option", fileName: "result.txt", opts: OutputOptions{Gzip: true}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tc.fileName)
+			if err := WriteOutput(domainsCount, &path, tc.opts); err != nil {
+				t.Fatalf("unexpected error occured: %v", err)
+			}
+
+			file, err := os.Open(path)
+			if err != nil {
+				t.Fatalf("unexpected error occured: %v", err)
+			}
+			defer file.Close()
+			reader, err := gzip.NewReader(file)
+			if err != nil {
+				t.Fatalf("unexpected error occured: %v", err)
+			}
+			content, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatalf("unexpected error occured: %v", err)
+			}
+			if string(content) != expectedContent {
+				t.Errorf("File content: %s, expected: %s", content, expectedContent)
+			}
+		})
+	}
+}
+
+func TestImport_FirstSeenLine(t *testing.T) {
+	csvInput := `title line
+first_name,last_name,email
+Mildred,Hernandez,mhernandez0@github.io
+
+Bonnie,Ortiz,bortiz1@cyberchimps.com
+Norma,Allen,nallen8@github.io
+Dennis,Henry,dhenry2@cyberchimps.com`
+
+	for _, unique := range []bool{false, true} {
+		imp := NewImporter(WithWorkers(4), WithSkipRows(1), WithUnique(unique))
+		domainsCount, err := imp.Import(strings.NewReader(csvInput))
+		if err != nil {
+			t.Fatalf("unexpected error occured: %v", err)
+		}
+
+		expectedStats := []DomainStat{
+			{Name: "cyberchimps.com", Count: 2, FirstSeenLine: 5},
+			{Name: "github.io", Count: 2, FirstSeenLine: 3},
+		}
+		if !reflect.DeepEqual(domainsCount.DomainStats, expectedStats) {
+			t.Errorf("unique %v: domain stats %v, expected: %v", unique, domainsCount.DomainStats, expectedStats)
+		}
+	}
+}
+
+func TestProcessCsv_ReadErrorsTerminate(t *testing.T) {
+	errDisk := errors.New("disk error")
+
+	testCases := []struct {
+		name          string
+		input         func() io.Reader
+		fastParse     bool
+		expectedTotal int
+		expectedErr   error
+	}{
+		{
+			name: "repeated_read_errors",
+			input: func() io.Reader {
+				return io.MultiReader(strings.NewReader("email\na@github.io\n"), iotest.ErrReader(errDisk))
+			},
+			expectedErr: errDisk,
+		},
+		{
+			name: "repeated_read_errors_fast_parse",
+			input: func() io.Reader {
+				return io.MultiReader(strings.NewReader("email\na@github.io\n"), iotest.ErrReader(errDisk))
+			},
+			fastParse:   true,
+			expectedErr: errDisk,
+		},
+		{
+			name: "bare_quote_mid_stream",
+			input: func() io.Reader {
+				return strings.NewReader("email\na@github.io\nb\"x@cnet.com\nc@cnet.com\n")
+			},
+			expectedTotal: 2,
+		},
+	}
+
+	for _, tc := range testCases {
+		imp := NewImporter(WithEmailColumn(0), WithWorkers(2), WithFastParse(tc.fastParse), WithLogger(log.New(io.Discard, "", 0)))
+		done := make(chan *csvResult)
+		go func() {
+			result, err := imp.processCsv(tc.input())
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("%s: error: %v, expected: %v", tc.name, err, tc.expectedErr)
+			}
+			done <- result
+		}()
+
+		select {
+		case result := <-done:
+			if result != nil && result.totalCustomers != tc.expectedTotal {
+				t.Errorf("%s: total customers: %d, expected: %d", tc.name, result.totalCustomers, tc.expectedTotal)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s: processCsv did not return", tc.name)
+		}
+	}
+}
+
+func TestImport_TruncatedGzip(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write([]byte(generateCsv(10_000, 10)))
+	gz.Close()
+	truncated := buf.Bytes()[:buf.Len()/2]
+
+	for _, format := range []string{"", INPUT_FORMAT_JSONL} {
+		imp := NewImporter(WithInputFormat(format), WithLogger(log.New(io.Discard, "", 0)))
+		if _, err := imp.Import(bytes.NewReader(truncated)); !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("format %q: error: %v, expected: %v", format, err, io.ErrUnexpectedEOF)
+		}
+	}
+}
+
+func TestImporter_TrimChars(t *testing.T) {
+	csvInput := "email\n\"mhernandez0@github.io\"\n `bortiz1@cnet.com` \n'dhenry2@github.io'\n\"\"\n"
+
+	testCases := []struct {
+		name          string
+		trimChars     string
+		expected      []DomainStat
+		expectedTotal int
+	}{
+		{
+			name:      "quotes_and_backticks",
+			trimChars: "\"'`",
+			expected: []DomainStat{
+				{Name: "cnet.com", Count: 1, FirstSeenLine: 3},
+				{Name: "github.io", Count: 2, FirstSeenLine: 2},
+			},
+			expectedTotal: 3,
+		},
+		{
+			name:      "default_whitespace",
+			trimChars: "",
+			expected: []DomainStat{
+				{Name: "cnet.com`", Count: 1, FirstSeenLine: 3},
+				{Name: "github.io\"", Count: 1, FirstSeenLine: 2},
+				{Name: "github.io'", Count: 1, FirstSeenLine: 4},
+			},
+			expectedTotal: 3,
+		},
+	}
+
+	for _, tc := range testCases {
+		// The fast parser leaves quotes in place like exports that quote
+		// without CSV escaping.
+		imp := NewImporter(WithEmailColumn(0), WithFastParse(true), WithTrimChars(tc.trimChars), WithLogger(log.New(io.Discard, "", 0)))
+		domainsCount, err := imp.Import(strings.NewReader(csvInput))
+		if err != nil {
+			t.Fatalf("%s: unexpected error occured: %v", tc.name, err)
+		}
+		if domainsCount.TotalCount != tc.expectedTotal {
+			t.Errorf("%s: total customers: %d, expected: %d", tc.name, domainsCount.TotalCount, tc.expectedTotal)
+		}
+		if !reflect.DeepEqual(domainsCount.DomainStats, tc.expected) {
+			t.Errorf("%s: domain stats %v, expected: %v", tc.name, domainsCount.DomainStats, tc.expected)
+		}
+	}
+}
+
+func TestImporter_MaxEmailLength(t *testing.T) {
+	blob := "a@" + strings.Repeat("x", 300) + ".com"
+	csvInput := "email\nmhernandez0@github.io\n" + blob + "\n"
+
+	testCases := []struct {
+		name             string
+		opts             []Option
+		expectedTotal    int
+		expectedWarnings []Warning
+	}{
+		{
+			name:             "default",
+			expectedTotal:    1,
+			expectedWarnings: []Warning{{Category: WARNING_LONG_EMAIL, Line: 3, Value: truncateEmail(blob)}},
+		},
+		{name: "disabled", opts: []Option{WithMaxEmailLength(0)}, expectedTotal: 2},
+		{name: "raised", opts: []Option{WithMaxEmailLength(len(blob))}, expectedTotal: 2},
+	}
+
+	for _, tc := range testCases {
+		imp := NewImporter(append([]Option{WithEmailColumn(0), WithLogger(log.New(io.Discard, "", 0))}, tc.opts...)...)
+		domainsCount, err := imp.Import(strings.NewReader(csvInput))
+		if err != nil {
+			t.Fatalf("%s: unexpected error occured: %v", tc.name, err)
+		}
+		if domainsCount.TotalCount != tc.expectedTotal {
+			t.Errorf("%s: total customers: %d, expected: %d", tc.name, domainsCount.TotalCount, tc.expectedTotal)
+		}
+		if !reflect.DeepEqual(domainsCount.Warnings, tc.expectedWarnings) {
+			t.Errorf("%s: warnings %v, expected: %v", tc.name, domainsCount.Warnings, tc.expectedWarnings)
+		}
+		if expectedInvalid := len(tc.expectedWarnings); domainsCount.InvalidCount != expectedInvalid {
+			t.Errorf("%s: invalid count: %d, expected: %d", tc.name, domainsCount.InvalidCount, expectedInvalid)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package customerimporter
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const DEFAULT_JSON_KEY = "email"
+
+// jsonField returns the field key of object as text: strings without their
+// quotes and other values as written. It reports false for missing and null
+// fields.
+func jsonField(object map[string]json.RawMessage, key string) (string, bool) {
+	raw, ok := object[key]
+	if !ok || string(raw) == "null" {
+		return "", false
+	}
+	var str string
+	if err := json.Unmarshal(raw, &str); err == nil {
+		return str, true
+	}
+	return string(raw), true
+}
+
+// jsonlReader is the INPUT_FORMAT_JSONL counterpart of csvReader. It decodes
+// a JSON object per line and sends its WithJSONKey field to the workers.
+// WithRowFilter, WithTimeWindow and WithDedupKey columns name object fields.
+func (imp *Importer) jsonlReader(reader *bufio.Reader, emailChan chan emailRow, tracker *progressTracker, sampled *bool, skipped *skipCounts) error {
+	defer close(emailChan)
+	lineNum := imp.skipRows
+	emitted := 0
+	keys := imp.newDedupKeys()
+	var scratch []byte
+
+	for {
+		if imp.limit > 0 && emitted == imp.limit {
+			imp.debugf("Row limit of %d reached", imp.limit)
+			tracker.update(lineNum, true)
+			*sampled = true
+			break
+		}
+
+		line, err := readLine(reader, &scratch)
+		lineNum++
+		if err == io.EOF {
+			imp.debugf("End of file reached")
+			tracker.update(lineNum-1, true)
+			break
+		}
+		if err != nil {
+			tracker.update(lineNum-1, true)
+			return fmt.Errorf("error reading jsonl line %d: %w", lineNum, err)
+		}
+		if lineNum%PROGRESS_INTERVAL == 0 {
+			tracker.update(lineNum, false)
+		}
+		if len(bytes.TrimSpace(line)) == 0 {
+			imp.debugf("Skipping blank jsonl line %d", lineNum)
+			continue
+		}
+
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(line, &object); err != nil {
+			imp.logger.Printf("Error reading jsonl line %d: %v\n", lineNum, err)
+			skipped.warn(WARNING_MALFORMED_ROW, lineNum, "")
+			continue
+		}
+
+		email, ok := jsonField(object, imp.jsonKey)
+		if !ok {
+			imp.logger.Printf("Line %d has no %q field\n", lineNum, imp.jsonKey)
+			skipped.addShortRow(lineNum, fmt.Sprintf("no %q field", imp.jsonKey))
+			continue
+		}
+
+		if !imp.matchesJSONFilters(object) {
+			imp.debugf("Skipping jsonl line %d not matching the filters", lineNum)
+			if imp.explains(email) {
+				imp.explain(skipped, lineNum, email, "skipped as the row doesn't match the filters")
+			}
+			continue
+		}
+
+		timestamp, found := "", false
+		if imp.timeWindow != "" {
+			timestamp, found = jsonField(object, imp.timeColumn)
+		}
+		key, keyFound := "", false
+		if keys != nil {
+			key, keyFound = jsonField(object, imp.dedupKey)
+			keyFound = keyFound && imp.claimsKey(email)
+		}
+		if keys.duplicate(key, keyFound) {
+			imp.debugf("Skipping jsonl line %d with an earlier row of key %q", lineNum, key)
+			if imp.explains(email) {
+				imp.explain(skipped, lineNum, email, "skipped as an earlier row has key %q", key)
+			}
+			continue
+		}
+		emailChan <- emailRow{email: imp.windowed(email, timestamp, found), line: lineNum, weight: 1}
+		emitted++
+	}
+	return nil
+}
+
+// matchesJSONFilters reports whether object passes the WithRowFilter filters,
+// their columns naming fields.
+func (imp *Importer) matchesJSONFilters(object map[string]json.RawMessage) bool {
+	for _, filter := range imp.rowFilters {
+		value, ok := jsonField(object, filter.column)
+		if !ok || !strings.EqualFold(strings.TrimSpace(value), filter.value) {
+			return false
+		}
+	}
+	return true
+}
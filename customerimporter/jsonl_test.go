@@ -0,0 +1,83 @@
+package customerimporter
+
+import (
+	"bytes"
+	"compress/gzip"
+	"log"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestImporter_JSONL(t *testing.T) {
+	jsonlInput := `{"email": "a@github.io", "plan": "pro"}
+{"email": "b@cnet.com", "plan": "free"}
+
+{"mail": "c@github.io"}
+{"email": "d@github.io", "plan": "pro"
+{"email": null}
+{"email": "e@cnet.com", "plan": "pro", "seats": 3}`
+
+	testCases := []struct {
+		name         string
+		opts         []Option
+		expected     []DomainStat
+		expectedSkip string
+	}{
+		{
+			name: "default_key",
+			expected: []DomainStat{
+				{Name: "cnet.com", Count: 2, FirstSeenLine: 2},
+				{Name: "github.io", Count: 1, FirstSeenLine: 1},
+			},
+			expectedSkip: "Skipped 3 rows (0 bad email, 0 empty email, 0 long email, 2 short row, 1 malformed)\n",
+		},
+		{
+			name: "custom_key",
+			opts: []Option{WithJSONKey("mail")},
+			expected: []DomainStat{
+				{Name: "github.io", Count: 1, FirstSeenLine: 4},
+			},
+			expectedSkip: "Skipped 5 rows (0 bad email, 0 empty email, 0 long email, 4 short row, 1 malformed)\n",
+		},
+		{
+			name: "filter",
+			opts: []Option{WithRowFilter("plan", "PRO")},
+			expected: []DomainStat{
+				{Name: "cnet.com", Count: 1, FirstSeenLine: 7},
+				{Name: "github.io", Count: 1, FirstSeenLine: 1},
+			},
+			expectedSkip: "Skipped 3 rows (0 bad email, 0 empty email, 0 long email, 2 short row, 1 malformed)\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		var logs bytes.Buffer
+		imp := NewImporter(append([]Option{WithInputFormat(INPUT_FORMAT_JSONL), WithLogger(log.New(&logs, "", 0))}, tc.opts...)...)
+		domainsCount, err := imp.Import(strings.NewReader(jsonlInput))
+		if err != nil {
+			t.Fatalf("%s: unexpected error occured: %v", tc.name, err)
+		}
+		if !reflect.DeepEqual(domainsCount.DomainStats, tc.expected) {
+			t.Errorf("%s: domain stats %v, expected: %v", tc.name, domainsCount.DomainStats, tc.expected)
+		}
+		if !strings.HasSuffix(logs.String(), tc.expectedSkip) {
+			t.Errorf("%s: logs %q, expected to end with: %q", tc.name, logs.String(), tc.expectedSkip)
+		}
+	}
+}
+
+func TestImporter_JSONLGzip(t *testing.T) {
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	gz.Write([]byte(`{"email":"a@github.io"}` + "\n"))
+	gz.Close()
+
+	domainsCount, err := NewImporter(WithInputFormat(INPUT_FORMAT_JSONL)).Import(&compressed)
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	if domainsCount.TotalCount != 1 {
+		t.Errorf("total customers: %d, expected: %d", domainsCount.TotalCount, 1)
+	}
+}
@@ -0,0 +1,59 @@
+package customerimporter
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// LiveCounter counts the domains of emails added one at a time, e.g. from a
+// signup feed, and reports the running counts at any point. It is safe for
+// concurrent use.
+type LiveCounter struct {
+	// mu is held shared by Add, whose counts go to the locked shards of
+	// counter, and exclusively by Snapshot, so a snapshot never sees an Add
+	// half done.
+	mu      sync.RWMutex
+	counter *shardedCounter
+	invalid atomic.Int64
+}
+
+// NewLiveCounter returns a LiveCounter with no emails counted yet.
+func NewLiveCounter() *LiveCounter {
+	return &LiveCounter{counter: newShardedCounter()}
+}
+
+// Add counts the domain of email, or counts it as invalid when it has none.
+func (lc *LiveCounter) Add(email string) {
+	lc.mu.RLock()
+	defer lc.mu.RUnlock()
+
+	domain := extractDomain(strings.TrimSpace(email))
+	if domain == "" {
+		lc.invalid.Add(1)
+		return
+	}
+	lc.counter.add(domain, false)
+}
+
+// Feed adds every email received on emails until the channel is closed.
+func (lc *LiveCounter) Feed(emails <-chan string) {
+	for email := range emails {
+		lc.Add(email)
+	}
+}
+
+// Snapshot returns the counts of all emails added so far, sorted by domain
+// like an import result. Adds wait while the counts are copied.
+func (lc *LiveCounter) Snapshot() DomainsCount {
+	lc.mu.Lock()
+	domainMap, roleMap, total := lc.counter.merge()
+	invalid := int(lc.invalid.Load())
+	lc.mu.Unlock()
+
+	return DomainsCount{
+		DomainStats:  createStats(domainMap, roleMap, false),
+		TotalCount:   total,
+		InvalidCount: invalid,
+	}
+}
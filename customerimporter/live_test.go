@@ -0,0 +1,81 @@
+package customerimporter
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestLiveCounter(t *testing.T) {
+	counter := NewLiveCounter()
+	counter.Add("a@github.io")
+	counter.Add(" B@GitHub.io ")
+	counter.Add("c@cnet.com")
+	counter.Add("invalid-email")
+
+	snapshot := counter.Snapshot()
+	expectedStats := []DomainStat{{Name: "cnet.com", Count: 1}, {Name: "github.io", Count: 2}}
+	if !reflect.DeepEqual(snapshot.DomainStats, expectedStats) {
+		t.Errorf("Domain stats: %v, expected: %v", snapshot.DomainStats, expectedStats)
+	}
+	if snapshot.TotalCount != 3 {
+		t.Errorf("Total count: %d, expected: 3", snapshot.TotalCount)
+	}
+	if snapshot.InvalidCount != 1 {
+		t.Errorf("Invalid count: %d, expected: 1", snapshot.InvalidCount)
+	}
+
+	counter.Add("d@cnet.com")
+	if snapshot.DomainStats[0].Count != 1 {
+		t.Errorf("Earlier snapshot count: %d, expected it to stay 1", snapshot.DomainStats[0].Count)
+	}
+	if total := counter.Snapshot().TotalCount; total != 4 {
+		t.Errorf("Total count: %d, expected: 4", total)
+	}
+}
+
+func TestLiveCounter_Feed(t *testing.T) {
+	const FEEDS = 4
+	const EMAILS = 1000
+
+	counter := NewLiveCounter()
+	var wg sync.WaitGroup
+	for range FEEDS {
+		emails := make(chan string)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			counter.Feed(emails)
+		}()
+		go func() {
+			defer close(emails)
+			for i := range EMAILS {
+				emails <- fmt.Sprintf("user%d@domain%d.com", i, i%10)
+			}
+		}()
+	}
+
+	// Every snapshot taken while feeding must add up.
+	for range 10 {
+		snapshot := counter.Snapshot()
+		sum := 0
+		for _, domainStat := range snapshot.DomainStats {
+			sum += domainStat.Count
+		}
+		if sum != snapshot.TotalCount {
+			t.Errorf("Snapshot domain counts sum to %d, expected the total: %d", sum, snapshot.TotalCount)
+		}
+	}
+	wg.Wait()
+
+	snapshot := counter.Snapshot()
+	if snapshot.TotalCount != FEEDS*EMAILS {
+		t.Errorf("Total count: %d, expected: %d", snapshot.TotalCount, FEEDS*EMAILS)
+	}
+	for _, domainStat := range snapshot.DomainStats {
+		if domainStat.Count != FEEDS*EMAILS/10 {
+			t.Errorf("Domain %s count: %d, expected: %d", domainStat.Name, domainStat.Count, FEEDS*EMAILS/10)
+		}
+	}
+}
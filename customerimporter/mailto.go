@@ -0,0 +1,32 @@
+package customerimporter
+
+import (
+	"net/url"
+	"strings"
+)
+
+const MAILTO_SCHEME = "mailto:"
+
+// WithStripMailto counts emails stored as clickable mailto: links, like
+// mailto:user@x.com?subject=Hi, by their address. Emails without the scheme
+// are counted as usual.
+func WithStripMailto(strip bool) Option {
+	return func(imp *Importer) {
+		imp.stripMailto = strip
+	}
+}
+
+// stripMailto returns the address of a mailto: link, ignoring the case of the
+// scheme, without its query and percent-decoded. Other emails are returned
+// unchanged.
+func stripMailto(email string) string {
+	address, ok := cutPrefixFold(email, MAILTO_SCHEME)
+	if !ok {
+		return email
+	}
+	address, _, _ = strings.Cut(address, "?")
+	if unescaped, err := url.PathUnescape(address); err == nil {
+		address = unescaped
+	}
+	return strings.TrimSpace(address)
+}
@@ -0,0 +1,66 @@
+package customerimporter
+
+import (
+	"io"
+	"log"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStripMailto(t *testing.T) {
+	testCases := []struct {
+		email    string
+		expected string
+	}{
+		{email: "mailto:user@x.com", expected: "user@x.com"},
+		{email: "MAILTO:user@x.com", expected: "user@x.com"},
+		{email: "mailto:user@x.com?subject=Hello", expected: "user@x.com"},
+		{email: "mailto:user%40x.com", expected: "user@x.com"},
+		{email: "user@x.com", expected: "user@x.com"},
+		{email: "mailtouser@x.com", expected: "mailtouser@x.com"},
+	}
+
+	for _, tc := range testCases {
+		if email := stripMailto(tc.email); email != tc.expected {
+			t.Errorf("stripMailto(%q): %q, expected: %q", tc.email, email, tc.expected)
+		}
+	}
+}
+
+func TestImporter_StripMailto(t *testing.T) {
+	csvInput := "email\nmailto:mhernandez0@github.io\nbortiz1@github.io\n Mailto:dhenry2@cnet.com?subject=Hi \n"
+
+	testCases := []struct {
+		name     string
+		strip    bool
+		expected []DomainStat
+	}{
+		{
+			name:  "stripped",
+			strip: true,
+			expected: []DomainStat{
+				{Name: "cnet.com", Count: 1, FirstSeenLine: 4},
+				{Name: "github.io", Count: 2, FirstSeenLine: 2},
+			},
+		},
+		{
+			name: "default",
+			expected: []DomainStat{
+				{Name: "cnet.com?subject=hi", Count: 1, FirstSeenLine: 4},
+				{Name: "github.io", Count: 2, FirstSeenLine: 2},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		imp := NewImporter(WithEmailColumn(0), WithStripMailto(tc.strip), WithLogger(log.New(io.Discard, "", 0)))
+		domainsCount, err := imp.Import(strings.NewReader(csvInput))
+		if err != nil {
+			t.Fatalf("%s: unexpected error occured: %v", tc.name, err)
+		}
+		if !reflect.DeepEqual(domainsCount.DomainStats, tc.expected) {
+			t.Errorf("%s: domain stats: %v, expected: %v", tc.name, domainsCount.DomainStats, tc.expected)
+		}
+	}
+}
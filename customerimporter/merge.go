@@ -0,0 +1,280 @@
+package customerimporter
+
+import (
+	"container/heap"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// MergeResults sums JSON encoded DomainsCount documents, as written with
+// FORMAT_JSON in either the report or the flat shape, into a single result.
+// Each document's domain stats must be sorted by name, as they are by default.
+// Grouped results with subdomains are an error, as the groups of different
+// results don't line up. The documents are streamed and merged k-way, so
+// besides the merged result only the current entry of each input is held in
+// memory.
+func MergeResults(readers ...io.Reader) (*DomainsCount, error) {
+	streams := make([]*statStream, 0, len(readers))
+	for i, reader := range readers {
+		stream, err := newStatStream(fmt.Sprintf("result %d", i+1), reader, true)
+		if err != nil {
+			return &DomainsCount{}, err
+		}
+		streams = append(streams, stream)
+	}
+
+	pending := make(statHeap, 0, len(streams))
+	for _, stream := range streams {
+		if !stream.done {
+			pending = append(pending, stream)
+		}
+	}
+	heap.Init(&pending)
+
+	merged := &DomainsCount{DomainStats: []DomainStat{}}
+	for pending.Len() > 0 {
+		stream := pending[0]
+		stat := stream.head
+		if len(stat.Subdomains) > 0 {
+			return &DomainsCount{}, stream.errorf("merge does not support grouped results with subdomains")
+		}
+
+		last := len(merged.DomainStats) - 1
+		if last >= 0 && merged.DomainStats[last].Name == stat.Name {
+			merged.DomainStats[last].Count += stat.Count
+			merged.DomainStats[last].RoleCount += stat.RoleCount
+		} else {
+			merged.DomainStats = append(merged.DomainStats, stat)
+		}
+
+		if err := stream.next(); err != nil {
+			return &DomainsCount{}, err
+		}
+		if stream.done {
+			heap.Pop(&pending)
+		} else {
+			heap.Fix(&pending, 0)
+		}
+	}
+
+	for _, stream := range streams {
+		merged.TotalCount += stream.total
+	}
+	return merged, nil
+}
+
+// MergeResultFiles opens the JSON result files at paths and merges them with
+// MergeResults.
+func MergeResultFiles(paths ...string) (*DomainsCount, error) {
+	readers := make([]io.Reader, 0, len(paths))
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			return &DomainsCount{}, err
+		}
+		defer file.Close()
+		readers = append(readers, file)
+	}
+	return MergeResults(readers...)
+}
+
+// ReadResult decodes a JSON encoded DomainsCount, in either the report or the
+// flat shape, in whatever order its domain stats are, e.g. as written with
+// OutputOptions.SortKeys, keeping their Subdomains. Unlike MergeResults it
+// holds the whole result in memory.
+func ReadResult(reader io.Reader) (*DomainsCount, error) {
+	stream, err := newStatStream("result", reader, false)
+	if err != nil {
+		return &DomainsCount{}, err
+	}
+	result := &DomainsCount{DomainStats: []DomainStat{}}
+	for !stream.done {
+		result.DomainStats = append(result.DomainStats, stream.head)
+		if err := stream.next(); err != nil {
+			return &DomainsCount{}, err
+		}
+	}
+	result.TotalCount = stream.total
+	return result, nil
+}
+
+// ReadResultFile opens the JSON result file at path and reads it with
+// ReadResult.
+func ReadResultFile(path string) (*DomainsCount, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return &DomainsCount{}, err
+	}
+	defer file.Close()
+	return ReadResult(file)
+}
+
+// MergeCounts sums results into one, like MergeResults does for JSON
+// documents, with the domain stats sorted by the sort and collation of imp.
+// Subdomains are dropped, as groups of different results don't line up, and
+// so is FirstSeenLine, as the lines of different inputs don't compare. The
+// totals and the invalid and disposable counts add up, and the warnings are
+// kept in the order given.
+func (imp *Importer) MergeCounts(results ...DomainsCount) *DomainsCount {
+	merged := &DomainsCount{DomainStats: []DomainStat{}}
+	index := make(map[string]int)
+	for _, result := range results {
+		for _, domainStat := range result.DomainStats {
+			i, ok := index[domainStat.Name]
+			if !ok {
+				index[domainStat.Name] = len(merged.DomainStats)
+				merged.DomainStats = append(merged.DomainStats, DomainStat{Name: domainStat.Name, Disposable: domainStat.Disposable})
+				i = len(merged.DomainStats) - 1
+			}
+			merged.DomainStats[i].Count += domainStat.Count
+			merged.DomainStats[i].RoleCount += domainStat.RoleCount
+		}
+		merged.TotalCount += result.TotalCount
+		merged.InvalidCount += result.InvalidCount
+		merged.DisposableCount += result.DisposableCount
+		merged.Warnings = append(merged.Warnings, result.Warnings...)
+		for category, count := range result.WarningCounts {
+			if merged.WarningCounts == nil {
+				merged.WarningCounts = make(map[WarningCategory]int)
+			}
+			merged.WarningCounts[category] += count
+		}
+	}
+	sortStats(merged.DomainStats, imp.naturalSort)
+	if imp.collation != nil {
+		collateStats(merged.DomainStats, *imp.collation)
+	}
+	return merged
+}
+
+// statStream decodes the domain stats of a JSON encoded DomainsCount one entry
+// at a time. head is the current entry until done is set, at which point the
+// whole document has been read and total is known. With sorted, entries out
+// of name order are an error.
+type statStream struct {
+	name    string
+	decoder *json.Decoder
+	head    DomainStat
+	total   int
+	done    bool
+	sorted  bool
+}
+
+func newStatStream(name string, reader io.Reader, sorted bool) (*statStream, error) {
+	stream := &statStream{name: name, decoder: json.NewDecoder(reader), sorted: sorted}
+
+	if err := stream.expectDelim('{'); err != nil {
+		return nil, err
+	}
+	inStats, err := stream.readFields()
+	if err != nil {
+		return nil, err
+	}
+	if !inStats {
+		stream.done = true
+		return stream, nil
+	}
+	return stream, stream.next()
+}
+
+// readFields reads object fields until the start of the domain stats array,
+// returning true, or the end of the object, returning false.
+func (s *statStream) readFields() (bool, error) {
+	for s.decoder.More() {
+		token, err := s.decoder.Token()
+		if err != nil {
+			return false, s.errorf("%v", err)
+		}
+
+		switch token {
+		case "domain_stats", "domains":
+			delim, err := s.decoder.Token()
+			if err != nil {
+				return false, s.errorf("%v", err)
+			}
+			if delim == nil {
+				continue
+			}
+			if delim != json.Delim('[') {
+				return false, s.errorf("%s is not an array", token)
+			}
+			return true, nil
+		case "total_count":
+			if err := s.decoder.Decode(&s.total); err != nil {
+				return false, s.errorf("%v", err)
+			}
+		case "summary":
+			var summary jsonSummary
+			if err := s.decoder.Decode(&summary); err != nil {
+				return false, s.errorf("%v", err)
+			}
+			s.total = summary.TotalCustomers
+		default:
+			var skipped json.RawMessage
+			if err := s.decoder.Decode(&skipped); err != nil {
+				return false, s.errorf("%v", err)
+			}
+		}
+	}
+	return false, s.expectDelim('}')
+}
+
+// next advances head to the following domain stat, reading the rest of the
+// document once the domain stats are exhausted.
+func (s *statStream) next() error {
+	if s.decoder.More() {
+		var stat DomainStat
+		if err := s.decoder.Decode(&stat); err != nil {
+			return s.errorf("%v", err)
+		}
+		if s.sorted && s.head.Name != "" && stat.Name <= s.head.Name {
+			return s.errorf("domain stats are not sorted by name: %q after %q", stat.Name, s.head.Name)
+		}
+		s.head = stat
+		return nil
+	}
+
+	if err := s.expectDelim(']'); err != nil {
+		return err
+	}
+	if _, err := s.readFields(); err != nil {
+		return err
+	}
+	s.done = true
+	return nil
+}
+
+func (s *statStream) expectDelim(delim json.Delim) error {
+	token, err := s.decoder.Token()
+	if err != nil {
+		return s.errorf("%v", err)
+	}
+	if token != delim {
+		return s.errorf("expected %v, got %v", delim, token)
+	}
+	return nil
+}
+
+func (s *statStream) errorf(format string, v ...any) error {
+	return fmt.Errorf("error reading %s: %s", s.name, fmt.Sprintf(format, v...))
+}
+
+// statHeap orders streams by the name of their head domain stat.
+type statHeap []*statStream
+
+func (h statHeap) Len() int           { return len(h) }
+func (h statHeap) Less(i, j int) bool { return h[i].head.Name < h[j].head.Name }
+func (h statHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *statHeap) Push(x any) {
+	*h = append(*h, x.(*statStream))
+}
+
+func (h *statHeap) Pop() any {
+	old := *h
+	stream := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return stream
+}
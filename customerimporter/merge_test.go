@@ -0,0 +1,171 @@
+package customerimporter
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/language"
+)
+
+func TestMergeResults(t *testing.T) {
+	first := DomainsCount{DomainStats: []DomainStat{
+		{Name: "acquirethisname.com", Count: 1},
+		{Name: "github.io", Count: 3, RoleCount: 1},
+	},
+		TotalCount: 4,
+	}
+	second := DomainsCount{DomainStats: []DomainStat{
+		{Name: "cnet.com", Count: 2},
+		{Name: "github.io", Count: 2},
+		{Name: "zoho.com", Count: 1},
+	},
+		TotalCount: 5,
+	}
+
+	readers := []*bytes.Buffer{}
+	for i, domainsCount := range []DomainsCount{first, second, {}} {
+		var buf bytes.Buffer
+		opts := OutputOptions{Format: FORMAT_JSON, JSONFlat: i == 0}
+		if err := WriteTo(&buf, domainsCount, opts); err != nil {
+			t.Fatalf("error writing json: %v", err)
+		}
+		readers = append(readers, &buf)
+	}
+	// Field order must not matter.
+	totalFirst := strings.NewReader(`{"total_count": 1, "domain_stats": [{"name": "cnet.com", "count": 1}]}`)
+
+	merged, err := MergeResults(readers[0], readers[1], readers[2], totalFirst)
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+
+	expectedStats := []DomainStat{
+		{Name: "acquirethisname.com", Count: 1},
+		{Name: "cnet.com", Count: 3},
+		{Name: "github.io", Count: 5, RoleCount: 1},
+		{Name: "zoho.com", Count: 1},
+	}
+	if merged.TotalCount != 10 {
+		t.Errorf("Total count: %d, expected: 10", merged.TotalCount)
+	}
+	if len(merged.DomainStats) != len(expectedStats) {
+		t.Fatalf("Domain stats: %v, expected: %v", merged.DomainStats, expectedStats)
+	}
+	for i, domain := range merged.DomainStats {
+		if !reflect.DeepEqual(domain, expectedStats[i]) {
+			t.Errorf("Domain stat: %v, expected: %v", domain, expectedStats[i])
+		}
+	}
+}
+
+func TestMergeCounts(t *testing.T) {
+	partial := DomainsCount{DomainStats: []DomainStat{
+		{Name: "github.io", Count: 3, RoleCount: 1},
+		{Name: "zoho.com", Count: 1},
+	},
+		TotalCount: 4,
+	}
+	fresh := DomainsCount{DomainStats: []DomainStat{
+		{Name: "cnet.com", Count: 2, FirstSeenLine: 3},
+		{Name: "github.io", Count: 2, FirstSeenLine: 2},
+	},
+		TotalCount:    4,
+		InvalidCount:  1,
+		Warnings:      []Warning{{Category: WARNING_BAD_EMAIL, Line: 4, Value: "invalid"}},
+		WarningCounts: map[WarningCategory]int{WARNING_BAD_EMAIL: 1},
+	}
+
+	merged := NewImporter().MergeCounts(partial, fresh)
+
+	expected := &DomainsCount{DomainStats: []DomainStat{
+		{Name: "cnet.com", Count: 2},
+		{Name: "github.io", Count: 5, RoleCount: 1},
+		{Name: "zoho.com", Count: 1},
+	},
+		TotalCount:    8,
+		InvalidCount:  1,
+		Warnings:      []Warning{{Category: WARNING_BAD_EMAIL, Line: 4, Value: "invalid"}},
+		WarningCounts: map[WarningCategory]int{WARNING_BAD_EMAIL: 1},
+	}
+	if !reflect.DeepEqual(merged, expected) {
+		t.Errorf("merged %v, expected: %v", merged, expected)
+	}
+}
+
+func TestMergeCounts_Collation(t *testing.T) {
+	partial := DomainsCount{DomainStats: []DomainStat{{Name: "ábc.com", Count: 1}}, TotalCount: 1}
+	fresh := DomainsCount{DomainStats: []DomainStat{{Name: "abd.com", Count: 1}}, TotalCount: 1}
+
+	merged := NewImporter(WithCollation(language.Und)).MergeCounts(partial, fresh)
+
+	if len(merged.DomainStats) != 2 || merged.DomainStats[0].Name != "ábc.com" {
+		t.Errorf("domain stats %v, expected ábc.com first", merged.DomainStats)
+	}
+}
+
+func TestMergeResults_Errors(t *testing.T) {
+	testCases := []struct {
+		name        string
+		input       string
+		expectedErr string
+	}{
+		{
+			name:  "unsorted",
+			input: `{"domain_stats": [{"name": "github.io", "count": 1}, {"name": "cnet.com", "count": 1}], "total_count": 2}`,
+		},
+		{
+			name:  "not_an_object",
+			input: `[]`,
+		},
+		{
+			name:  "truncated",
+			input: `{"domain_stats": [{"name": "github.io", "count": 1}`,
+		},
+		{
+			name:        "grouped",
+			input:       `{"domains": [{"name": "io", "count": 1, "subdomains": [{"name": "github.io", "count": 1}]}]}`,
+			expectedErr: "merge does not support grouped results with subdomains",
+		},
+		{
+			name:        "not_an_array",
+			input:       `{"domains": {"github.io": 1}}`,
+			expectedErr: "domains is not an array",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := MergeResults(strings.NewReader(tc.input))
+			if err == nil {
+				t.Fatal("error expected, got nil")
+			}
+			if !strings.Contains(err.Error(), tc.expectedErr) {
+				t.Errorf("error %q, expected: %q", err, tc.expectedErr)
+			}
+		})
+	}
+}
+
+func TestReadResult(t *testing.T) {
+	input := `{"summary": {"total_customers": 5}, "domains": [
+		{"name": "io", "count": 3, "subdomains": [{"name": "github.io", "count": 3}]},
+		{"name": "com", "count": 2}
+	]}`
+
+	result, err := ReadResult(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	expected := &DomainsCount{
+		DomainStats: []DomainStat{
+			{Name: "io", Count: 3, Subdomains: []DomainStat{{Name: "github.io", Count: 3}}},
+			{Name: "com", Count: 2},
+		},
+		TotalCount: 5,
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("result %v, expected: %v", result, expected)
+	}
+}
@@ -0,0 +1,43 @@
+package customerimporter
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"time"
+)
+
+const METADATA_RUN_ID_FORMAT = "Run ID: %s\n"
+const METADATA_GENERATED_AT_FORMAT = "Generated at: %s\n"
+
+// RunMetadata identifies the run that produced a report, to correlate reports
+// with logs.
+type RunMetadata struct {
+	RunID       string    `json:"run_id"`
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+// NewRunMetadata returns metadata with a random version 4 UUID as the run ID,
+// generated now.
+func NewRunMetadata() (*RunMetadata, error) {
+	var uuid [16]byte
+	if _, err := rand.Read(uuid[:]); err != nil {
+		return nil, fmt.Errorf("error generating run id: %v", err)
+	}
+	uuid[6] = uuid[6]&0x0f | 0x40
+	uuid[8] = uuid[8]&0x3f | 0x80
+
+	return &RunMetadata{
+		RunID:       fmt.Sprintf("%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:]),
+		GeneratedAt: time.Now().UTC(),
+	}, nil
+}
+
+func writeTextMetadata(w io.Writer, metadata *RunMetadata) error {
+	_, err := fmt.Fprintf(w, METADATA_RUN_ID_FORMAT, metadata.RunID)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, METADATA_GENERATED_AT_FORMAT, metadata.GeneratedAt.Format(time.RFC3339))
+	return err
+}
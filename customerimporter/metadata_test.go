@@ -0,0 +1,29 @@
+package customerimporter
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestNewRunMetadata(t *testing.T) {
+	uuidPattern := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+	first, err := NewRunMetadata()
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	second, err := NewRunMetadata()
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+
+	if !uuidPattern.MatchString(first.RunID) {
+		t.Errorf("Run ID: %s, expected a version 4 UUID", first.RunID)
+	}
+	if first.RunID == second.RunID {
+		t.Errorf("Run IDs of two runs are both %s", first.RunID)
+	}
+	if first.GeneratedAt.IsZero() {
+		t.Error("GeneratedAt is not set")
+	}
+}
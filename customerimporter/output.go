@@ -0,0 +1,502 @@
+package customerimporter
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const FORMAT_TEXT = "text"
+const FORMAT_JSON = "json"
+const FORMAT_CSV = "csv"
+const FORMAT_YAML = "yaml"
+
+// FORMATS lists the supported output formats. FORMAT_YAML is only supported
+// when built with the yaml tag, so text-only users don't need the YAML library.
+var FORMATS = []string{FORMAT_TEXT, FORMAT_JSON, FORMAT_CSV}
+
+// writeYAML renders FORMAT_YAML, set when built with the yaml tag.
+var writeYAML func(w io.Writer, domainsCount DomainsCount) error
+
+const DEFAULT_TOTAL_LABEL = "Total number of customers"
+const OUTPUT_HEADER_FORMAT = "%s: %d\n"
+const SAMPLE_LINE_FORMAT = "Sample of the first %d rows\n"
+const DISPOSABLE_LINE_FORMAT = "Disposable email customers: %d\n"
+const DISPOSABLE_MARKER = " [disposable]"
+const INVALID_LINE_FORMAT = "Customers with invalid email: %d\n"
+const OUTPUT_LINE_FORMAT = "Domain: %s, Customers: %d\n"
+const NORMALIZED_LINE_FORMAT = "Domain: %s, Customers per %d: %s\n"
+const CUMULATIVE_LINE_FORMAT = "Domain: %s, Customers: %d, Cumulative: %s%%\n"
+const ROLE_LINE_FORMAT = "Domain: %s, Customers: %d, Role accounts: %d, Personal: %d\n"
+
+// ALIGNED_LINE_FORMAT and ALIGNED_ROLE_LINE_FORMAT are the Align variants of
+// OUTPUT_LINE_FORMAT and ROLE_LINE_FORMAT, padding the name with its comma and
+// the count to the widest of the output.
+const ALIGNED_LINE_FORMAT = "Domain: %-*s Customers: %*d\n"
+const ALIGNED_ROLE_LINE_FORMAT = "Domain: %-*s Customers: %*d, Role accounts: %d, Personal: %d\n"
+const GROUP_HEADER_FORMAT = "Group: %s\n"
+const GROUP_SUBTOTAL_FORMAT = "Subtotal: %d\n"
+const GROUP_INDENT = "  "
+const DEFAULT_BAR_WIDTH = 80
+const BAR_CHAR = "█"
+const MIN_BAR_LENGTH = 10
+
+// WriteTo renders domainsCount to w as WriteOutput does to a file or stdout.
+func WriteTo(w io.Writer, domainsCount DomainsCount, opts OutputOptions) error {
+	if inlineChecksum(opts) {
+		return writeWithChecksum(w, domainsCount, opts)
+	}
+	if opts.Coverage != nil {
+		// Before filtering, as the count limits would hide present domains.
+		return writeCoverage(w, domainsCount, opts)
+	}
+	domainsCount.DomainStats = filterStats(domainsCount.DomainStats, opts)
+	// Before the tree replaces the domains with their top-level domains.
+	summary := newJSONSummary(domainsCount)
+	if opts.Tree {
+		domainsCount.DomainStats = treeStats(domainsCount.DomainStats)
+		domainsCount.Hierarchy = true
+	}
+	if len(opts.SortKeys) > 0 {
+		domainsCount.DomainStats = sortStatsBy(domainsCount.DomainStats, opts.SortKeys)
+	}
+	if opts.Cumulative {
+		domainsCount.DomainStats = sortByCount(domainsCount.DomainStats)
+	}
+
+	if opts.NamesOnly {
+		return writeNames(w, domainsCount)
+	}
+	if opts.Top {
+		return writeTop(w, domainsCount)
+	}
+
+	switch opts.Format {
+	case "", FORMAT_TEXT:
+		return writeText(w, domainsCount, opts)
+	case FORMAT_JSON:
+		if opts.SummaryOnly {
+			return encodeJSON(w, summary)
+		}
+		if opts.JSONFlat {
+			return writeJSONFlat(w, domainsCount)
+		}
+		report := newJSONReport(domainsCount, opts.Metadata)
+		report.Summary = summary
+		if opts.HistogramBounds != nil {
+			report.Histogram = Histogram(domainsCount.DomainStats, opts.HistogramBounds)
+		}
+		if opts.Cumulative {
+			running := 0
+			for i := range report.Domains {
+				running += report.Domains[i].Count
+				report.Domains[i].CumulativePercent = percentOf(running, domainsCount.TotalCount)
+			}
+		}
+		if opts.NormalizePer > 0 {
+			report.Summary.NormalizePer = opts.NormalizePer
+			normalizeJSONDomains(report.Domains, domainsCount.TotalCount, opts.NormalizePer)
+		}
+		return encodeJSON(w, report)
+	case FORMAT_CSV:
+		return WriteCSV(w, domainsCount)
+	case FORMAT_YAML:
+		if writeYAML != nil {
+			return writeYAML(w, domainsCount)
+		}
+		return ValidateFormat(opts.Format)
+	default:
+		return ValidateFormat(opts.Format)
+	}
+}
+
+// ValidateFormat returns an error listing the FORMATS when format is not one
+// of them. An empty format is text.
+func ValidateFormat(format string) error {
+	if format == "" || slices.Contains(FORMATS, format) {
+		return nil
+	}
+	return fmt.Errorf("unsupported format: %s (supported: %s)", format, strings.Join(FORMATS, ", "))
+}
+
+func filterStats(domainStats []DomainStat, opts OutputOptions) []DomainStat {
+	if opts.MinCount <= 0 && opts.MaxCount <= 0 {
+		return domainStats
+	}
+
+	filtered := make([]DomainStat, 0, len(domainStats))
+	for _, domainStat := range domainStats {
+		if opts.InCountRange(domainStat.Count) {
+			filtered = append(filtered, domainStat)
+		}
+	}
+	return filtered
+}
+
+// InCountRange reports whether a domain of count customers falls within the
+// MinCount and MaxCount of opts.
+func (opts OutputOptions) InCountRange(count int) bool {
+	if opts.MinCount > 0 && count < opts.MinCount {
+		return false
+	}
+	return opts.MaxCount <= 0 || count <= opts.MaxCount
+}
+
+func writeText(w io.Writer, domainsCount DomainsCount, opts OutputOptions) error {
+	if opts.Metadata != nil {
+		if err := writeTextMetadata(w, opts.Metadata); err != nil {
+			return err
+		}
+	}
+
+	label := opts.TotalLabel
+	if label == "" {
+		label = DEFAULT_TOTAL_LABEL
+	}
+	_, err := fmt.Fprintf(w, OUTPUT_HEADER_FORMAT, label, domainsCount.TotalCount)
+	if err != nil {
+		return err
+	}
+	if domainsCount.SampleRows > 0 {
+		_, err = fmt.Fprintf(w, SAMPLE_LINE_FORMAT, domainsCount.SampleRows)
+		if err != nil {
+			return err
+		}
+	}
+	if domainsCount.DisposableCount > 0 {
+		_, err = fmt.Fprintf(w, DISPOSABLE_LINE_FORMAT, domainsCount.DisposableCount)
+		if err != nil {
+			return err
+		}
+	}
+	if domainsCount.InvalidCount > 0 {
+		_, err = fmt.Fprintf(w, INVALID_LINE_FORMAT, domainsCount.InvalidCount)
+		if err != nil {
+			return err
+		}
+	}
+	if opts.HistogramBounds != nil {
+		return writeHistogram(w, Histogram(domainsCount.DomainStats, opts.HistogramBounds))
+	}
+	if opts.Bars {
+		return writeBars(w, domainsCount, opts)
+	}
+	if opts.Cumulative {
+		return writeCumulative(w, domainsCount)
+	}
+	if opts.Align {
+		opts.nameWidth, opts.countWidth = textWidths(domainsCount.DomainStats)
+	}
+	if domainsCount.Hierarchy {
+		return writeTextHierarchy(w, domainsCount.DomainStats, "", domainsCount.TotalCount, opts)
+	}
+	for _, domainStat := range domainsCount.DomainStats {
+		if domainStat.Subdomains != nil {
+			err = writeTextGroup(w, domainStat, domainsCount.TotalCount, opts)
+		} else {
+			_, err = io.WriteString(w, formatLine(domainStat, domainsCount.TotalCount, opts))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteStatLine writes the text line of a single domain stat like the text
+// output of opts, or just its name with NamesOnly, for output written one
+// domain at a time, see WithStream. Lines relative to the total, like those of
+// NormalizePer, are not supported.
+func WriteStatLine(w io.Writer, domainStat DomainStat, opts OutputOptions) error {
+	if opts.NamesOnly {
+		_, err := fmt.Fprintln(w, domainStat.Name)
+		return err
+	}
+	_, err := io.WriteString(w, formatLine(domainStat, 0, opts))
+	return err
+}
+
+func writeNames(w io.Writer, domainsCount DomainsCount) error {
+	for _, domainStat := range domainsCount.DomainStats {
+		_, err := fmt.Fprintln(w, domainStat.Name)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeBars writes a line per domain with a bar proportional to its count,
+// the largest count spanning the space opts.Width leaves after the aligned
+// name, count and percent columns.
+func writeBars(w io.Writer, domainsCount DomainsCount, opts OutputOptions) error {
+	nameWidth, maxCount := 0, 0
+	for _, domainStat := range domainsCount.DomainStats {
+		nameWidth = max(nameWidth, utf8.RuneCountInString(domainStat.Name))
+		maxCount = max(maxCount, domainStat.Count)
+	}
+	countWidth := len(strconv.Itoa(maxCount))
+
+	width := opts.Width
+	if width <= 0 {
+		width = DEFAULT_BAR_WIDTH
+	}
+	// Two spaces between columns and room for a percent like " 100%".
+	barLength := max(width-nameWidth-countWidth-4-len(" 100%"), MIN_BAR_LENGTH)
+
+	for _, domainStat := range domainsCount.DomainStats {
+		length := 0
+		if maxCount > 0 {
+			length = domainStat.Count * barLength / maxCount
+		}
+		percent := strconv.FormatFloat(percentOf(domainStat.Count, domainsCount.TotalCount), 'f', -1, 64)
+		_, err := fmt.Fprintf(w, "%-*s  %*d  %s %s%%\n",
+			nameWidth, domainStat.Name, countWidth, domainStat.Count, strings.Repeat(BAR_CHAR, length), percent)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sortByCount returns a copy of domainStats ordered by count, largest first,
+// and by name among equal counts.
+func sortByCount(domainStats []DomainStat) []DomainStat {
+	sorted := slices.Clone(domainStats)
+	slices.SortStableFunc(sorted, func(a, b DomainStat) int {
+		return b.Count - a.Count
+	})
+	return sorted
+}
+
+// writeCumulative writes a line per domain with the share of all customers
+// it and the larger domains before it cover. Groups are written as their
+// totals.
+func writeCumulative(w io.Writer, domainsCount DomainsCount) error {
+	running := 0
+	for _, domainStat := range domainsCount.DomainStats {
+		running += domainStat.Count
+		cumulative := strconv.FormatFloat(percentOf(running, domainsCount.TotalCount), 'f', -1, 64)
+		_, err := fmt.Fprintf(w, CUMULATIVE_LINE_FORMAT, domainStat.Name, domainStat.Count, cumulative)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeTextGroup writes a grouped domain as a header, its member domains
+// indented underneath and the group's subtotal.
+func writeTextGroup(w io.Writer, group DomainStat, total int, opts OutputOptions) error {
+	_, err := fmt.Fprintf(w, GROUP_HEADER_FORMAT, group.Name)
+	if err != nil {
+		return err
+	}
+	for _, member := range group.Subdomains {
+		_, err := io.WriteString(w, GROUP_INDENT+formatLine(member, total, opts))
+		if err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, GROUP_INDENT+GROUP_SUBTOTAL_FORMAT, group.Count)
+	return err
+}
+
+// writeTextHierarchy writes a line per GROUP_BY_HIERARCHY level, indented
+// below the level above.
+func writeTextHierarchy(w io.Writer, domainStats []DomainStat, indent string, total int, opts OutputOptions) error {
+	for _, domainStat := range domainStats {
+		if _, err := io.WriteString(w, indent+formatLine(domainStat, total, opts)); err != nil {
+			return err
+		}
+		if err := writeTextHierarchy(w, domainStat.Subdomains, indent+GROUP_INDENT, total, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// textWidths returns the widest name, with its comma, and count of
+// domainStats and their subdomains for Align.
+func textWidths(domainStats []DomainStat) (int, int) {
+	nameWidth, countWidth := 0, 0
+	for _, domainStat := range domainStats {
+		nameWidth = max(nameWidth, len(domainStat.Name)+1)
+		countWidth = max(countWidth, len(strconv.Itoa(domainStat.Count)))
+		subNameWidth, subCountWidth := textWidths(domainStat.Subdomains)
+		nameWidth, countWidth = max(nameWidth, subNameWidth), max(countWidth, subCountWidth)
+	}
+	return nameWidth, countWidth
+}
+
+func formatLine(domainStat DomainStat, total int, opts OutputOptions) string {
+	line := fmt.Sprintf(OUTPUT_LINE_FORMAT, domainStat.Name, domainStat.Count)
+	if opts.Align {
+		line = fmt.Sprintf(ALIGNED_LINE_FORMAT, opts.nameWidth, domainStat.Name+",", opts.countWidth, domainStat.Count)
+	}
+	if opts.RoleCounts {
+		line = fmt.Sprintf(ROLE_LINE_FORMAT, domainStat.Name, domainStat.Count, domainStat.RoleCount, domainStat.Count-domainStat.RoleCount)
+		if opts.Align {
+			line = fmt.Sprintf(ALIGNED_ROLE_LINE_FORMAT, opts.nameWidth, domainStat.Name+",", opts.countWidth, domainStat.Count, domainStat.RoleCount, domainStat.Count-domainStat.RoleCount)
+		}
+	}
+	if opts.NormalizePer > 0 {
+		normalized := strconv.FormatFloat(normalizedCount(domainStat.Count, total, opts.NormalizePer), 'f', -1, 64)
+		line = fmt.Sprintf(NORMALIZED_LINE_FORMAT, domainStat.Name, opts.NormalizePer, normalized)
+	}
+	if domainStat.Disposable {
+		line = strings.TrimSuffix(line, "\n") + DISPOSABLE_MARKER + "\n"
+	}
+	return line
+}
+
+type jsonReport struct {
+	Metadata  *RunMetadata      `json:"metadata,omitempty"`
+	Summary   jsonSummary       `json:"summary"`
+	Histogram []HistogramBucket `json:"histogram,omitempty"`
+	Domains   []jsonDomain      `json:"domains"`
+}
+
+type jsonSummary struct {
+	TotalCustomers      int `json:"total_customers"`
+	DistinctDomains     int `json:"distinct_domains"`
+	SampleRows          int `json:"sample_rows,omitempty"`
+	DisposableCustomers int `json:"disposable_customers,omitempty"`
+	InvalidCustomers    int `json:"invalid_customers,omitempty"`
+	NormalizePer        int `json:"normalize_per,omitempty"`
+}
+
+type jsonDomain struct {
+	Name       string  `json:"name"`
+	Count      int     `json:"count"`
+	Percent    float64 `json:"percent"`
+	Normalized float64 `json:"normalized,omitempty"`
+	// CumulativePercent is only set on top-level domains.
+	CumulativePercent float64      `json:"cumulative_percent,omitempty"`
+	RoleCount         int          `json:"role_count,omitempty"`
+	Subdomains        []jsonDomain `json:"subdomains,omitempty"`
+	Disposable        bool         `json:"disposable,omitempty"`
+}
+
+// WriteJSON encodes domainsCount as a summary object followed by the domains
+// with their share of all customers, rounded to two decimals. This is the
+// FORMAT_JSON output.
+func WriteJSON(w io.Writer, domainsCount DomainsCount) error {
+	return encodeJSON(w, newJSONReport(domainsCount, nil))
+}
+
+func newJSONReport(domainsCount DomainsCount, metadata *RunMetadata) jsonReport {
+	return jsonReport{
+		Metadata: metadata,
+		Summary:  newJSONSummary(domainsCount),
+		Domains:  jsonDomains(domainsCount.DomainStats, domainsCount.TotalCount),
+	}
+}
+
+func newJSONSummary(domainsCount DomainsCount) jsonSummary {
+	return jsonSummary{
+		TotalCustomers:      domainsCount.TotalCount,
+		DistinctDomains:     len(domainsCount.DomainStats),
+		SampleRows:          domainsCount.SampleRows,
+		DisposableCustomers: domainsCount.DisposableCount,
+		InvalidCustomers:    domainsCount.InvalidCount,
+	}
+}
+
+func jsonDomains(domainStats []DomainStat, total int) []jsonDomain {
+	domains := make([]jsonDomain, 0, len(domainStats))
+	for _, domainStat := range domainStats {
+		domain := jsonDomain{
+			Name:       domainStat.Name,
+			Count:      domainStat.Count,
+			Percent:    percentOf(domainStat.Count, total),
+			RoleCount:  domainStat.RoleCount,
+			Disposable: domainStat.Disposable,
+		}
+		if len(domainStat.Subdomains) > 0 {
+			domain.Subdomains = jsonDomains(domainStat.Subdomains, total)
+		}
+		domains = append(domains, domain)
+	}
+	return domains
+}
+
+func normalizeJSONDomains(domains []jsonDomain, total, per int) {
+	for i := range domains {
+		domains[i].Normalized = normalizedCount(domains[i].Count, total, per)
+		normalizeJSONDomains(domains[i].Subdomains, total, per)
+	}
+}
+
+// writeJSONFlat encodes domainsCount as is.
+func writeJSONFlat(w io.Writer, domainsCount DomainsCount) error {
+	if domainsCount.DomainStats == nil {
+		domainsCount.DomainStats = []DomainStat{}
+	}
+	return encodeJSON(w, domainsCount)
+}
+
+func encodeJSON(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+func percentOf(count, total int) float64 {
+	return normalizedCount(count, total, 100)
+}
+
+// normalizedCount returns count per `per` customers of total, rounded to two
+// decimals, e.g. 25 for 1 of 4 customers per 100.
+func normalizedCount(count, total, per int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(count)*float64(per)*100/float64(total)) / 100
+}
+
+// CSVHeader returns the header record of FORMAT_CSV output.
+func CSVHeader() []string {
+	return []string{"domain", "customers"}
+}
+
+// CSVRecord returns the FORMAT_CSV record of domainStat.
+func CSVRecord(domainStat DomainStat) []string {
+	return []string{domainStat.Name, strconv.Itoa(domainStat.Count)}
+}
+
+// writeCSVStats writes a record per domain stat.
+func writeCSVStats(writer *csv.Writer, domainStats []DomainStat) error {
+	for _, domainStat := range domainStats {
+		if err := writer.Write(CSVRecord(domainStat)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteCSV writes domainsCount as a domain,customers header followed by one
+// row per domain. Grouped domains are written as their group totals. The
+// nested levels of GROUP_BY_HIERARCHY and OutputOptions.Tree can't be told
+// apart in the flat rows, so they are an error. This is the FORMAT_CSV output.
+func WriteCSV(w io.Writer, domainsCount DomainsCount) error {
+	if domainsCount.Hierarchy {
+		return errors.New("csv output does not support nested domain levels")
+	}
+	writer := csv.NewWriter(w)
+	if err := writer.Write(CSVHeader()); err != nil {
+		return err
+	}
+	if err := writeCSVStats(writer, domainsCount.DomainStats); err != nil {
+		return err
+	}
+	writer.Flush()
+	return writer.Error()
+}
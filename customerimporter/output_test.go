@@ -0,0 +1,609 @@
+package customerimporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFilterStats(t *testing.T) {
+	domainStats := []DomainStat{
+		{Name: "acquirethisname.com", Count: 1},
+		{Name: "cnet.com", Count: 2},
+		{Name: "github.io", Count: 3},
+		{Name: "zoho.com", Count: 1},
+	}
+
+	testCases := []struct {
+		name          string
+		opts          OutputOptions
+		expectedNames []string
+	}{
+		{
+			name:          "no_limits",
+			opts:          OutputOptions{},
+			expectedNames: []string{"acquirethisname.com", "cnet.com", "github.io", "zoho.com"},
+		},
+		{
+			name:          "singletons",
+			opts:          OutputOptions{MinCount: 1, MaxCount: 1},
+			expectedNames: []string{"acquirethisname.com", "zoho.com"},
+		},
+		{
+			name:          "min_count",
+			opts:          OutputOptions{MinCount: 2},
+			expectedNames: []string{"cnet.com", "github.io"},
+		},
+		{
+			name:          "max_count",
+			opts:          OutputOptions{MaxCount: 2},
+			expectedNames: []string{"acquirethisname.com", "cnet.com", "zoho.com"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			filtered := filterStats(domainStats, tc.opts)
+			if len(filtered) != len(tc.expectedNames) {
+				t.Fatalf("filtered stats: %v, expected names: %v", filtered, tc.expectedNames)
+			}
+			for i, domainStat := range filtered {
+				if domainStat.Name != tc.expectedNames[i] {
+					t.Errorf("Domain name: %s, expected: %s", domainStat.Name, tc.expectedNames[i])
+				}
+			}
+		})
+	}
+}
+
+func TestWriteTo_JSON(t *testing.T) {
+	domainsCount := DomainsCount{DomainStats: []DomainStat{
+		{Name: "cnet.com", Count: 1},
+		{Name: "github.io", Count: 2, RoleCount: 1},
+	},
+		TotalCount: 3,
+	}
+
+	testCases := []struct {
+		name           string
+		opts           OutputOptions
+		expectedOutput string
+	}{
+		{
+			name: "report",
+			opts: OutputOptions{Format: FORMAT_JSON},
+			expectedOutput: `{
+  "summary": {
+    "total_customers": 3,
+    "distinct_domains": 2
+  },
+  "domains": [
+    {
+      "name": "cnet.com",
+      "count": 1,
+      "percent": 33.33
+    },
+    {
+      "name": "github.io",
+      "count": 2,
+      "percent": 66.67,
+      "role_count": 1
+    }
+  ]
+}
+`,
+		},
+		{
+			name: "flat",
+			opts: OutputOptions{Format: FORMAT_JSON, JSONFlat: true},
+			expectedOutput: `{
+  "domain_stats": [
+    {
+      "name": "cnet.com",
+      "count": 1
+    },
+    {
+      "name": "github.io",
+      "count": 2,
+      "role_count": 1
+    }
+  ],
+  "total_count": 3
+}
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteTo(&buf, domainsCount, tc.opts); err != nil {
+				t.Fatalf("unexpected error occured: %v", err)
+			}
+			if buf.String() != tc.expectedOutput {
+				t.Errorf("output %s, expected: %s", buf.String(), tc.expectedOutput)
+			}
+		})
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	domainsCount := DomainsCount{DomainStats: []DomainStat{
+		{Name: "cnet.com", Count: 1},
+		{Name: "github.io", Count: 2},
+	},
+		TotalCount: 3,
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, domainsCount); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+
+	expectedOutput := "domain,customers\ncnet.com,1\ngithub.io,2\n"
+	if buf.String() != expectedOutput {
+		t.Errorf("output %s, expected: %s", buf.String(), expectedOutput)
+	}
+}
+
+func TestWriteTo_Metadata(t *testing.T) {
+	domainsCount := DomainsCount{DomainStats: []DomainStat{{Name: "cnet.com", Count: 1}}, TotalCount: 1}
+	metadata := &RunMetadata{
+		RunID:       "0b7e6c2a-3f1d-4c8e-9a55-6d2f0e1b4c77",
+		GeneratedAt: time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC),
+	}
+
+	testCases := []struct {
+		name           string
+		opts           OutputOptions
+		expectedOutput string
+	}{
+		{
+			name: "text",
+			opts: OutputOptions{Metadata: metadata},
+			expectedOutput: `Run ID: 0b7e6c2a-3f1d-4c8e-9a55-6d2f0e1b4c77
+Generated at: 2024-05-01T12:30:00Z
+Total number of customers: 1
+Domain: cnet.com, Customers: 1
+`,
+		},
+		{
+			name: "json",
+			opts: OutputOptions{Format: FORMAT_JSON, Metadata: metadata},
+			expectedOutput: `{
+  "metadata": {
+    "run_id": "0b7e6c2a-3f1d-4c8e-9a55-6d2f0e1b4c77",
+    "generated_at": "2024-05-01T12:30:00Z"
+  },
+  "summary": {
+    "total_customers": 1,
+    "distinct_domains": 1
+  },
+  "domains": [
+    {
+      "name": "cnet.com",
+      "count": 1,
+      "percent": 100
+    }
+  ]
+}
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteTo(&buf, domainsCount, tc.opts); err != nil {
+				t.Fatalf("unexpected error occured: %v", err)
+			}
+			if buf.String() != tc.expectedOutput {
+				t.Errorf("output %s, expected: %s", buf.String(), tc.expectedOutput)
+			}
+		})
+	}
+}
+
+func TestWriteTo_NamesOnly(t *testing.T) {
+	domainsCount := DomainsCount{DomainStats: []DomainStat{
+		{Name: "cnet.com", Count: 1},
+		{Name: "github.io", Count: 2},
+		{Name: "zoho.com", Count: 1},
+	},
+		TotalCount: 4,
+	}
+
+	var buf bytes.Buffer
+	opts := OutputOptions{Format: FORMAT_JSON, NamesOnly: true, MinCount: 1, MaxCount: 1}
+	if err := WriteTo(&buf, domainsCount, opts); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+
+	expectedOutput := "cnet.com\nzoho.com\n"
+	if buf.String() != expectedOutput {
+		t.Errorf("output %s, expected: %s", buf.String(), expectedOutput)
+	}
+}
+
+func TestWriteTo_SummaryOnly(t *testing.T) {
+	domainsCount := DomainsCount{DomainStats: []DomainStat{
+		{Name: "cnet.com", Count: 1},
+		{Name: "github.io", Count: 2},
+	},
+		TotalCount: 3,
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTo(&buf, domainsCount, OutputOptions{Format: FORMAT_JSON, SummaryOnly: true}); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+
+	expectedOutput := "{\n  \"total_customers\": 3,\n  \"distinct_domains\": 2\n}\n"
+	if buf.String() != expectedOutput {
+		t.Errorf("output %s, expected: %s", buf.String(), expectedOutput)
+	}
+}
+
+func TestWriteTo_Tree(t *testing.T) {
+	domainsCount := DomainsCount{DomainStats: []DomainStat{
+		{Name: "cnet.com", Count: 1},
+		{Name: "corp.com", Count: 2},
+		{Name: "eng.corp.com", Count: 1},
+		{Name: "github.io", Count: 3},
+	},
+		TotalCount: 7,
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTo(&buf, domainsCount, OutputOptions{Tree: true}); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	expectedOutput := `Total number of customers: 7
+Domain: com, Customers: 4
+  Domain: cnet.com, Customers: 1
+  Domain: corp.com, Customers: 3
+    Domain: eng.corp.com, Customers: 1
+Domain: io, Customers: 3
+  Domain: github.io, Customers: 3
+`
+	if buf.String() != expectedOutput {
+		t.Errorf("output %s, expected: %s", buf.String(), expectedOutput)
+	}
+
+	buf.Reset()
+	if err := WriteTo(&buf, domainsCount, OutputOptions{Format: FORMAT_JSON, Tree: true}); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	var report struct {
+		Summary struct {
+			DistinctDomains int `json:"distinct_domains"`
+		} `json:"summary"`
+		Domains []struct {
+			Name       string `json:"name"`
+			Count      int    `json:"count"`
+			Subdomains []struct {
+				Name  string `json:"name"`
+				Count int    `json:"count"`
+			} `json:"subdomains"`
+		} `json:"domains"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	if len(report.Domains) != 2 || report.Domains[0].Name != "com" || report.Domains[0].Count != 4 || len(report.Domains[0].Subdomains) != 2 {
+		t.Errorf("json domains %+v, expected com with 4 customers in 2 domains first", report.Domains)
+	}
+	if report.Summary.DistinctDomains != 4 {
+		t.Errorf("Distinct domains: %d, expected: 4", report.Summary.DistinctDomains)
+	}
+}
+
+func TestWriteTo_Text(t *testing.T) {
+	testCases := []struct {
+		name           string
+		domainsCount   DomainsCount
+		opts           OutputOptions
+		expectedOutput string
+	}{
+		{
+			name: "valid_domains_count",
+			domainsCount: DomainsCount{DomainStats: []DomainStat{
+				{
+					Name:  "acquirethisname.com",
+					Count: 1,
+				},
+				{
+					Name:  "cnet.com",
+					Count: 1,
+				},
+				{
+					Name:  "github.io",
+					Count: 3,
+				},
+			},
+				TotalCount: 5,
+			},
+			expectedOutput: `Total number of customers: 5
+Domain: acquirethisname.com, Customers: 1
+Domain: cnet.com, Customers: 1
+Domain: github.io, Customers: 3` + "\n",
+		},
+		{
+			name:           "empty_domains_count",
+			domainsCount:   DomainsCount{},
+			expectedOutput: "Total number of customers: 0\n",
+		},
+		{
+			name:           "explicit_text_format",
+			domainsCount:   DomainsCount{DomainStats: []DomainStat{{Name: "cnet.com", Count: 2}}, TotalCount: 2},
+			opts:           OutputOptions{Format: FORMAT_TEXT},
+			expectedOutput: "Total number of customers: 2\nDomain: cnet.com, Customers: 2\n",
+		},
+		{
+			name:           "sample",
+			domainsCount:   DomainsCount{DomainStats: []DomainStat{{Name: "cnet.com", Count: 2}}, TotalCount: 2, SampleRows: 2},
+			expectedOutput: "Total number of customers: 2\nSample of the first 2 rows\nDomain: cnet.com, Customers: 2\n",
+		},
+		{
+			name: "aligned",
+			domainsCount: DomainsCount{DomainStats: []DomainStat{
+				{Name: "acquirethisname.com", Count: 1},
+				{Name: "github.io", Count: 12, Disposable: true},
+			},
+				TotalCount: 13,
+			},
+			opts: OutputOptions{Align: true},
+			expectedOutput: `Total number of customers: 13
+Domain: acquirethisname.com, Customers:  1
+Domain: github.io,           Customers: 12 [disposable]` + "\n",
+		},
+		{
+			name: "aligned_role_counts",
+			domainsCount: DomainsCount{DomainStats: []DomainStat{
+				{Name: "cnet.com", Count: 100, RoleCount: 1},
+				{Name: "zoho.com", Count: 2},
+			},
+				TotalCount: 102,
+			},
+			opts: OutputOptions{Align: true, RoleCounts: true},
+			expectedOutput: `Total number of customers: 102
+Domain: cnet.com, Customers: 100, Role accounts: 1, Personal: 99
+Domain: zoho.com, Customers:   2, Role accounts: 0, Personal: 2` + "\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteTo(&buf, tc.domainsCount, tc.opts); err != nil {
+				t.Fatalf("unexpected error occured: %v", err)
+			}
+			if buf.String() != tc.expectedOutput {
+				t.Errorf("output %s, expected: %s", buf.String(), tc.expectedOutput)
+			}
+		})
+	}
+}
+
+func TestWriteTo_UnsupportedFormat(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteTo(&buf, DomainsCount{}, OutputOptions{Format: "xml"})
+	expectedError := "unsupported format: xml (supported: " + strings.Join(FORMATS, ", ") + ")"
+	if err == nil || err.Error() != expectedError {
+		t.Errorf("error: %v, expected: %s", err, expectedError)
+	}
+}
+
+// failingWriter accepts limit bytes and then fails every write, like a full disk.
+type failingWriter struct {
+	limit int
+}
+
+var errDiskFull = errors.New("no space left on device")
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > w.limit {
+		n := w.limit
+		w.limit = 0
+		return n, errDiskFull
+	}
+	w.limit -= len(p)
+	return len(p), nil
+}
+
+// TestWriteTo_WriteError checks that every format keeps the error of the
+// writer in the chain; FORMAT_YAML is only among FORMATS with -tags yaml.
+func TestWriteTo_WriteError(t *testing.T) {
+	domainsCount := DomainsCount{
+		TotalCount:  3,
+		DomainStats: []DomainStat{{Name: "cnet.com", Count: 2}, {Name: "github.io", Count: 1}},
+	}
+	for _, format := range FORMATS {
+		t.Run(format, func(t *testing.T) {
+			err := WriteTo(&failingWriter{limit: 4}, domainsCount, OutputOptions{Format: format})
+			if !errors.Is(err, errDiskFull) {
+				t.Errorf("error: %v, expected: %v", err, errDiskFull)
+			}
+		})
+	}
+}
+
+func TestValidateFormat(t *testing.T) {
+	testCases := []struct {
+		format      string
+		expectError bool
+	}{
+		{format: ""},
+		{format: FORMAT_TEXT},
+		{format: FORMAT_JSON},
+		{format: FORMAT_CSV},
+		{format: "xml", expectError: true},
+		{format: "JSON", expectError: true},
+	}
+
+	for _, tc := range testCases {
+		err := ValidateFormat(tc.format)
+		if tc.expectError && err == nil {
+			t.Errorf("ValidateFormat(%q): error expected, got nil", tc.format)
+		}
+		if !tc.expectError && err != nil {
+			t.Errorf("ValidateFormat(%q): unexpected error occured: %v", tc.format, err)
+		}
+	}
+}
+
+func TestWriteTo_Bars(t *testing.T) {
+	domainsCount := DomainsCount{DomainStats: []DomainStat{
+		{Name: "cnet.com", Count: 1},
+		{Name: "github.io", Count: 3},
+		{Name: "zoho.com", Count: 1},
+	},
+		TotalCount: 5,
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTo(&buf, domainsCount, OutputOptions{Bars: true, Width: 40}); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+
+	// 40 columns leave 21 for the bars next to the names, counts and percents.
+	expectedOutput := "Total number of customers: 5\n" +
+		"cnet.com   1  " + strings.Repeat(BAR_CHAR, 7) + " 20%\n" +
+		"github.io  3  " + strings.Repeat(BAR_CHAR, 21) + " 60%\n" +
+		"zoho.com   1  " + strings.Repeat(BAR_CHAR, 7) + " 20%\n"
+	if buf.String() != expectedOutput {
+		t.Errorf("output %s, expected: %s", buf.String(), expectedOutput)
+	}
+}
+
+var update = flag.Bool("update", false, "Rewrite the golden files in testdata/golden")
+
+func TestWriteTo_Golden(t *testing.T) {
+	testCases := []struct {
+		name string
+		opts []Option
+	}{
+		{name: "domain"},
+		{name: "natural", opts: []Option{WithNaturalSort(true)}},
+		{name: "tld", opts: []Option{WithGroupBy(GROUP_BY_TLD)}},
+		{name: "registered", opts: []Option{WithGroupBy(GROUP_BY_REGISTERED)}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			goldenPath := filepath.Join("testdata", "golden", tc.name+".txt")
+			for _, workers := range []int{1, 8} {
+				domainsCount, err := NewImporter(append(tc.opts, WithWorkers(workers))...).ImportFile(filepath.Join("testdata", "customers.csv"))
+				if err != nil {
+					t.Fatalf("unexpected error occured: %v", err)
+				}
+				var buf bytes.Buffer
+				if err := WriteTo(&buf, *domainsCount, OutputOptions{}); err != nil {
+					t.Fatalf("unexpected error occured: %v", err)
+				}
+
+				if *update {
+					if err := os.WriteFile(goldenPath, buf.Bytes(), 0644); err != nil {
+						t.Fatalf("unexpected error occured: %v", err)
+					}
+				}
+				golden, err := os.ReadFile(goldenPath)
+				if err != nil {
+					t.Fatalf("unexpected error occured: %v", err)
+				}
+				if !bytes.Equal(buf.Bytes(), golden) {
+					t.Errorf("workers %d: output %s, expected: %s", workers, buf.String(), golden)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteTo_NormalizePer(t *testing.T) {
+	domainsCount := DomainsCount{DomainStats: []DomainStat{
+		{Name: "cnet.com", Count: 1},
+		{Name: "github.io", Count: 2},
+	},
+		TotalCount: 3,
+	}
+	opts := OutputOptions{NormalizePer: 10000}
+
+	var buf bytes.Buffer
+	if err := WriteTo(&buf, domainsCount, opts); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	expectedOutput := "Total number of customers: 3\n" +
+		"Domain: cnet.com, Customers per 10000: 3333.33\n" +
+		"Domain: github.io, Customers per 10000: 6666.67\n"
+	if buf.String() != expectedOutput {
+		t.Errorf("output %s, expected: %s", buf.String(), expectedOutput)
+	}
+
+	buf.Reset()
+	opts.Format = FORMAT_JSON
+	if err := WriteTo(&buf, domainsCount, opts); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	for _, expected := range []string{`"normalize_per": 10000`, `"normalized": 6666.67`} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("output %s, expected: %s", buf.String(), expected)
+		}
+	}
+}
+
+func TestWriteTo_Cumulative(t *testing.T) {
+	domainsCount := DomainsCount{DomainStats: []DomainStat{
+		{Name: "cnet.com", Count: 1},
+		{Name: "github.io", Count: 5},
+		{Name: "hubpages.com", Count: 2},
+		{Name: "zoho.com", Count: 2},
+	},
+		TotalCount: 10,
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTo(&buf, domainsCount, OutputOptions{Cumulative: true}); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	expectedOutput := "Total number of customers: 10\n" +
+		"Domain: github.io, Customers: 5, Cumulative: 50%\n" +
+		"Domain: hubpages.com, Customers: 2, Cumulative: 70%\n" +
+		"Domain: zoho.com, Customers: 2, Cumulative: 90%\n" +
+		"Domain: cnet.com, Customers: 1, Cumulative: 100%\n"
+	if buf.String() != expectedOutput {
+		t.Errorf("output %s, expected: %s", buf.String(), expectedOutput)
+	}
+	if domainsCount.DomainStats[0].Name != "cnet.com" {
+		t.Errorf("Domain stats reordered in place: %v", domainsCount.DomainStats)
+	}
+
+	buf.Reset()
+	if err := WriteTo(&buf, domainsCount, OutputOptions{Format: FORMAT_JSON, Cumulative: true}); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"cumulative_percent": 70`) {
+		t.Errorf("output %s, expected the cumulative percent", buf.String())
+	}
+}
+
+func TestWriteStatLine(t *testing.T) {
+	domainStat := DomainStat{Name: "github.io", Count: 3, RoleCount: 1, Disposable: true}
+	for _, opts := range []OutputOptions{{}, {RoleCounts: true}, {NamesOnly: true}} {
+		var line, report bytes.Buffer
+		if err := WriteStatLine(&line, domainStat, opts); err != nil {
+			t.Fatalf("unexpected error occured: %v", err)
+		}
+		if err := WriteTo(&report, DomainsCount{DomainStats: []DomainStat{domainStat}, TotalCount: 3}, opts); err != nil {
+			t.Fatalf("unexpected error occured: %v", err)
+		}
+		if !strings.HasSuffix(report.String(), line.String()) {
+			t.Errorf("line %q, expected the last line of: %q", line.String(), report.String())
+		}
+	}
+}
@@ -0,0 +1,68 @@
+//go:build yaml
+
+package customerimporter
+
+import (
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+func init() {
+	FORMATS = append(FORMATS, FORMAT_YAML)
+	writeYAML = encodeYAML
+}
+
+type yamlReport struct {
+	Total   int          `yaml:"total"`
+	Domains []yamlDomain `yaml:"domains"`
+}
+
+type yamlDomain struct {
+	Domain     string       `yaml:"domain"`
+	Count      int          `yaml:"count"`
+	Subdomains []yamlDomain `yaml:"subdomains,omitempty"`
+}
+
+// encodeYAML writes domainsCount as FORMAT_YAML. yaml.v3 reports write
+// failures as a message of its own, so the error of w is returned instead,
+// for callers to match like that of the other formats.
+func encodeYAML(w io.Writer, domainsCount DomainsCount) error {
+	writer := &errorWriter{writer: w}
+	encoder := yaml.NewEncoder(writer)
+	encoder.SetIndent(2)
+	err := encoder.Encode(yamlReport{Total: domainsCount.TotalCount, Domains: yamlDomains(domainsCount.DomainStats)})
+	if err == nil {
+		err = encoder.Close()
+	}
+	if writer.err != nil {
+		return writer.err
+	}
+	return err
+}
+
+// errorWriter passes writes on to writer, keeping the first error.
+type errorWriter struct {
+	writer io.Writer
+	err    error
+}
+
+func (w *errorWriter) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+	return n, err
+}
+
+func yamlDomains(domainStats []DomainStat) []yamlDomain {
+	domains := make([]yamlDomain, 0, len(domainStats))
+	for _, domainStat := range domainStats {
+		domain := yamlDomain{Domain: domainStat.Name, Count: domainStat.Count}
+		if len(domainStat.Subdomains) > 0 {
+			domain.Subdomains = yamlDomains(domainStat.Subdomains)
+		}
+		domains = append(domains, domain)
+	}
+	return domains
+}
@@ -0,0 +1,38 @@
+//go:build yaml
+
+package customerimporter
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteTo_YAML(t *testing.T) {
+	domainsCount := DomainsCount{DomainStats: []DomainStat{
+		{Name: "github.io", Count: 3},
+		{Name: "zoho.com", Count: 1},
+	},
+		TotalCount: 4,
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTo(&buf, domainsCount, OutputOptions{Format: FORMAT_YAML}); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+
+	expectedOutput := "total: 4\n" +
+		"domains:\n" +
+		"  - domain: github.io\n" +
+		"    count: 3\n" +
+		"  - domain: zoho.com\n" +
+		"    count: 1\n"
+	if buf.String() != expectedOutput {
+		t.Errorf("output %s, expected: %s", buf.String(), expectedOutput)
+	}
+}
+
+func TestValidateFormat_YAML(t *testing.T) {
+	if err := ValidateFormat(FORMAT_YAML); err != nil {
+		t.Errorf("unexpected error occured: %v", err)
+	}
+}
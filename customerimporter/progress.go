@@ -0,0 +1,96 @@
+package customerimporter
+
+import (
+	"io"
+	"io/fs"
+	"sync/atomic"
+)
+
+const PROGRESS_INTERVAL = 10000
+
+// Progress describes how far an import got. TotalBytes is -1 when the input
+// size is unknown, e.g. for stdin, pipes or URLs without a Content-Length, in
+// which case only Rows is meaningful.
+type Progress struct {
+	Rows       int
+	BytesRead  int64
+	TotalBytes int64
+	Done       bool
+}
+
+// Percent returns the share of the input read so far, from 0 to 100, and
+// false when the input size is unknown.
+func (p Progress) Percent() (float64, bool) {
+	if p.TotalBytes <= 0 {
+		return 0, false
+	}
+	percent := float64(p.BytesRead) * 100 / float64(p.TotalBytes)
+	return min(percent, 100), true
+}
+
+// countingReader counts the bytes read through it. The count may be read from
+// other goroutines while reading is in progress.
+type countingReader struct {
+	reader io.Reader
+	read   atomic.Int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.read.Add(int64(n))
+	return n, err
+}
+
+type progressTracker struct {
+	counter    *countingReader
+	totalBytes int64
+	report     func(Progress)
+}
+
+func newProgressTracker(reader io.Reader, report func(Progress)) *progressTracker {
+	return &progressTracker{
+		counter:    &countingReader{reader: reader},
+		totalBytes: inputSize(reader),
+		report:     report,
+	}
+}
+
+func (t *progressTracker) update(rows int, done bool) {
+	if t == nil {
+		return
+	}
+	t.report(Progress{
+		Rows:       rows,
+		BytesRead:  t.counter.read.Load(),
+		TotalBytes: t.totalBytes,
+		Done:       done,
+	})
+}
+
+// inputSize returns the size in bytes of reader when it is a regular file or
+// knows its size, and -1 otherwise.
+func inputSize(reader io.Reader) int64 {
+	switch r := reader.(type) {
+	case interface{ Stat() (fs.FileInfo, error) }:
+		info, err := r.Stat()
+		if err != nil || !info.Mode().IsRegular() {
+			return -1
+		}
+		return info.Size()
+	case interface{ Size() int64 }:
+		return r.Size()
+	default:
+		return -1
+	}
+}
+
+// sizedReadCloser attaches a known size, such as a Content-Length, to a
+// reader.
+type sizedReadCloser struct {
+	io.ReadCloser
+	size int64
+}
+
+func (r sizedReadCloser) Size() int64 {
+	return r.size
+}
@@ -0,0 +1,82 @@
+package customerimporter
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestImporter_Progress(t *testing.T) {
+	csvInput := generateCsv(2*PROGRESS_INTERVAL+500, 10)
+
+	file, err := os.CreateTemp("", "csvTestFile_*.csv")
+	if err != nil {
+		t.Fatalf("Error creating temp file: %v", err)
+	}
+	defer file.Close()
+	defer os.Remove(file.Name())
+	if _, err := file.WriteString(csvInput); err != nil {
+		t.Fatalf("error writing to file: %v", err)
+	}
+
+	testCases := []struct {
+		name      string
+		reader    func() io.Reader
+		knownSize bool
+	}{
+		{
+			name: "regular_file",
+			reader: func() io.Reader {
+				file.Seek(0, io.SeekStart)
+				return file
+			},
+			knownSize: true,
+		},
+		{
+			name: "unknown_size",
+			reader: func() io.Reader {
+				return io.MultiReader(strings.NewReader(csvInput))
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var reports []Progress
+			imp := NewImporter(WithProgress(func(p Progress) {
+				reports = append(reports, p)
+			}))
+
+			if _, err := imp.Import(tc.reader()); err != nil {
+				t.Fatalf("unexpected error occured: %v", err)
+			}
+
+			if len(reports) != 3 {
+				t.Fatalf("progress reports: %d, expected: 3", len(reports))
+			}
+			last := reports[len(reports)-1]
+			if !last.Done || last.Rows != 2*PROGRESS_INTERVAL+500 {
+				t.Errorf("last progress: %+v, expected done after %d rows", last, 2*PROGRESS_INTERVAL+500)
+			}
+
+			percent, ok := last.Percent()
+			if ok != tc.knownSize {
+				t.Fatalf("percent known: %v, expected: %v", ok, tc.knownSize)
+			}
+			if ok && percent != 100 {
+				t.Errorf("final percent: %v, expected: 100", percent)
+			}
+
+			previous := -1.0
+			for _, report := range reports {
+				if percent, ok := report.Percent(); ok {
+					if percent < previous {
+						t.Errorf("percent went backwards: %v after %v", percent, previous)
+					}
+					previous = percent
+				}
+			}
+		})
+	}
+}
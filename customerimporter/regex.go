@@ -0,0 +1,23 @@
+package customerimporter
+
+import (
+	"regexp"
+	"strings"
+)
+
+// WithMatchRegex only counts domains that re matches, e.g. `\.edu$` for all
+// .edu domains. It is a WithDomainFilter matched against the lowercased
+// domain.
+func WithMatchRegex(re *regexp.Regexp) Option {
+	return WithDomainFilter(func(domain string) bool {
+		return re.MatchString(strings.ToLower(domain))
+	})
+}
+
+// WithExcludeRegex skips domains that re matches, like WithMatchRegex with
+// the match inverted.
+func WithExcludeRegex(re *regexp.Regexp) Option {
+	return WithDomainFilter(func(domain string) bool {
+		return !re.MatchString(strings.ToLower(domain))
+	})
+}
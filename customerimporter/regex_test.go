@@ -0,0 +1,65 @@
+package customerimporter
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestImporter_Regex(t *testing.T) {
+	csvInput := "email\na@mit.edu\nb@mail.cnet.com\nc@Stanford.EDU\nd@github.io\ne@mail.mit.edu\n"
+
+	testCases := []struct {
+		name          string
+		opts          []Option
+		expectedStats []DomainStat
+	}{
+		{
+			name: "match",
+			opts: []Option{WithMatchRegex(regexp.MustCompile(`\.edu$`))},
+			expectedStats: []DomainStat{
+				{Name: "mail.mit.edu", Count: 1, FirstSeenLine: 6},
+				{Name: "mit.edu", Count: 1, FirstSeenLine: 2},
+				{Name: "stanford.edu", Count: 1, FirstSeenLine: 4},
+			},
+		},
+		{
+			name: "exclude",
+			opts: []Option{WithExcludeRegex(regexp.MustCompile(`^mail\.`))},
+			expectedStats: []DomainStat{
+				{Name: "github.io", Count: 1, FirstSeenLine: 5},
+				{Name: "mit.edu", Count: 1, FirstSeenLine: 2},
+				{Name: "stanford.edu", Count: 1, FirstSeenLine: 4},
+			},
+		},
+		{
+			name: "match_and_exclude",
+			opts: []Option{WithMatchRegex(regexp.MustCompile(`\.edu$`)), WithExcludeRegex(regexp.MustCompile(`^mail\.`))},
+			expectedStats: []DomainStat{
+				{Name: "mit.edu", Count: 1, FirstSeenLine: 2},
+				{Name: "stanford.edu", Count: 1, FirstSeenLine: 4},
+			},
+		},
+		{
+			name: "case_sensitive_input",
+			opts: []Option{WithCaseSensitive(true), WithMatchRegex(regexp.MustCompile(`^stanford\.edu$`))},
+			expectedStats: []DomainStat{
+				{Name: "Stanford.EDU", Count: 1, FirstSeenLine: 4},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := append([]Option{WithEmailColumn(0)}, tc.opts...)
+			domainsCount, err := NewImporter(opts...).Import(strings.NewReader(csvInput))
+			if err != nil {
+				t.Fatalf("unexpected error occured: %v", err)
+			}
+			if !reflect.DeepEqual(domainsCount.DomainStats, tc.expectedStats) {
+				t.Errorf("domain stats %v, expected: %v", domainsCount.DomainStats, tc.expectedStats)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package customerimporter
+
+import "io"
+
+// Diagnostics returns the Warnings of the import as errors wrapping
+// ErrSkippedRow, for callers that handle them like other errors.
+func (dc *DomainsCount) Diagnostics() []error {
+	diagnostics := make([]error, 0, len(dc.Warnings))
+	for _, warning := range dc.Warnings {
+		diagnostics = append(diagnostics, warning)
+	}
+	return diagnostics
+}
+
+// ImportWithReport is Import that also returns the non-fatal problems of the
+// import, the rows it skipped, as errors, so callers don't need to parse the
+// log for them; pair it with WithLogger to silence the log. Only the first
+// MAX_WARNINGS are returned, WarningCounts counts them all. The error is only
+// set when the import failed.
+func (imp *Importer) ImportWithReport(reader io.Reader) (*DomainsCount, []error, error) {
+	domainsCount, err := imp.Import(reader)
+	if err != nil {
+		return domainsCount, nil, err
+	}
+	return domainsCount, domainsCount.Diagnostics(), nil
+}
+
+// ImportFileWithReport is ImportFile returning the diagnostics of
+// ImportWithReport.
+func (imp *Importer) ImportFileWithReport(path string) (*DomainsCount, []error, error) {
+	domainsCount, err := imp.ImportFile(path)
+	if err != nil {
+		return domainsCount, nil, err
+	}
+	return domainsCount, domainsCount.Diagnostics(), nil
+}
+
+// FileReport is the outcome of one input of ImportFilesWithReport: the
+// customers it added and the rows it skipped, or Err when it failed.
+type FileReport struct {
+	Path      string
+	Customers int
+	Skipped   int
+	Err       error
+}
+
+// ImportFilesWithReport is ImportFiles that also returns a FileReport per
+// path, in order. A file that fails doesn't fail the import; its error is in
+// its report and the result sums the other files. The error is only set
+// when the import failed as a whole, e.g. for invalid options.
+func (imp *Importer) ImportFilesWithReport(paths ...string) (*DomainsCount, []FileReport, error) {
+	reports := make([]FileReport, 0, len(paths))
+	domainsCount, err := imp.importFiles(paths, &reports)
+	if err != nil {
+		return domainsCount, nil, err
+	}
+	return domainsCount, reports, nil
+}
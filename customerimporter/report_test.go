@@ -0,0 +1,86 @@
+package customerimporter
+
+import (
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestImportFileWithReport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "customers.csv")
+	csvInput := "email,name\nmhernandez0@github.io,M\nbortiz1cyberchimps.com,B\n\ndhenry2@cnet.com,D\n,N\n"
+	if err := os.WriteFile(path, []byte(csvInput), 0644); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+
+	imp := NewImporter(WithEmailColumn(0), WithLogger(log.New(io.Discard, "", 0)))
+	domainsCount, diagnostics, err := imp.ImportFileWithReport(path)
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	if domainsCount.TotalCount != 2 {
+		t.Errorf("Total count: %d, expected: 2", domainsCount.TotalCount)
+	}
+
+	expected := []string{`line 3: bad_email "bortiz1cyberchimps.com"`, "line 6: empty_email"}
+	if len(diagnostics) != len(expected) {
+		t.Fatalf("Diagnostics: %v, expected: %v", diagnostics, expected)
+	}
+	for i, diagnostic := range diagnostics {
+		if diagnostic.Error() != expected[i] {
+			t.Errorf("Diagnostic: %q, expected: %q", diagnostic.Error(), expected[i])
+		}
+		if !errors.Is(diagnostic, ErrSkippedRow) {
+			t.Errorf("Diagnostic %v doesn't wrap ErrSkippedRow", diagnostic)
+		}
+		var warning Warning
+		if !errors.As(diagnostic, &warning) {
+			t.Errorf("Diagnostic %v isn't a Warning", diagnostic)
+		}
+	}
+
+	if _, _, err := imp.ImportFileWithReport(filepath.Join(t.TempDir(), "missing.csv")); err == nil {
+		t.Error("error expected for a missing file, got nil")
+	}
+}
+
+func TestImportFilesWithReport(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.csv")
+	second := filepath.Join(dir, "second.csv")
+	missing := filepath.Join(dir, "missing.csv")
+	os.WriteFile(first, []byte("email\na@github.io\nb@cnet.com\n"), 0644)
+	os.WriteFile(second, []byte("email\nc@github.io\ninvalid\n"), 0644)
+
+	imp := NewImporter(WithEmailColumn(0), WithLogger(log.New(io.Discard, "", 0)))
+	domainsCount, reports, err := imp.ImportFilesWithReport(first, missing, second)
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	if domainsCount.TotalCount != 3 {
+		t.Errorf("Total count: %d, expected: 3", domainsCount.TotalCount)
+	}
+
+	if len(reports) != 3 {
+		t.Fatalf("Reports: %v, expected: 3", reports)
+	}
+	if reports[0] != (FileReport{Path: first, Customers: 2}) {
+		t.Errorf("report %v, expected: %v", reports[0], FileReport{Path: first, Customers: 2})
+	}
+	if reports[1].Path != missing || !errors.Is(reports[1].Err, os.ErrNotExist) {
+		t.Errorf("report %v, expected a not exist error for %s", reports[1], missing)
+	}
+	if reports[2] != (FileReport{Path: second, Customers: 1, Skipped: 1}) {
+		t.Errorf("report %v, expected: %v", reports[2], FileReport{Path: second, Customers: 1, Skipped: 1})
+	}
+}
+
+func TestWarning_Error(t *testing.T) {
+	warning := Warning{Category: WARNING_SHORT_ROW, Line: 4, Input: "customers.csv"}
+	if warning.Error() != "customers.csv:4: short_row" {
+		t.Errorf("Error: %q, expected: %q", warning.Error(), "customers.csv:4: short_row")
+	}
+}
@@ -0,0 +1,53 @@
+package customerimporter
+
+// Sink receives the result of an import, e.g. to write it to a file or to push
+// it to a database or queue.
+type Sink interface {
+	Write(domainsCount DomainsCount) error
+}
+
+// SinkFunc adapts a function to a Sink.
+type SinkFunc func(domainsCount DomainsCount) error
+
+func (f SinkFunc) Write(domainsCount DomainsCount) error {
+	return f(domainsCount)
+}
+
+// FileSink writes the result to the file at Path as configured by Options.
+type FileSink struct {
+	Path    string
+	Options OutputOptions
+}
+
+func (s FileSink) Write(domainsCount DomainsCount) error {
+	return writeFile(domainsCount, &s.Path, s.Options)
+}
+
+// StdoutSink writes the result to Options.Stdout, or os.Stdout when nil.
+type StdoutSink struct {
+	Options OutputOptions
+}
+
+func (s StdoutSink) Write(domainsCount DomainsCount) error {
+	return writeStdOut(domainsCount, s.Options)
+}
+
+// NewOutputSink returns the sink WriteOutput writes to: a FileSink for a
+// filePath and a StdoutSink when it is empty.
+func NewOutputSink(filePath string, opts OutputOptions) Sink {
+	if filePath != "" {
+		return FileSink{Path: filePath, Options: opts}
+	}
+	return StdoutSink{Options: opts}
+}
+
+// WriteSinks writes domainsCount to every sink in order, stopping at the first
+// error.
+func WriteSinks(domainsCount DomainsCount, sinks ...Sink) error {
+	for _, sink := range sinks {
+		if err := sink.Write(domainsCount); err != nil {
+			return err
+		}
+	}
+	return nil
+}
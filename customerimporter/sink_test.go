@@ -0,0 +1,47 @@
+package customerimporter
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteSinks(t *testing.T) {
+	domainsCount := DomainsCount{DomainStats: []DomainStat{{Name: "github.io", Count: 2}}, TotalCount: 2}
+	filePath := filepath.Join(t.TempDir(), "result.csv")
+	var stdout bytes.Buffer
+	var pushed []DomainsCount
+	errStop := errors.New("stop")
+
+	sinks := []Sink{
+		NewOutputSink(filePath, OutputOptions{Format: FORMAT_CSV}),
+		NewOutputSink("", OutputOptions{Stdout: &stdout}),
+		SinkFunc(func(domainsCount DomainsCount) error {
+			pushed = append(pushed, domainsCount)
+			return errStop
+		}),
+		SinkFunc(func(DomainsCount) error {
+			t.Error("sink after a failed one written")
+			return nil
+		}),
+	}
+	if err := WriteSinks(domainsCount, sinks...); !errors.Is(err, errStop) {
+		t.Errorf("expected the sink error, got: %v", err)
+	}
+
+	fileContents, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("error reading the contents of output file: %v", err)
+	}
+	if expected := "domain,customers\ngithub.io,2\n"; string(fileContents) != expected {
+		t.Errorf("file contents %q, expected: %q", fileContents, expected)
+	}
+	if expected := "Total number of customers: 2\nDomain: github.io, Customers: 2\n"; stdout.String() != expected {
+		t.Errorf("stdout %q, expected: %q", stdout.String(), expected)
+	}
+	if len(pushed) != 1 || pushed[0].TotalCount != 2 {
+		t.Errorf("pushed %v, expected the result once", pushed)
+	}
+}
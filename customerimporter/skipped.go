@@ -0,0 +1,113 @@
+package customerimporter
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+const SKIP_SUMMARY_FORMAT = "Skipped %d rows (%d bad email, %d empty email, %d long email, %d short row, %d malformed)\n"
+const INVALID_EMAIL_FORMAT = "Invalid email address %q, doesn't contain domain name\n"
+const SUPPRESSED_INVALID_FORMAT = "Not logged %d more invalid email addresses\n"
+
+// INVALID_EMAIL_LOG_LIMIT is the number of invalid emails logged one by one
+// before only their number is logged at the end, so that a dirty file doesn't
+// flood the log.
+const INVALID_EMAIL_LOG_LIMIT = 100
+
+// SHORT_ROW_FORMAT describes a row without the email column by the column
+// index and the number of fields of the row.
+const SHORT_ROW_FORMAT = "email column %d, but the row has %d fields"
+
+// ErrNoValidEmails is returned, wrapped, when none of the emails read has a
+// domain, which usually means the wrong column was chosen. WithAllowEmpty
+// returns the empty result instead.
+var ErrNoValidEmails = errors.New("no valid email addresses found")
+
+// MAX_LOGGED_EMAIL_LENGTH is the number of characters of an invalid email
+// that are logged.
+const MAX_LOGGED_EMAIL_LENGTH = 64
+
+// skipCounts tallies the rows skipped during an import by reason. It is
+// shared by the reading goroutine and the workers.
+type skipCounts struct {
+	badEmail   atomic.Int64
+	emptyEmail atomic.Int64
+	longEmail  atomic.Int64
+	shortRow   atomic.Int64
+	malformed  atomic.Int64
+	invalid    atomic.Int64
+	// valid counts the emails with a domain, which aren't skipped.
+	valid atomic.Int64
+	// sample is the first invalid email that isn't empty.
+	sample     string
+	sampleOnce sync.Once
+	// shortSample describes the first row too short for the email column.
+	shortSample string
+	shortOnce   sync.Once
+	// explained counts the rows of the WithExplain target.
+	explained atomic.Int64
+	recorded  atomic.Int64
+	mu        sync.Mutex
+	warnings  []Warning
+}
+
+// addInvalid counts an email without a domain on line and reports whether it
+// is among the first INVALID_EMAIL_LOG_LIMIT to be logged.
+func (s *skipCounts) addInvalid(email string, line int) bool {
+	if email == "" {
+		s.warn(WARNING_EMPTY_EMAIL, line, "")
+	} else {
+		s.warn(WARNING_BAD_EMAIL, line, email)
+		// Only read once the workers are done.
+		s.sampleOnce.Do(func() { s.sample = email })
+	}
+	return s.invalid.Add(1) <= INVALID_EMAIL_LOG_LIMIT
+}
+
+// addShortRow counts a row on line that lacks the email column, with reason
+// describing the email column and the row, like "email column 2, but the row
+// has 1 fields".
+func (s *skipCounts) addShortRow(line int, reason string) {
+	s.warn(WARNING_SHORT_ROW, line, "")
+	s.shortOnce.Do(func() { s.shortSample = fmt.Sprintf("line %d: %s", line, reason) })
+}
+
+// suppressed returns the number of invalid emails that weren't logged.
+func (s *skipCounts) suppressed() int64 {
+	return max(s.invalid.Load()-INVALID_EMAIL_LOG_LIMIT, 0)
+}
+
+// truncateEmail shortens email to MAX_LOGGED_EMAIL_LENGTH characters for
+// logging.
+func truncateEmail(email string) string {
+	if utf8.RuneCountInString(email) <= MAX_LOGGED_EMAIL_LENGTH {
+		return email
+	}
+	return string([]rune(email)[:MAX_LOGGED_EMAIL_LENGTH]) + "..."
+}
+
+// noValidEmails returns ErrNoValidEmails, wrapped with a sample, when rows
+// were read but none of them had an email with a domain, be it that all rows
+// were too short for the email column or their emails were invalid.
+func (s *skipCounts) noValidEmails() error {
+	invalid := s.badEmail.Load() + s.emptyEmail.Load() + s.longEmail.Load()
+	short := s.shortRow.Load()
+	if invalid+short == 0 || s.valid.Load() > 0 {
+		return nil
+	}
+	if invalid == 0 {
+		return fmt.Errorf("%w, all %d rows lack the email column, it may be misconfigured (%s)", ErrNoValidEmails, short, s.shortSample)
+	}
+	return fmt.Errorf("%w in %d rows, the email column may be misconfigured (first value: %q)", ErrNoValidEmails, invalid, truncateEmail(s.sample))
+}
+
+func (s *skipCounts) total() int64 {
+	return s.badEmail.Load() + s.emptyEmail.Load() + s.longEmail.Load() + s.shortRow.Load() + s.malformed.Load()
+}
+
+func (s *skipCounts) summary() string {
+	return fmt.Sprintf(SKIP_SUMMARY_FORMAT, s.total(), s.badEmail.Load(), s.emptyEmail.Load(), s.longEmail.Load(), s.shortRow.Load(), s.malformed.Load())
+}
@@ -0,0 +1,136 @@
+package customerimporter
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestProcessCsv_SkipSummary(t *testing.T) {
+	csvInput := `first_name,last_name,email
+Mildred,Hernandez,mhernandez0@github.io
+Bonnie,Ortiz,bortiz1cyberchimps.com
+Dennis,Henry,
+Norma,Allen,
+Short,Row
+"unterminated,Allen,nallen8@cnet.com`
+
+	var logs bytes.Buffer
+	imp := NewImporter(WithWorkers(2), WithLogger(log.New(&logs, "", 0)))
+	if _, err := imp.processCsv(strings.NewReader(csvInput)); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+
+	expectedSummary := "Skipped 5 rows (1 bad email, 2 empty email, 0 long email, 1 short row, 1 malformed)\n"
+	if !strings.HasSuffix(logs.String(), expectedSummary) {
+		t.Errorf("logs %q, expected to end with: %q", logs.String(), expectedSummary)
+	}
+}
+
+func TestProcessCsv_NoSkipSummaryWhenClean(t *testing.T) {
+	csvInput := `first_name,last_name,email
+Mildred,Hernandez,mhernandez0@github.io`
+
+	var logs bytes.Buffer
+	imp := NewImporter(WithLogger(log.New(&logs, "", 0)))
+	if _, err := imp.processCsv(strings.NewReader(csvInput)); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	if strings.Contains(logs.String(), "Skipped") {
+		t.Errorf("expected no skip summary, got: %s", logs.String())
+	}
+}
+
+func TestProcessCsv_InvalidEmailLogLimit(t *testing.T) {
+	var csvInput strings.Builder
+	csvInput.WriteString("first_name,last_name,email\n")
+	csvInput.WriteString("Mildred,Hernandez,mhernandez0@github.io\n")
+	for range INVALID_EMAIL_LOG_LIMIT + 5 {
+		csvInput.WriteString("Bonnie,Ortiz,bortiz1cyberchimps.com\n")
+	}
+	csvInput.WriteString("Norma,Allen," + strings.Repeat("x", 2*MAX_LOGGED_EMAIL_LENGTH) + "\n")
+
+	var logs bytes.Buffer
+	imp := NewImporter(WithWorkers(4), WithLogger(log.New(&logs, "", 0)))
+	if _, err := imp.processCsv(strings.NewReader(csvInput.String())); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+
+	logged := strings.Count(logs.String(), "Invalid email address")
+	if logged != INVALID_EMAIL_LOG_LIMIT {
+		t.Errorf("Logged invalid emails: %d, expected: %d", logged, INVALID_EMAIL_LOG_LIMIT)
+	}
+	if !strings.Contains(logs.String(), `"bortiz1cyberchimps.com"`) {
+		t.Errorf("logs %q, expected the invalid email", logs.String())
+	}
+	if !strings.Contains(logs.String(), "Not logged 6 more invalid email addresses\n") {
+		t.Errorf("logs %q, expected the number of unlogged emails", logs.String())
+	}
+}
+
+func TestTruncateEmail(t *testing.T) {
+	long := strings.Repeat("é", MAX_LOGGED_EMAIL_LENGTH+1)
+	testCases := []struct {
+		email    string
+		expected string
+	}{
+		{email: "bortiz1cyberchimps.com", expected: "bortiz1cyberchimps.com"},
+		{email: long, expected: long[:2*MAX_LOGGED_EMAIL_LENGTH] + "..."},
+	}
+
+	for _, tc := range testCases {
+		if actual := truncateEmail(tc.email); actual != tc.expected {
+			t.Errorf("truncateEmail(%q) = %q; want %q", tc.email, actual, tc.expected)
+		}
+	}
+}
+
+func TestImporter_NoValidEmails(t *testing.T) {
+	csvInput := `email,first_name
+Mildred,mhernandez0@github.io
+,bortiz1@cyberchimps.com
+Dennis,dhenry2@hubpages.com`
+
+	testCases := []struct {
+		name        string
+		opts        []Option
+		expectError bool
+	}{
+		{name: "wrong_column", opts: []Option{WithEmailHeader("email")}, expectError: true},
+		{name: "allow_empty", opts: []Option{WithEmailHeader("email"), WithAllowEmpty(true)}},
+		{name: "right_column", opts: []Option{WithEmailHeader("first_name")}},
+	}
+
+	for _, tc := range testCases {
+		imp := NewImporter(append([]Option{WithLogger(log.New(io.Discard, "", 0))}, tc.opts...)...)
+		_, err := imp.Import(strings.NewReader(csvInput))
+		if tc.expectError {
+			if !errors.Is(err, ErrNoValidEmails) {
+				t.Errorf("%s: error %v, expected: %v", tc.name, err, ErrNoValidEmails)
+			} else if !strings.Contains(err.Error(), `"Mildred"`) {
+				t.Errorf("%s: error %q, expected to contain the sample value", tc.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error occured: %v", tc.name, err)
+		}
+	}
+}
+
+func TestImporter_NoValidEmailsShortRows(t *testing.T) {
+	csvInput := "email\na@github.io\nb@cnet.com"
+
+	for _, fastParse := range []bool{false, true} {
+		imp := NewImporter(WithFastParse(fastParse), WithLogger(log.New(io.Discard, "", 0)))
+		_, err := imp.Import(strings.NewReader(csvInput))
+		if !errors.Is(err, ErrNoValidEmails) {
+			t.Errorf("fast parse %v: error %v, expected: %v", fastParse, err, ErrNoValidEmails)
+		} else if !strings.Contains(err.Error(), "email column 2, but the row has 1 fields") {
+			t.Errorf("fast parse %v: error %q, expected to name the email column", fastParse, err)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package customerimporter
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+// naturalLess orders strings so that runs of digits compare by their numeric
+// value, e.g. "site2.com" before "site10.com". Strings whose runs are
+// numerically equal, such as "a01" and "a1", fall back to plain comparison.
+func naturalLess(a, b string) bool {
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		if isDigit(a[i]) && isDigit(b[j]) {
+			startA, startB := i, j
+			for i < len(a) && isDigit(a[i]) {
+				i++
+			}
+			for j < len(b) && isDigit(b[j]) {
+				j++
+			}
+			numA := strings.TrimLeft(a[startA:i], "0")
+			numB := strings.TrimLeft(b[startB:j], "0")
+			if len(numA) != len(numB) {
+				return len(numA) < len(numB)
+			}
+			if numA != numB {
+				return numA < numB
+			}
+			continue
+		}
+
+		if a[i] != b[j] {
+			return a[i] < b[j]
+		}
+		i++
+		j++
+	}
+
+	if len(a)-i != len(b)-j {
+		return len(a)-i < len(b)-j
+	}
+	return a < b
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// collateStats orders domainStats and their Subdomains by name as the
+// language of tag sorts text, keeping the prior order of names the collation
+// considers equal. A collator is created per call as it isn't safe for
+// concurrent use.
+func collateStats(domainStats []DomainStat, tag language.Tag) {
+	collator := collate.New(tag)
+	var sortLevel func(stats []DomainStat)
+	sortLevel = func(stats []DomainStat) {
+		sort.SliceStable(stats, func(i, j int) bool {
+			return collator.CompareString(stats[i].Name, stats[j].Name) < 0
+		})
+		for i := range stats {
+			sortLevel(stats[i].Subdomains)
+		}
+	}
+	sortLevel(domainStats)
+}
+
+const SORT_BY_COUNT = "count"
+const SORT_BY_NAME = "name"
+
+// SortKey is a field output is ordered by, see ParseSortKeys.
+type SortKey struct {
+	Field string
+	Desc  bool
+}
+
+// ParseSortKeys parses comma-separated field[:asc|:desc] keys, e.g.
+// "count:desc,name:desc", in order of precedence. Fields are SORT_BY_COUNT,
+// descending unless asc is given, and SORT_BY_NAME, ascending unless desc is.
+func ParseSortKeys(spec string) ([]SortKey, error) {
+	var keys []SortKey
+	for _, part := range strings.Split(spec, ",") {
+		field, direction, _ := strings.Cut(strings.TrimSpace(part), ":")
+		key := SortKey{Field: strings.ToLower(field)}
+		switch key.Field {
+		case SORT_BY_COUNT:
+			key.Desc = true
+		case SORT_BY_NAME:
+		default:
+			return nil, fmt.Errorf("unsupported sort key: %s (supported: %s, %s)", field, SORT_BY_COUNT, SORT_BY_NAME)
+		}
+		switch strings.ToLower(direction) {
+		case "":
+		case "asc":
+			key.Desc = false
+		case "desc":
+			key.Desc = true
+		default:
+			return nil, fmt.Errorf("unsupported sort direction: %s (supported: asc, desc)", direction)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// sortStatsBy returns a copy of domainStats, and of their Subdomains,
+// ordered by keys. Stats equal in all keys keep their order.
+func sortStatsBy(domainStats []DomainStat, keys []SortKey) []DomainStat {
+	sorted := slices.Clone(domainStats)
+	slices.SortStableFunc(sorted, func(a, b DomainStat) int {
+		for _, key := range keys {
+			var c int
+			if key.Field == SORT_BY_COUNT {
+				c = cmp.Compare(a.Count, b.Count)
+			} else {
+				c = strings.Compare(a.Name, b.Name)
+			}
+			if key.Desc {
+				c = -c
+			}
+			if c != 0 {
+				return c
+			}
+		}
+		return 0
+	})
+	for i := range sorted {
+		if sorted[i].Subdomains != nil {
+			sorted[i].Subdomains = sortStatsBy(sorted[i].Subdomains, keys)
+		}
+	}
+	return sorted
+}
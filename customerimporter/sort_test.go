@@ -0,0 +1,174 @@
+package customerimporter
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/language"
+)
+
+func TestNaturalLess(t *testing.T) {
+	testCases := []struct {
+		a, b     string
+		expected bool
+	}{
+		{a: "site2.com", b: "site10.com", expected: true},
+		{a: "site10.com", b: "site2.com", expected: false},
+		{a: "a.com", b: "b.com", expected: true},
+		{a: "site1.com", b: "site1.com", expected: false},
+		{a: "site01.com", b: "site1.com", expected: true},
+		{a: "site1.com", b: "site01.com", expected: false},
+		{a: "site9", b: "site9a", expected: true},
+		{a: "10.com", b: "9.com", expected: false},
+		{a: "x1y2.com", b: "x1y10.com", expected: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.a+"_"+tc.b, func(t *testing.T) {
+			if actual := naturalLess(tc.a, tc.b); actual != tc.expected {
+				t.Errorf("naturalLess(%q, %q) = %v; want %v", tc.a, tc.b, actual, tc.expected)
+			}
+		})
+	}
+}
+
+func TestCreateStats_NaturalSort(t *testing.T) {
+	domainMap := map[string]int{"site10.com": 1, "site2.com": 2, "site1.com": 3}
+
+	expectedLexical := []string{"site1.com", "site10.com", "site2.com"}
+	for i, stat := range createStats(domainMap, nil, false) {
+		if stat.Name != expectedLexical[i] {
+			t.Errorf("lexical position %d: %s, expected: %s", i, stat.Name, expectedLexical[i])
+		}
+	}
+
+	expectedNatural := []string{"site1.com", "site2.com", "site10.com"}
+	for i, stat := range createStats(domainMap, nil, true) {
+		if stat.Name != expectedNatural[i] {
+			t.Errorf("natural position %d: %s, expected: %s", i, stat.Name, expectedNatural[i])
+		}
+	}
+}
+
+func TestStatLess_TotalOrder(t *testing.T) {
+	testCases := []struct {
+		a, b        DomainStat
+		naturalSort bool
+		expected    bool
+	}{
+		{a: DomainStat{Name: "a1.com"}, b: DomainStat{Name: "a01.com"}, naturalSort: true, expected: false},
+		{a: DomainStat{Name: "a01.com"}, b: DomainStat{Name: "a1.com"}, naturalSort: true, expected: true},
+		{a: DomainStat{Name: "site2.com"}, b: DomainStat{Name: "site10.com"}, naturalSort: true, expected: true},
+		{a: DomainStat{Name: "site2.com"}, b: DomainStat{Name: "site10.com"}, expected: false},
+		{a: DomainStat{Name: "cnet.com", Count: 1}, b: DomainStat{Name: "cnet.com", Count: 2}, expected: true},
+		{a: DomainStat{Name: "cnet.com", Count: 2}, b: DomainStat{Name: "cnet.com", Count: 2}, expected: false},
+	}
+
+	for _, tc := range testCases {
+		if actual := statLess(tc.a, tc.b, tc.naturalSort); actual != tc.expected {
+			t.Errorf("statLess(%v, %v, %v) = %v; want %v", tc.a, tc.b, tc.naturalSort, actual, tc.expected)
+		}
+	}
+}
+
+func TestImporter_Collation(t *testing.T) {
+	csvInput := "email\na@zürich.ch\nb@zug.ch\nc@ñandu.es\nd@nube.es\ne@ábc.com\nf@abd.com"
+
+	testCases := []struct {
+		name     string
+		opts     []Option
+		expected []string
+	}{
+		{name: "bytewise", expected: []string{"abd.com", "nube.es", "zug.ch", "zürich.ch", "ábc.com", "ñandu.es"}},
+		{name: "root", opts: []Option{WithCollation(language.Und)}, expected: []string{"ábc.com", "abd.com", "ñandu.es", "nube.es", "zug.ch", "zürich.ch"}},
+		{name: "spanish", opts: []Option{WithCollation(language.Spanish)}, expected: []string{"ábc.com", "abd.com", "nube.es", "ñandu.es", "zug.ch", "zürich.ch"}},
+	}
+
+	for _, tc := range testCases {
+		domainsCount, err := NewImporter(append([]Option{WithEmailColumn(0)}, tc.opts...)...).Import(strings.NewReader(csvInput))
+		if err != nil {
+			t.Fatalf("%s: unexpected error occured: %v", tc.name, err)
+		}
+		var names []string
+		for _, stat := range domainsCount.DomainStats {
+			names = append(names, stat.Name)
+		}
+		if !reflect.DeepEqual(names, tc.expected) {
+			t.Errorf("%s: domains %v, expected: %v", tc.name, names, tc.expected)
+		}
+	}
+}
+
+func TestParseSortKeys(t *testing.T) {
+	testCases := []struct {
+		spec     string
+		expected []SortKey
+		err      bool
+	}{
+		{spec: "count", expected: []SortKey{{Field: SORT_BY_COUNT, Desc: true}}},
+		{spec: "name", expected: []SortKey{{Field: SORT_BY_NAME}}},
+		{spec: "count:asc", expected: []SortKey{{Field: SORT_BY_COUNT}}},
+		{spec: "count:desc,name:desc", expected: []SortKey{{Field: SORT_BY_COUNT, Desc: true}, {Field: SORT_BY_NAME, Desc: true}}},
+		{spec: "Name:DESC, count", expected: []SortKey{{Field: SORT_BY_NAME, Desc: true}, {Field: SORT_BY_COUNT, Desc: true}}},
+		{spec: "size", err: true},
+		{spec: "count:up", err: true},
+	}
+
+	for _, tc := range testCases {
+		keys, err := ParseSortKeys(tc.spec)
+		if tc.err {
+			if err == nil {
+				t.Errorf("%s: expected an error", tc.spec)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: unexpected error occured: %v", tc.spec, err)
+		}
+		if !reflect.DeepEqual(keys, tc.expected) {
+			t.Errorf("%s: keys %v, expected: %v", tc.spec, keys, tc.expected)
+		}
+	}
+}
+
+func TestSortStatsBy(t *testing.T) {
+	domainStats := []DomainStat{
+		{Name: "a.com", Count: 1},
+		{Name: "b.com", Count: 2, Subdomains: []DomainStat{{Name: "x.b.com", Count: 1}, {Name: "y.b.com", Count: 1}}},
+		{Name: "c.com", Count: 2},
+	}
+
+	testCases := []struct {
+		spec     string
+		expected []string
+	}{
+		{spec: "name:desc", expected: []string{"c.com", "b.com", "a.com"}},
+		{spec: "count", expected: []string{"b.com", "c.com", "a.com"}},
+		{spec: "count:desc,name:desc", expected: []string{"c.com", "b.com", "a.com"}},
+		{spec: "count:asc,name:desc", expected: []string{"a.com", "c.com", "b.com"}},
+	}
+
+	for _, tc := range testCases {
+		keys, err := ParseSortKeys(tc.spec)
+		if err != nil {
+			t.Fatalf("%s: unexpected error occured: %v", tc.spec, err)
+		}
+		sorted := sortStatsBy(domainStats, keys)
+		var names []string
+		for _, stat := range sorted {
+			names = append(names, stat.Name)
+		}
+		if !reflect.DeepEqual(names, tc.expected) {
+			t.Errorf("%s: domains %v, expected: %v", tc.spec, names, tc.expected)
+		}
+	}
+
+	sorted := sortStatsBy(domainStats, []SortKey{{Field: SORT_BY_NAME, Desc: true}})
+	if sorted[1].Subdomains[0].Name != "y.b.com" {
+		t.Errorf("subdomain: %s, expected: %s", sorted[1].Subdomains[0].Name, "y.b.com")
+	}
+	if domainStats[0].Name != "a.com" || domainStats[1].Subdomains[0].Name != "x.b.com" {
+		t.Errorf("input was reordered: %v", domainStats)
+	}
+}
@@ -0,0 +1,84 @@
+package customerimporter
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// uniqueKey is what WithUnique deduplicates an email under. It leads with the
+// domain, so reading the set back in sorted order visits all emails of a
+// domain together.
+func uniqueKey(domain, email string) string {
+	return domain + "\x00" + strings.ToLower(email)
+}
+
+// countUnique counts the distinct emails of unique into counter or, with
+// WithStream, passes every domain to the stream as soon as its last email was
+// read and returns the number of customers streamed.
+func (imp *Importer) countUnique(unique *uniqueSet, counter *shardedCounter) (int, error) {
+	if imp.stream == nil {
+		return 0, unique.each(false, func(key string) error {
+			domain, email, _ := strings.Cut(key, "\x00")
+			counter.add(domain, imp.isRoleAccount(email))
+			return nil
+		})
+	}
+
+	total := 0
+	var current DomainStat
+	err := unique.each(true, func(key string) error {
+		domain, email, _ := strings.Cut(key, "\x00")
+		if domain != current.Name && current.Count > 0 {
+			if err := imp.stream(current); err != nil {
+				return err
+			}
+			current = DomainStat{}
+		}
+		current.Name = domain
+		current.Count++
+		if imp.isRoleAccount(email) {
+			current.RoleCount++
+		}
+		total++
+		return nil
+	})
+	if err != nil {
+		return total, err
+	}
+	if current.Count > 0 {
+		return total, imp.stream(current)
+	}
+	return total, nil
+}
+
+// streamCounts passes the domains of domainMap to the stream in name order.
+// Only their sorted names are held besides the maps, not a DomainStat each.
+func (imp *Importer) streamCounts(domainMap, roleMap, firstSeen map[string]int) error {
+	for _, domain := range slices.Sorted(maps.Keys(domainMap)) {
+		domainStat := DomainStat{Name: domain, Count: domainMap[domain], RoleCount: roleMap[domain], FirstSeenLine: firstSeen[domain]}
+		if err := imp.stream(domainStat); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateStream rejects options that need all domains at once, which
+// WithStream never holds.
+func (imp *Importer) validateStream() error {
+	switch {
+	case imp.stream == nil:
+		return nil
+	case imp.timeWindow != "":
+		return errors.New("streaming does not support time windows")
+	case imp.groupBy != "" && imp.groupBy != GROUP_BY_DOMAIN:
+		return errors.New("streaming does not support grouping")
+	case imp.naturalSort || imp.collation != nil:
+		return errors.New("streaming does not support natural sort or collation")
+	case len(imp.disposable) > 0:
+		return errors.New("streaming does not support disposable domains")
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package customerimporter
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestImporter_Stream(t *testing.T) {
+	csvInput := `first_name,last_name,email
+A,A,a@github.io
+B,B,info@cnet.com
+C,C,A@GitHub.io
+D,D,d@cnet.com.au
+E,E,e@cnet.com
+F,F,f@github.io`
+
+	for _, limit := range []int{100, 2} {
+		var streamed []DomainStat
+		imp := NewImporter(
+			WithUnique(true),
+			WithUniqueMemoryLimit(limit, t.TempDir()),
+			WithRoleAccounts([]string{"info"}),
+			WithStream(func(domainStat DomainStat) error {
+				streamed = append(streamed, domainStat)
+				return nil
+			}),
+		)
+		domainsCount, err := imp.Import(strings.NewReader(csvInput))
+		if err != nil {
+			t.Fatalf("unexpected error occured: %v", err)
+		}
+
+		expectedStats := []DomainStat{
+			{Name: "cnet.com", Count: 2, RoleCount: 1},
+			{Name: "cnet.com.au", Count: 1},
+			{Name: "github.io", Count: 2},
+		}
+		if !reflect.DeepEqual(streamed, expectedStats) {
+			t.Errorf("memory limit %d: streamed stats: %v, expected: %v", limit, streamed, expectedStats)
+		}
+		if domainsCount.TotalCount != 5 {
+			t.Errorf("memory limit %d: total count: %d, expected: 5", limit, domainsCount.TotalCount)
+		}
+		if len(domainsCount.DomainStats) != 0 {
+			t.Errorf("memory limit %d: domain stats: %v, expected none", limit, domainsCount.DomainStats)
+		}
+	}
+}
+
+func TestImporter_StreamCounted(t *testing.T) {
+	csvInput := `first_name,last_name,email
+A,A,a@github.io
+B,B,info@cnet.com
+C,C,a@github.io
+D,D,d@cnet.com.au
+E,E,e@cnet.com`
+
+	var streamed []DomainStat
+	imp := NewImporter(
+		WithRoleAccounts([]string{"info"}),
+		WithStream(func(domainStat DomainStat) error {
+			streamed = append(streamed, domainStat)
+			return nil
+		}),
+	)
+	domainsCount, err := imp.Import(strings.NewReader(csvInput))
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+
+	expectedStats := []DomainStat{
+		{Name: "cnet.com", Count: 2, RoleCount: 1, FirstSeenLine: 3},
+		{Name: "cnet.com.au", Count: 1, FirstSeenLine: 5},
+		{Name: "github.io", Count: 2, FirstSeenLine: 2},
+	}
+	if !reflect.DeepEqual(streamed, expectedStats) {
+		t.Errorf("Streamed stats: %v, expected: %v", streamed, expectedStats)
+	}
+	if domainsCount.TotalCount != 5 {
+		t.Errorf("Total count: %d, expected: 5", domainsCount.TotalCount)
+	}
+	if len(domainsCount.DomainStats) != 0 {
+		t.Errorf("Domain stats: %v, expected none", domainsCount.DomainStats)
+	}
+}
+
+func TestImporter_StreamErrors(t *testing.T) {
+	csvInput := "first_name,last_name,email\nA,A,a@github.io\nB,B,b@cnet.com"
+	errStop := errors.New("stop")
+	stop := func(DomainStat) error { return errStop }
+
+	testCases := []struct {
+		name string
+		opts []Option
+	}{
+		{name: "windowed", opts: []Option{WithStream(stop), WithTimeWindow("created_at", WINDOW_DAY)}},
+		{name: "stream_error_counted", opts: []Option{WithStream(stop)}},
+		{name: "grouped", opts: []Option{WithUnique(true), WithStream(stop), WithGroupBy(GROUP_BY_TLD)}},
+		{name: "natural_sort", opts: []Option{WithUnique(true), WithStream(stop), WithNaturalSort(true)}},
+		{name: "stream_error", opts: []Option{WithUnique(true), WithStream(stop)}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := NewImporter(tc.opts...).Import(strings.NewReader(csvInput)); err == nil {
+				t.Error("error expected, got nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package customerimporter
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// SYSLOG_TAG is the tag of the messages of NewSyslogSink when none is given.
+const SYSLOG_TAG = "customerimporter"
+const SYSLOG_SUMMARY_FORMAT = "Total number of customers: %d, Distinct domains: %d"
+
+// ErrSyslogUnsupported is returned by NewSyslogSink on platforms without a
+// system log, such as Windows.
+var ErrSyslogUnsupported = errors.New("syslog is not supported on this platform")
+
+// syslogWriter is the part of syslog.Writer SyslogSink uses.
+type syslogWriter interface {
+	Info(msg string) error
+	Close() error
+}
+
+// SyslogSink sends the result to the system log as one info message with the
+// SYSLOG_SUMMARY_FORMAT totals and, with Domains, a message per domain after
+// it. Create it with NewSyslogSink and Close it when done.
+type SyslogSink struct {
+	Domains bool
+	writer  syslogWriter
+}
+
+func (s *SyslogSink) Write(domainsCount DomainsCount) error {
+	if err := s.writer.Info(fmt.Sprintf(SYSLOG_SUMMARY_FORMAT, domainsCount.TotalCount, len(domainsCount.DomainStats))); err != nil {
+		return err
+	}
+	if !s.Domains {
+		return nil
+	}
+	for _, domainStat := range domainsCount.DomainStats {
+		if err := s.writer.Info(strings.TrimSuffix(fmt.Sprintf(OUTPUT_LINE_FORMAT, domainStat.Name, domainStat.Count), "\n")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the connection to the system log.
+func (s *SyslogSink) Close() error {
+	return s.writer.Close()
+}
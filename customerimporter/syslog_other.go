@@ -0,0 +1,8 @@
+//go:build windows || plan9
+
+package customerimporter
+
+// NewSyslogSink returns ErrSyslogUnsupported, as there is no system log.
+func NewSyslogSink(tag string, domains bool) (*SyslogSink, error) {
+	return nil, ErrSyslogUnsupported
+}
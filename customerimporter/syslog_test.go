@@ -0,0 +1,66 @@
+package customerimporter
+
+import (
+	"reflect"
+	"testing"
+)
+
+type fakeSyslog struct {
+	messages []string
+	closed   bool
+}
+
+func (f *fakeSyslog) Info(msg string) error {
+	f.messages = append(f.messages, msg)
+	return nil
+}
+
+func (f *fakeSyslog) Close() error {
+	f.closed = true
+	return nil
+}
+
+func TestSyslogSink(t *testing.T) {
+	domainsCount := DomainsCount{DomainStats: []DomainStat{
+		{Name: "cnet.com", Count: 1},
+		{Name: "github.io", Count: 2},
+	},
+		TotalCount: 3,
+	}
+
+	testCases := []struct {
+		name             string
+		domains          bool
+		expectedMessages []string
+	}{
+		{name: "summary", expectedMessages: []string{"Total number of customers: 3, Distinct domains: 2"}},
+		{
+			name:    "domains",
+			domains: true,
+			expectedMessages: []string{
+				"Total number of customers: 3, Distinct domains: 2",
+				"Domain: cnet.com, Customers: 1",
+				"Domain: github.io, Customers: 2",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			writer := &fakeSyslog{}
+			sink := &SyslogSink{Domains: tc.domains, writer: writer}
+			if err := WriteSinks(domainsCount, sink); err != nil {
+				t.Fatalf("unexpected error occured: %v", err)
+			}
+			if err := sink.Close(); err != nil {
+				t.Fatalf("unexpected error occured: %v", err)
+			}
+			if !reflect.DeepEqual(writer.messages, tc.expectedMessages) {
+				t.Errorf("messages %q, expected: %q", writer.messages, tc.expectedMessages)
+			}
+			if !writer.closed {
+				t.Error("syslog writer not closed")
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !windows && !plan9
+
+package customerimporter
+
+import "log/syslog"
+
+// NewSyslogSink connects to the local system log, sending with tag, or
+// SYSLOG_TAG when it is empty, as the user facility.
+func NewSyslogSink(tag string, domains bool) (*SyslogSink, error) {
+	if tag == "" {
+		tag = SYSLOG_TAG
+	}
+	writer, err := syslog.New(syslog.LOG_INFO|syslog.LOG_USER, tag)
+	if err != nil {
+		return nil, err
+	}
+	return &SyslogSink{Domains: domains, writer: writer}, nil
+}
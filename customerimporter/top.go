@@ -0,0 +1,89 @@
+package customerimporter
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const TOP_LINE_FORMAT = "%s %d\n"
+
+// topStats returns the n domains of domainMap with the most customers, most
+// first and ties going to the alphabetically first. They are picked in a
+// single pass through a heap of the n best domains so far, so only n domain
+// stats are built and sorted, not one per domain.
+func topStats(domainMap map[string]int, roleMap map[string]int, n int) []DomainStat {
+	best := make(topHeap, 0, min(n, len(domainMap)))
+	for domain, customers := range domainMap {
+		stat := DomainStat{Name: domain, Count: customers}
+		if len(best) < n {
+			heap.Push(&best, stat)
+		} else if ranksBelow(best[0], stat) {
+			best[0] = stat
+			heap.Fix(&best, 0)
+		}
+	}
+
+	top := make([]DomainStat, len(best))
+	for i := len(top) - 1; i >= 0; i-- {
+		top[i] = heap.Pop(&best).(DomainStat)
+		top[i].RoleCount = roleMap[top[i].Name]
+	}
+	return top
+}
+
+// ranksBelow reports whether a ranks below b in topStats, having fewer
+// customers or as many and a name sorting after it.
+func ranksBelow(a, b DomainStat) bool {
+	return a.Count < b.Count || (a.Count == b.Count && a.Name > b.Name)
+}
+
+// topHeap keeps the lowest ranking of the domains it holds first.
+type topHeap []DomainStat
+
+func (h topHeap) Len() int           { return len(h) }
+func (h topHeap) Less(i, j int) bool { return ranksBelow(h[i], h[j]) }
+func (h topHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *topHeap) Push(x any) {
+	*h = append(*h, x.(DomainStat))
+}
+
+func (h *topHeap) Pop() any {
+	old := *h
+	stat := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return stat
+}
+
+// validateTopDomain reports options WithTopDomain and WithTopDomains can't be
+// combined with, as they need every domain.
+func (imp *Importer) validateTopDomain() error {
+	if imp.topDomains <= 0 {
+		return nil
+	}
+	if (imp.groupBy != "" && imp.groupBy != GROUP_BY_DOMAIN) || imp.timeWindow != "" {
+		return errors.New("the top domains don't support grouping or time windows")
+	}
+	if len(imp.disposable) > 0 || imp.stream != nil {
+		return errors.New("the top domains don't support disposable domains or streaming")
+	}
+	return nil
+}
+
+// writeTop writes the domain with the most customers, ties going to the
+// alphabetically first like WithTopDomain, so that it needn't be the only one.
+func writeTop(w io.Writer, domainsCount DomainsCount) error {
+	if len(domainsCount.DomainStats) == 0 {
+		return nil
+	}
+	top := domainsCount.DomainStats[0]
+	for _, domainStat := range domainsCount.DomainStats[1:] {
+		if domainStat.Count > top.Count || (domainStat.Count == top.Count && domainStat.Name < top.Name) {
+			top = domainStat
+		}
+	}
+	_, err := fmt.Fprintf(w, TOP_LINE_FORMAT, top.Name, top.Count)
+	return err
+}
@@ -0,0 +1,151 @@
+package customerimporter
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTopStats(t *testing.T) {
+	testCases := []struct {
+		name      string
+		domainMap map[string]int
+		roleMap   map[string]int
+		expected  []DomainStat
+	}{
+		{
+			name:      "most_customers",
+			domainMap: map[string]int{"cnet.com": 2, "github.io": 3, "zoho.com": 1},
+			roleMap:   map[string]int{"github.io": 1},
+			expected:  []DomainStat{{Name: "github.io", Count: 3, RoleCount: 1}},
+		},
+		{
+			name:      "tie_alphabetical",
+			domainMap: map[string]int{"zoho.com": 2, "cnet.com": 2, "github.io": 2},
+			expected:  []DomainStat{{Name: "cnet.com", Count: 2}},
+		},
+		{
+			name:      "empty",
+			domainMap: map[string]int{},
+			expected:  []DomainStat{},
+		},
+	}
+
+	for _, tc := range testCases {
+		if actual := topStats(tc.domainMap, tc.roleMap, 1); !reflect.DeepEqual(actual, tc.expected) {
+			t.Errorf("%s: top %v, expected: %v", tc.name, actual, tc.expected)
+		}
+	}
+}
+
+// naiveTopStats is topStats by sorting all domains and truncating them.
+func naiveTopStats(domainMap map[string]int, roleMap map[string]int, n int) []DomainStat {
+	keys, _ := ParseSortKeys("count,name")
+	domainStats := sortStatsBy(createStats(domainMap, roleMap, false), keys)
+	return domainStats[:min(n, len(domainStats))]
+}
+
+// topDomainMap returns a domainMap of size domains with counts repeating
+// often enough for ties.
+func topDomainMap(size int) map[string]int {
+	domainMap := make(map[string]int, size)
+	for i := range size {
+		domainMap[fmt.Sprintf("domain%d.com", i)] = (i * 7919) % 97
+	}
+	return domainMap
+}
+
+func TestTopStats_MatchesSort(t *testing.T) {
+	domainMap := topDomainMap(1000)
+	roleMap := map[string]int{"domain3.com": 2, "domain500.com": 1}
+
+	for _, n := range []int{1, 5, 96, 1000, 2000} {
+		expected := naiveTopStats(domainMap, roleMap, n)
+		if actual := topStats(domainMap, roleMap, n); !reflect.DeepEqual(actual, expected) {
+			t.Errorf("top %d: %v, expected: %v", n, actual, expected)
+		}
+	}
+}
+
+func BenchmarkTopStats(b *testing.B) {
+	domainMap := topDomainMap(1_000_000)
+	b.Run("heap", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			topStats(domainMap, nil, 10)
+		}
+	})
+	b.Run("sort", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			naiveTopStats(domainMap, nil, 10)
+		}
+	})
+}
+
+func TestImporter_TopDomains(t *testing.T) {
+	csvInput := `email
+a@github.io
+b@cnet.com
+c@github.io
+d@zoho.com
+e@cnet.com
+f@acme.org`
+
+	domainsCount, err := NewImporter(WithEmailColumn(0), WithTopDomains(3)).Import(strings.NewReader(csvInput))
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	if domainsCount.TotalCount != 6 {
+		t.Errorf("Total customers: %d, expected: %d", domainsCount.TotalCount, 6)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTo(&buf, *domainsCount, OutputOptions{Format: FORMAT_CSV}); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	expected := "domain,customers\ncnet.com,2\ngithub.io,2\nacme.org,1\n"
+	if buf.String() != expected {
+		t.Errorf("output %q, expected: %q", buf.String(), expected)
+	}
+}
+
+func TestImporter_TopDomain(t *testing.T) {
+	csvInput := `email
+a@github.io
+b@cnet.com
+c@github.io`
+
+	domainsCount, err := NewImporter(WithEmailColumn(0), WithTopDomain(true)).Import(strings.NewReader(csvInput))
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	if domainsCount.TotalCount != 3 {
+		t.Errorf("Total customers: %d, expected: %d", domainsCount.TotalCount, 3)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTo(&buf, *domainsCount, OutputOptions{Top: true}); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	if buf.String() != "github.io 2\n" {
+		t.Errorf("output %q, expected: %q", buf.String(), "github.io 2\n")
+	}
+
+	// Without WithTopDomain the largest domain is picked from all of them.
+	buf.Reset()
+	all := DomainsCount{DomainStats: []DomainStat{{Name: "cnet.com", Count: 1}, {Name: "zoho.com", Count: 2}, {Name: "github.io", Count: 2}}}
+	if err := WriteTo(&buf, all, OutputOptions{Top: true}); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	if buf.String() != "github.io 2\n" {
+		t.Errorf("output %q, expected: %q", buf.String(), "github.io 2\n")
+	}
+
+	_, err = NewImporter(WithEmailColumn(0), WithTopDomain(true), WithGroupBy(GROUP_BY_TLD)).Import(strings.NewReader(csvInput))
+	if err == nil {
+		t.Error("error expected with grouping, got nil")
+	}
+}
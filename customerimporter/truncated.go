@@ -0,0 +1,36 @@
+package customerimporter
+
+import "io"
+
+const TRUNCATED_WARNING_FORMAT = "Warning: line %d, the last one, has no line break and was skipped, the input may be truncated\n"
+
+// lastByteReader remembers the last byte read from reader, to tell whether
+// the input ended with a line break.
+type lastByteReader struct {
+	reader io.Reader
+	last   byte
+	read   bool
+}
+
+func (r *lastByteReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		r.last, r.read = p[n-1], true
+	}
+	return n, err
+}
+
+// truncated reports whether the input ended without a line break.
+func (r *lastByteReader) truncated() bool {
+	return r.read && r.last != '\n'
+}
+
+// warnTruncated logs TRUNCATED_WARNING_FORMAT when the last row, at line, was
+// skipped as short or malformed and end didn't end with a line break, which
+// is how an export cut off mid-record looks. end is nil when the raw input is
+// unknown, as with ImportCSV.
+func (imp *Importer) warnTruncated(end *lastByteReader, lastSkipped, line int) {
+	if end != nil && lastSkipped == line && end.truncated() {
+		imp.logger.Printf(TRUNCATED_WARNING_FORMAT, line)
+	}
+}
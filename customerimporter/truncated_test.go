@@ -0,0 +1,64 @@
+package customerimporter
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestImporter_LastLineWithoutLineBreak(t *testing.T) {
+	for _, fastParse := range []bool{false, true} {
+		for _, delimiter := range []rune{',', ';', '\t', '|'} {
+			t.Run(fmt.Sprintf("fast_%t_%q", fastParse, delimiter), func(t *testing.T) {
+				csvInput := strings.ReplaceAll("first_name,last_name,email\r\nA,A,a@github.io\r\nB,B,b@cnet.com", ",", string(delimiter))
+				var logs bytes.Buffer
+				imp := NewImporter(WithDelimiter(delimiter), WithFastParse(fastParse), WithLogger(log.New(&logs, "", 0)))
+				domainsCount, err := imp.Import(strings.NewReader(csvInput))
+				if err != nil {
+					t.Fatalf("unexpected error occured: %v", err)
+				}
+				if domainsCount.TotalCount != 2 {
+					t.Errorf("Total count: %d, expected: 2", domainsCount.TotalCount)
+				}
+				if strings.Contains(logs.String(), "truncated") {
+					t.Errorf("logs %q, expected no truncation warning", logs.String())
+				}
+			})
+		}
+	}
+}
+
+func TestImporter_TruncatedWarning(t *testing.T) {
+	testCases := []struct {
+		name      string
+		csvInput  string
+		fastParse bool
+		expected  bool
+	}{
+		{name: "short_last_row", csvInput: "first_name,last_name,email\r\nA,A,a@github.io\r\nB,B", expected: true},
+		{name: "short_last_row_fast", csvInput: "first_name,last_name,email\r\nA,A,a@github.io\r\nB,B", fastParse: true, expected: true},
+		{name: "unterminated_quote", csvInput: "first_name,last_name,email\nA,A,a@github.io\nB,B,\"b@cnet", expected: true},
+		{name: "short_last_row_with_line_break", csvInput: "first_name,last_name,email\nA,A,a@github.io\nB,B\n"},
+		{name: "short_row_before_last", csvInput: "first_name,last_name,email\nB,B\nA,A,a@github.io"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			imp := NewImporter(WithFastParse(tc.fastParse), WithLogger(log.New(&logs, "", 0)))
+			domainsCount, err := imp.Import(strings.NewReader(tc.csvInput))
+			if err != nil {
+				t.Fatalf("unexpected error occured: %v", err)
+			}
+			if domainsCount.TotalCount != 1 {
+				t.Errorf("Total count: %d, expected: 1", domainsCount.TotalCount)
+			}
+			warned := strings.Contains(logs.String(), fmt.Sprintf(TRUNCATED_WARNING_FORMAT, 3))
+			if warned != tc.expected {
+				t.Errorf("truncation warning: %t, expected: %t (logs: %s)", warned, tc.expected, logs.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package customerimporter
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"sync"
+)
+
+const DEFAULT_UNIQUE_MEMORY_LIMIT = 1_000_000
+
+// uniqueSet collects distinct emails with bounded memory. Once more than
+// limit emails are held, they are sorted and spilled to a temporary chunk
+// file, and the chunks are merged when the set is read back, so memory only
+// grows with limit and the number of chunks.
+type uniqueSet struct {
+	mu     sync.Mutex
+	emails map[string]struct{}
+	limit  int
+	dir    string
+	chunks []string
+	err    error
+}
+
+func newUniqueSet(limit int, dir string) *uniqueSet {
+	if limit <= 0 {
+		limit = DEFAULT_UNIQUE_MEMORY_LIMIT
+	}
+	return &uniqueSet{
+		emails: make(map[string]struct{}),
+		limit:  limit,
+		dir:    dir,
+	}
+}
+
+// add records email. A failure to spill is kept and returned by each, since
+// add is called from the workers, which have no way to report errors.
+func (s *uniqueSet) add(email string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return
+	}
+	s.emails[email] = struct{}{}
+	if len(s.emails) > s.limit {
+		s.err = s.spill()
+	}
+}
+
+func (s *uniqueSet) spill() error {
+	file, err := os.CreateTemp(s.dir, "customerimporter_unique_*.chunk")
+	if err != nil {
+		return fmt.Errorf("error creating spill file: %v", err)
+	}
+	defer file.Close()
+	s.chunks = append(s.chunks, file.Name())
+
+	writer := bufio.NewWriter(file)
+	var lenBuf [binary.MaxVarintLen64]byte
+	for _, email := range slices.Sorted(maps.Keys(s.emails)) {
+		n := binary.PutUvarint(lenBuf[:], uint64(len(email)))
+		if _, err := writer.Write(lenBuf[:n]); err != nil {
+			return fmt.Errorf("error writing spill file: %v", err)
+		}
+		if _, err := writer.WriteString(email); err != nil {
+			return fmt.Errorf("error writing spill file: %v", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("error writing spill file: %v", err)
+	}
+
+	clear(s.emails)
+	return nil
+}
+
+// each calls fn once for every distinct email added, stopping at the first
+// error fn returns. Once emails were spilled they are visited in sorted order;
+// set sorted to sort them when they are all held in memory as well. It must
+// only be called once all adds are done.
+func (s *uniqueSet) each(sorted bool, fn func(email string) error) error {
+	if s.err != nil {
+		return s.err
+	}
+	if len(s.chunks) == 0 {
+		emails := maps.Keys(s.emails)
+		if sorted {
+			emails = slices.Values(slices.Sorted(emails))
+		}
+		for email := range emails {
+			if err := fn(email); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if len(s.emails) > 0 {
+		if err := s.spill(); err != nil {
+			return err
+		}
+	}
+
+	pending := make(chunkHeap, 0, len(s.chunks))
+	defer func() {
+		for _, chunk := range pending {
+			chunk.file.Close()
+		}
+	}()
+	for _, path := range s.chunks {
+		chunk, err := openChunk(path)
+		if err != nil {
+			return err
+		}
+		if !chunk.done {
+			pending = append(pending, chunk)
+		} else {
+			chunk.file.Close()
+		}
+	}
+	heap.Init(&pending)
+
+	previous, first := "", true
+	for pending.Len() > 0 {
+		chunk := pending[0]
+		if first || chunk.head != previous {
+			if err := fn(chunk.head); err != nil {
+				return err
+			}
+			previous, first = chunk.head, false
+		}
+
+		if err := chunk.next(); err != nil {
+			return err
+		}
+		if chunk.done {
+			heap.Pop(&pending)
+			chunk.file.Close()
+		} else {
+			heap.Fix(&pending, 0)
+		}
+	}
+	return nil
+}
+
+// cleanup removes the spill files.
+func (s *uniqueSet) cleanup() {
+	for _, path := range s.chunks {
+		os.Remove(path)
+	}
+	s.chunks = nil
+}
+
+type chunkReader struct {
+	file   *os.File
+	reader *bufio.Reader
+	head   string
+	done   bool
+}
+
+func openChunk(path string) (*chunkReader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening spill file: %v", err)
+	}
+	chunk := &chunkReader{file: file, reader: bufio.NewReader(file)}
+	if err := chunk.next(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return chunk, nil
+}
+
+func (c *chunkReader) next() error {
+	size, err := binary.ReadUvarint(c.reader)
+	if err == io.EOF {
+		c.done = true
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading spill file: %v", err)
+	}
+
+	buf := make([]byte, size)
+	if _, err := io.ReadFull(c.reader, buf); err != nil {
+		return fmt.Errorf("error reading spill file: %v", err)
+	}
+	c.head = string(buf)
+	return nil
+}
+
+// chunkHeap orders spill chunks by their current email.
+type chunkHeap []*chunkReader
+
+func (h chunkHeap) Len() int           { return len(h) }
+func (h chunkHeap) Less(i, j int) bool { return h[i].head < h[j].head }
+func (h chunkHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *chunkHeap) Push(x any) {
+	*h = append(*h, x.(*chunkReader))
+}
+
+func (h *chunkHeap) Pop() any {
+	old := *h
+	chunk := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return chunk
+}
@@ -0,0 +1,87 @@
+package customerimporter
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestImporter_Unique(t *testing.T) {
+	var sb strings.Builder
+	sb.WriteString("first_name,last_name,email\n")
+	for i := range 1000 {
+		// 60 distinct addresses over 6 domains, repeated with varying case.
+		email := fmt.Sprintf("user%d@domain%d.com", i%60, i%6)
+		if i%2 == 1 {
+			email = strings.ToUpper(email)
+		}
+		fmt.Fprintf(&sb, "Name%d,Surname%d,%s\n", i, i, email)
+	}
+	csvInput := sb.String()
+
+	testCases := []struct {
+		name        string
+		memoryLimit int
+	}{
+		{name: "in_memory", memoryLimit: 0},
+		{name: "spilled", memoryLimit: 7},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			spillDir := t.TempDir()
+			imp := NewImporter(WithUnique(true), WithUniqueMemoryLimit(tc.memoryLimit, spillDir))
+
+			domainsCount, err := imp.Import(strings.NewReader(csvInput))
+			if err != nil {
+				t.Fatalf("unexpected error occured: %v", err)
+			}
+
+			if domainsCount.TotalCount != 60 {
+				t.Errorf("Total count: %d, expected: 60", domainsCount.TotalCount)
+			}
+			if len(domainsCount.DomainStats) != 6 {
+				t.Fatalf("Domains: %d, expected: 6", len(domainsCount.DomainStats))
+			}
+			for _, domain := range domainsCount.DomainStats {
+				if domain.Count != 10 {
+					t.Errorf("Domain %s count: %d, expected: 10", domain.Name, domain.Count)
+				}
+			}
+
+			leftovers, err := os.ReadDir(spillDir)
+			if err != nil {
+				t.Fatalf("error reading spill dir: %v", err)
+			}
+			if len(leftovers) != 0 {
+				t.Errorf("expected spill files to be removed, found %d", len(leftovers))
+			}
+		})
+	}
+}
+
+func TestUniqueSet_Spill(t *testing.T) {
+	set := newUniqueSet(2, t.TempDir())
+	defer set.cleanup()
+
+	for _, email := range []string{"c@x.com", "a@x.com", "b@x.com", "a@x.com", "d@x.com", "c@x.com", "e@x.com"} {
+		set.add(email)
+	}
+	if len(set.chunks) == 0 {
+		t.Fatal("expected the set to spill to disk")
+	}
+
+	var emails []string
+	if err := set.each(false, func(email string) error {
+		emails = append(emails, email)
+		return nil
+	}); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+
+	expected := "a@x.com,b@x.com,c@x.com,d@x.com,e@x.com"
+	if strings.Join(emails, ",") != expected {
+		t.Errorf("emails: %v, expected: %s", emails, expected)
+	}
+}
@@ -0,0 +1,118 @@
+package customerimporter
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// WarningCategory classifies the data-quality problem of a skipped row.
+type WarningCategory string
+
+const WARNING_BAD_EMAIL WarningCategory = "bad_email"
+const WARNING_EMPTY_EMAIL WarningCategory = "empty_email"
+const WARNING_LONG_EMAIL WarningCategory = "long_email"
+const WARNING_SHORT_ROW WarningCategory = "short_row"
+const WARNING_MALFORMED_ROW WarningCategory = "malformed_row"
+
+// WARNING_CATEGORIES lists the categories in the order of summaries.
+var WARNING_CATEGORIES = []WarningCategory{WARNING_BAD_EMAIL, WARNING_EMPTY_EMAIL, WARNING_LONG_EMAIL, WARNING_SHORT_ROW, WARNING_MALFORMED_ROW}
+
+// MAX_WARNINGS is the number of warnings kept per import, so that a dirty
+// file doesn't hold one per row. WarningCounts counts them all.
+const MAX_WARNINGS = 1000
+
+// Warning is a row the import skipped. Line is its line in the input, Value
+// the offending email or weight if any, and Input the path of the input it
+// was read from with ImportFiles.
+type Warning struct {
+	Category WarningCategory `json:"category"`
+	Line     int             `json:"line"`
+	Value    string          `json:"value,omitempty"`
+	Input    string          `json:"input,omitempty"`
+}
+
+// ErrSkippedRow is wrapped by every Warning used as an error, see
+// ImportWithReport.
+var ErrSkippedRow = errors.New("skipped row")
+
+// Error describes the warning as its location, path:line with ImportFiles,
+// then its category and value, e.g. line 3: bad_email "bortiz1cyberchimps.com".
+func (w Warning) Error() string {
+	location := fmt.Sprintf("line %d", w.Line)
+	if w.Input != "" {
+		location = fmt.Sprintf("%s:%d", w.Input, w.Line)
+	}
+	message := fmt.Sprintf("%s: %s", location, w.Category)
+	if w.Value != "" {
+		message += fmt.Sprintf(" %q", w.Value)
+	}
+	return message
+}
+
+func (w Warning) Unwrap() error {
+	return ErrSkippedRow
+}
+
+// warn counts a skipped row of category and keeps it among the warnings up
+// to MAX_WARNINGS. It is safe for concurrent use.
+func (s *skipCounts) warn(category WarningCategory, line int, value string) {
+	switch category {
+	case WARNING_BAD_EMAIL:
+		s.badEmail.Add(1)
+	case WARNING_EMPTY_EMAIL:
+		s.emptyEmail.Add(1)
+	case WARNING_LONG_EMAIL:
+		s.longEmail.Add(1)
+	case WARNING_SHORT_ROW:
+		s.shortRow.Add(1)
+	case WARNING_MALFORMED_ROW:
+		s.malformed.Add(1)
+	}
+	if s.recorded.Add(1) > MAX_WARNINGS {
+		return
+	}
+	s.mu.Lock()
+	s.warnings = append(s.warnings, Warning{Category: category, Line: line, Value: truncateEmail(value)})
+	s.mu.Unlock()
+}
+
+// sortedWarnings returns the kept warnings by line, as the workers report
+// them out of order. It must only be called once all writers are done.
+func (s *skipCounts) sortedWarnings() []Warning {
+	sort.SliceStable(s.warnings, func(i, j int) bool {
+		return s.warnings[i].Line < s.warnings[j].Line
+	})
+	return s.warnings
+}
+
+// warningCounts returns the number of rows skipped per category, including
+// those beyond MAX_WARNINGS.
+func (s *skipCounts) warningCounts() map[WarningCategory]int {
+	counts := make(map[WarningCategory]int)
+	for category, count := range map[WarningCategory]int64{
+		WARNING_BAD_EMAIL:     s.badEmail.Load(),
+		WARNING_EMPTY_EMAIL:   s.emptyEmail.Load(),
+		WARNING_LONG_EMAIL:    s.longEmail.Load(),
+		WARNING_SHORT_ROW:     s.shortRow.Load(),
+		WARNING_MALFORMED_ROW: s.malformed.Load(),
+	} {
+		if count > 0 {
+			counts[category] = int(count)
+		}
+	}
+	return counts
+}
+
+// WarningSummary returns the WarningCounts as e.g. "2 bad_email, 1 short_row",
+// or an empty string when no row was skipped.
+func (dc *DomainsCount) WarningSummary() string {
+	var parts []string
+	for _, category := range WARNING_CATEGORIES {
+		if count := dc.WarningCounts[category]; count > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", count, category))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,126 @@
+package customerimporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestImporter_Warnings(t *testing.T) {
+	csvInput := `first_name,last_name,email
+Mildred,Hernandez,mhernandez0@github.io
+Bonnie,Ortiz,bortiz1cyberchimps.com
+Dennis,Henry,
+Short,Row
+"unterminated,Allen,nallen8@cnet.com`
+
+	imp := NewImporter(WithWorkers(2), WithLogger(log.New(io.Discard, "", 0)))
+	domainsCount, err := imp.Import(strings.NewReader(csvInput))
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+
+	expected := []Warning{
+		{Category: WARNING_BAD_EMAIL, Line: 3, Value: "bortiz1cyberchimps.com"},
+		{Category: WARNING_EMPTY_EMAIL, Line: 4},
+		{Category: WARNING_SHORT_ROW, Line: 5},
+		{Category: WARNING_MALFORMED_ROW, Line: 6},
+	}
+	if !reflect.DeepEqual(domainsCount.Warnings, expected) {
+		t.Errorf("warnings %v, expected: %v", domainsCount.Warnings, expected)
+	}
+	expectedSummary := "1 bad_email, 1 empty_email, 1 short_row, 1 malformed_row"
+	if summary := domainsCount.WarningSummary(); summary != expectedSummary {
+		t.Errorf("warning summary: %q, expected: %q", summary, expectedSummary)
+	}
+	if domainsCount.InvalidCount != 2 {
+		t.Errorf("Invalid count: %d, expected: 2", domainsCount.InvalidCount)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTo(&buf, *domainsCount, OutputOptions{}); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	expectedOutput := "Total number of customers: 1\nCustomers with invalid email: 2\nDomain: github.io, Customers: 1\n"
+	if buf.String() != expectedOutput {
+		t.Errorf("output %q, expected: %q", buf.String(), expectedOutput)
+	}
+	buf.Reset()
+	if err := WriteTo(&buf, *domainsCount, OutputOptions{Format: FORMAT_JSON}); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"invalid_customers": 2`) {
+		t.Errorf("json output %s, expected invalid_customers", buf.String())
+	}
+}
+
+func TestImporter_WarningLines(t *testing.T) {
+	csvInput := "name,email\n\"multi\nline\",a@github.io\n\nShort\nx,bad-email\n"
+
+	imp := NewImporter(WithEmailColumn(1), WithLogger(log.New(io.Discard, "", 0)))
+	domainsCount, err := imp.Import(strings.NewReader(csvInput))
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+
+	// Both the short row and the email are numbered by their input line.
+	expected := []Warning{
+		{Category: WARNING_SHORT_ROW, Line: 5},
+		{Category: WARNING_BAD_EMAIL, Line: 6, Value: "bad-email"},
+	}
+	if !reflect.DeepEqual(domainsCount.Warnings, expected) {
+		t.Errorf("warnings %v, expected: %v", domainsCount.Warnings, expected)
+	}
+
+	encoded, err := json.Marshal(domainsCount)
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	if strings.Contains(string(encoded), "bad-email") {
+		t.Errorf("JSON result %s, expected no rejected values", encoded)
+	}
+}
+
+func TestImporter_WarningsLimit(t *testing.T) {
+	var csvInput strings.Builder
+	csvInput.WriteString("email\na@github.io\n")
+	for range MAX_WARNINGS + 5 {
+		csvInput.WriteString("not-an-email\n")
+	}
+
+	imp := NewImporter(WithEmailColumn(0), WithLogger(log.New(io.Discard, "", 0)))
+	domainsCount, err := imp.Import(strings.NewReader(csvInput.String()))
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	if len(domainsCount.Warnings) != MAX_WARNINGS {
+		t.Errorf("warnings: %d, expected: %d", len(domainsCount.Warnings), MAX_WARNINGS)
+	}
+	if count := domainsCount.WarningCounts[WARNING_BAD_EMAIL]; count != MAX_WARNINGS+5 {
+		t.Errorf("bad email warnings: %d, expected: %d", count, MAX_WARNINGS+5)
+	}
+}
+
+func TestImportFiles_WarningsInput(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.csv")
+	second := filepath.Join(dir, "second.csv")
+	os.WriteFile(first, []byte("email\na@github.io\n"), 0644)
+	os.WriteFile(second, []byte("email\nb@cnet.com\n\"broken\n"), 0644)
+
+	imp := NewImporter(WithEmailColumn(0), WithLogger(log.New(io.Discard, "", 0)))
+	domainsCount, err := imp.ImportFiles(first, second)
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	expected := []Warning{{Category: WARNING_MALFORMED_ROW, Line: 3, Input: second}}
+	if !reflect.DeepEqual(domainsCount.Warnings, expected) {
+		t.Errorf("warnings %v, expected: %v", domainsCount.Warnings, expected)
+	}
+}
@@ -0,0 +1,62 @@
+package customerimporter
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const INVALID_WEIGHT_FORMAT = "Line %d has invalid weight %q\n"
+
+// validateWeights reports options WithWeightColumn can't be combined with.
+func (imp *Importer) validateWeights() error {
+	if !imp.weighted {
+		return nil
+	}
+	if imp.weightIdx < 0 {
+		return fmt.Errorf("weight column index must not be negative, got %d", imp.weightIdx)
+	}
+	if imp.unique {
+		return errors.New("weights don't support unique counting")
+	}
+	if imp.inputFormat == INPUT_FORMAT_JSONL {
+		return fmt.Errorf("weights are not supported with %s input", INPUT_FORMAT_JSONL)
+	}
+	return nil
+}
+
+// resolveWeightIdx returns the WithWeightColumn index, checked against
+// header, or -1 when rows are not weighted.
+func (imp *Importer) resolveWeightIdx(header []string, emailIdx int) (int, error) {
+	if !imp.weighted {
+		return -1, nil
+	}
+	if imp.weightIdx >= len(header) {
+		return 0, fmt.Errorf("weight column index %d out of range for %d header columns", imp.weightIdx, len(header))
+	}
+	if idx, ok := columnIndex(emailIdx, len(header)); ok && idx == imp.weightIdx {
+		return 0, fmt.Errorf("weight column index %d is the email column", imp.weightIdx)
+	}
+	return imp.weightIdx, nil
+}
+
+// rowWeight returns the number of customers a row with the weight field
+// stands for. Rows that aren't weighted count once, and so do rows whose
+// weight is missing or not a positive integer, unless WithStrictWeights asks
+// to skip them, in which case rowWeight reports false.
+func (imp *Importer) rowWeight(field string, found bool, line int) (int, bool) {
+	if !imp.weighted {
+		return 1, true
+	}
+	weight, err := strconv.Atoi(strings.TrimSpace(field))
+	if found && err == nil && weight > 0 {
+		return weight, true
+	}
+	if imp.strictWeights {
+		imp.logger.Printf(INVALID_WEIGHT_FORMAT, line, field)
+		return 0, false
+	}
+	imp.debugf("Counting line %d with invalid weight %q once", line, field)
+	return 1, true
+}
@@ -0,0 +1,83 @@
+package customerimporter
+
+import (
+	"io"
+	"log"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestImporter_WeightColumn(t *testing.T) {
+	csvInput := `email,quantity
+a@github.io,3
+b@cnet.com,
+c@github.io,two
+d@cnet.com,-1
+e@github.io, 2
+f@zoho.com,1`
+
+	testCases := []struct {
+		name          string
+		strict        bool
+		expected      []DomainStat
+		expectedTotal int
+	}{
+		{
+			name: "default_invalid_to_one",
+			expected: []DomainStat{
+				{Name: "cnet.com", Count: 2, FirstSeenLine: 3},
+				{Name: "github.io", Count: 6, FirstSeenLine: 2},
+				{Name: "zoho.com", Count: 1, FirstSeenLine: 7},
+			},
+			expectedTotal: 9,
+		},
+		{
+			name:   "strict",
+			strict: true,
+			expected: []DomainStat{
+				{Name: "github.io", Count: 5, FirstSeenLine: 2},
+				{Name: "zoho.com", Count: 1, FirstSeenLine: 7},
+			},
+			expectedTotal: 6,
+		},
+	}
+
+	for _, tc := range testCases {
+		for _, fastParse := range []bool{false, true} {
+			imp := NewImporter(WithEmailColumn(0), WithWeightColumn(1), WithStrictWeights(tc.strict),
+				WithFastParse(fastParse), WithLogger(log.New(io.Discard, "", 0)))
+			domainsCount, err := imp.Import(strings.NewReader(csvInput))
+			if err != nil {
+				t.Fatalf("%s: unexpected error occured: %v", tc.name, err)
+			}
+			if domainsCount.TotalCount != tc.expectedTotal {
+				t.Errorf("%s fast parse %v: total customers: %d, expected: %d", tc.name, fastParse, domainsCount.TotalCount, tc.expectedTotal)
+			}
+			if !reflect.DeepEqual(domainsCount.DomainStats, tc.expected) {
+				t.Errorf("%s fast parse %v: domain stats %v, expected: %v", tc.name, fastParse, domainsCount.DomainStats, tc.expected)
+			}
+		}
+	}
+}
+
+func TestImporter_WeightColumnErrors(t *testing.T) {
+	csvInput := "email,quantity\na@github.io,3"
+
+	testCases := []struct {
+		name string
+		opts []Option
+	}{
+		{name: "out_of_range", opts: []Option{WithWeightColumn(2)}},
+		{name: "negative", opts: []Option{WithWeightColumn(-1)}},
+		{name: "email_column", opts: []Option{WithWeightColumn(0)}},
+		{name: "unique", opts: []Option{WithWeightColumn(1), WithUnique(true)}},
+	}
+
+	for _, tc := range testCases {
+		_, err := NewImporter(append([]Option{WithEmailColumn(0)}, tc.opts...)...).Import(strings.NewReader(csvInput))
+		if err == nil {
+			t.Errorf("%s: expected an error", tc.name)
+		}
+	}
+}
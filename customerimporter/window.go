@@ -0,0 +1,98 @@
+package customerimporter
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const WINDOW_HOUR = "hour"
+const WINDOW_DAY = "day"
+
+// WINDOW_UNKNOWN is the window of rows whose timestamp is missing or can't be
+// parsed.
+const WINDOW_UNKNOWN = "unknown"
+
+// windowSeparator joins a row's window and email on their way to the workers
+// and its window and domain in the counts.
+const windowSeparator = "\x00"
+
+// timestampLayouts are the timestamp formats tried, in order.
+var timestampLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// timeWindow returns the window of timestamp for granularity in UTC, like
+// 2024-01-02 for WINDOW_DAY or 2024-01-02T15:00 for WINDOW_HOUR.
+func timeWindow(timestamp, granularity string) string {
+	timestamp = strings.TrimSpace(timestamp)
+	for _, layout := range timestampLayouts {
+		t, err := time.Parse(layout, timestamp)
+		if err != nil {
+			continue
+		}
+		if granularity == WINDOW_HOUR {
+			return t.UTC().Format("2006-01-02T15:00")
+		}
+		return t.UTC().Format(time.DateOnly)
+	}
+	return WINDOW_UNKNOWN
+}
+
+// validateTimeWindow reports options WithTimeWindow can't be combined with.
+func (imp *Importer) validateTimeWindow() error {
+	if imp.timeWindow == "" {
+		return nil
+	}
+	if imp.timeWindow != WINDOW_HOUR && imp.timeWindow != WINDOW_DAY {
+		return fmt.Errorf("unsupported time window: %s", imp.timeWindow)
+	}
+	if imp.groupBy != "" && imp.groupBy != GROUP_BY_DOMAIN {
+		return errors.New("time windows don't support grouping")
+	}
+	if imp.unique || len(imp.disposable) > 0 {
+		return errors.New("time windows don't support unique counting or disposable domains")
+	}
+	return nil
+}
+
+// resolveTimeIdx returns the index of the WithTimeWindow column in header, or
+// -1 when not counting by time window.
+func (imp *Importer) resolveTimeIdx(header []string) (int, error) {
+	if imp.timeWindow == "" {
+		return -1, nil
+	}
+	return resolveColumn(header, imp.timeColumn)
+}
+
+// windowed prefixes email with the window of the timestamp when counting by
+// time window. found is false for rows without the timestamp column.
+func (imp *Importer) windowed(email, timestamp string, found bool) string {
+	if imp.timeWindow == "" {
+		return email
+	}
+	window := WINDOW_UNKNOWN
+	if found {
+		window = timeWindow(timestamp, imp.timeWindow)
+	}
+	return window + windowSeparator + email
+}
+
+// windowStats groups the window and domain keyed domainStats by window, the
+// members named by their domain alone.
+func windowStats(domainStats []DomainStat, naturalSort bool) []DomainStat {
+	groups := groupStats(domainStats, func(key string) string {
+		window, _, _ := strings.Cut(key, windowSeparator)
+		return window
+	}, naturalSort)
+	for _, group := range groups {
+		for i := range group.Subdomains {
+			_, group.Subdomains[i].Name, _ = strings.Cut(group.Subdomains[i].Name, windowSeparator)
+		}
+	}
+	return groups
+}
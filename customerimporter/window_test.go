@@ -0,0 +1,84 @@
+package customerimporter
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTimeWindow(t *testing.T) {
+	testCases := []struct {
+		timestamp   string
+		granularity string
+		expected    string
+	}{
+		{timestamp: "2024-01-02T15:04:05Z", granularity: WINDOW_DAY, expected: "2024-01-02"},
+		{timestamp: "2024-01-02T15:04:05Z", granularity: WINDOW_HOUR, expected: "2024-01-02T15:00"},
+		{timestamp: "2024-01-02T23:30:00-02:00", granularity: WINDOW_DAY, expected: "2024-01-03"},
+		{timestamp: "2024-01-02 08:15:00", granularity: WINDOW_HOUR, expected: "2024-01-02T08:00"},
+		{timestamp: " 2024-01-02 ", granularity: WINDOW_DAY, expected: "2024-01-02"},
+		{timestamp: "02/01/2024", granularity: WINDOW_DAY, expected: WINDOW_UNKNOWN},
+		{timestamp: "", granularity: WINDOW_DAY, expected: WINDOW_UNKNOWN},
+	}
+
+	for _, tc := range testCases {
+		if actual := timeWindow(tc.timestamp, tc.granularity); actual != tc.expected {
+			t.Errorf("timeWindow(%q, %s) = %s; want %s", tc.timestamp, tc.granularity, actual, tc.expected)
+		}
+	}
+}
+
+func TestImporter_TimeWindow(t *testing.T) {
+	csvInput := `email,signed_up
+a@github.io,2024-01-02T10:00:00Z
+b@cnet.com,2024-01-02T11:30:00Z
+c@github.io,2024-01-01 23:59:59
+d@github.io,yesterday
+e@cnet.com`
+
+	expected := []DomainStat{
+		{Name: "2024-01-01", Count: 1, FirstSeenLine: 4, Subdomains: []DomainStat{{Name: "github.io", Count: 1, FirstSeenLine: 4}}},
+		{Name: "2024-01-02", Count: 2, FirstSeenLine: 2, Subdomains: []DomainStat{{Name: "cnet.com", Count: 1, FirstSeenLine: 3}, {Name: "github.io", Count: 1, FirstSeenLine: 2}}},
+		{Name: WINDOW_UNKNOWN, Count: 2, FirstSeenLine: 5, Subdomains: []DomainStat{{Name: "cnet.com", Count: 1, FirstSeenLine: 6}, {Name: "github.io", Count: 1, FirstSeenLine: 5}}},
+	}
+
+	for _, fastParse := range []bool{false, true} {
+		// The short last row is only accepted by the csv parser when ragged.
+		input := csvInput
+		if !fastParse {
+			input = strings.Replace(input, "e@cnet.com", "e@cnet.com,", 1)
+		}
+		imp := NewImporter(WithEmailColumn(0), WithTimeWindow("signed_up", WINDOW_DAY), WithFastParse(fastParse))
+		domainsCount, err := imp.Import(strings.NewReader(input))
+		if err != nil {
+			t.Fatalf("unexpected error occured: %v", err)
+		}
+		if domainsCount.TotalCount != 5 {
+			t.Errorf("fast parse %v: total customers: %d, expected: %d", fastParse, domainsCount.TotalCount, 5)
+		}
+		if !reflect.DeepEqual(domainsCount.DomainStats, expected) {
+			t.Errorf("fast parse %v: domain stats %v, expected: %v", fastParse, domainsCount.DomainStats, expected)
+		}
+	}
+}
+
+func TestImporter_TimeWindowErrors(t *testing.T) {
+	csvInput := "email,signed_up\na@github.io,2024-01-02"
+
+	testCases := []struct {
+		name string
+		opts []Option
+	}{
+		{name: "missing_column", opts: []Option{WithTimeWindow("created", WINDOW_DAY)}},
+		{name: "unsupported_window", opts: []Option{WithTimeWindow("signed_up", "week")}},
+		{name: "group_by", opts: []Option{WithTimeWindow("signed_up", WINDOW_DAY), WithGroupBy(GROUP_BY_TLD)}},
+		{name: "unique", opts: []Option{WithTimeWindow("signed_up", WINDOW_DAY), WithUnique(true)}},
+	}
+
+	for _, tc := range testCases {
+		_, err := NewImporter(append([]Option{WithEmailColumn(0)}, tc.opts...)...).Import(strings.NewReader(csvInput))
+		if err == nil {
+			t.Errorf("%s: error expected, got nil", tc.name)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadDomainList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "disposable.txt")
+	content := "# disposable providers\nmailinator.com\n\n  10minutemail.com  \n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("error writing domain list: %v", err)
+	}
+
+	domains, err := loadDomainList(path)
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	expected := []string{"mailinator.com", "10minutemail.com"}
+	if !reflect.DeepEqual(domains, expected) {
+		t.Errorf("Domains: %v, expected: %v", domains, expected)
+	}
+
+	if _, err := loadDomainList(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("error expected for a missing file, got nil")
+	}
+}
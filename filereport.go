@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/mikarwacki/TeamworkGoTests/customerimporter"
+)
+
+// writeFileReports writes the -per-file-report table, a row per input with
+// its customers, skipped rows and status.
+func writeFileReports(w io.Writer, reports []customerimporter.FileReport) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "FILE\tCUSTOMERS\tSKIPPED\tSTATUS")
+	for _, report := range reports {
+		status := "ok"
+		if report.Err != nil {
+			status = "error: " + report.Err.Error()
+		}
+		fmt.Fprintf(table, "%s\t%d\t%d\t%s\n", report.Path, report.Customers, report.Skipped, status)
+	}
+	return table.Flush()
+}
+
+// failedReports returns how many of reports failed.
+func failedReports(reports []customerimporter.FileReport) int {
+	failed := 0
+	for _, report := range reports {
+		if report.Err != nil {
+			failed++
+		}
+	}
+	return failed
+}
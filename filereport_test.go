@@ -0,0 +1,31 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/mikarwacki/TeamworkGoTests/customerimporter"
+)
+
+func TestWriteFileReports(t *testing.T) {
+	reports := []customerimporter.FileReport{
+		{Path: "first.csv", Customers: 120, Skipped: 1},
+		{Path: "missing.csv", Err: errors.New("file not found")},
+	}
+
+	var buf bytes.Buffer
+	if err := writeFileReports(&buf, reports); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+
+	expected := "FILE         CUSTOMERS  SKIPPED  STATUS\n" +
+		"first.csv    120        1        ok\n" +
+		"missing.csv  0          0        error: file not found\n"
+	if buf.String() != expected {
+		t.Errorf("report %q, expected: %q", buf.String(), expected)
+	}
+	if failed := failedReports(reports); failed != 1 {
+		t.Errorf("failed: %d, expected: 1", failed)
+	}
+}
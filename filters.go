@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mikarwacki/TeamworkGoTests/customerimporter"
+)
+
+// rowFilter is one -filter column and the value its rows must hold.
+type rowFilter struct {
+	column string
+	value  string
+}
+
+// rowFilters collects the repeatable -filter col=value flag.
+type rowFilters []rowFilter
+
+func (f *rowFilters) String() string {
+	var filters []string
+	for _, filter := range *f {
+		filters = append(filters, filter.column+"="+filter.value)
+	}
+	return strings.Join(filters, ",")
+}
+
+// Set parses a col=value filter. The value may be empty to count the rows
+// with a blank column.
+func (f *rowFilters) Set(value string) error {
+	column, filterValue, ok := strings.Cut(value, "=")
+	if !ok || strings.TrimSpace(column) == "" {
+		return fmt.Errorf("-filter must be col=value, got %q", value)
+	}
+	*f = append(*f, rowFilter{column: column, value: filterValue})
+	return nil
+}
+
+// options returns an importer option per filter.
+func (f rowFilters) options() []customerimporter.Option {
+	var opts []customerimporter.Option
+	for _, filter := range f {
+		opts = append(opts, customerimporter.WithRowFilter(filter.column, filter.value))
+	}
+	return opts
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRowFilters_Set(t *testing.T) {
+	testCases := []struct {
+		value       string
+		expected    rowFilter
+		expectError bool
+	}{
+		{value: "country=US", expected: rowFilter{column: "country", value: "US"}},
+		{value: "plan=", expected: rowFilter{column: "plan"}},
+		{value: "note=a=b", expected: rowFilter{column: "note", value: "a=b"}},
+		{value: "country", expectError: true},
+		{value: "=US", expectError: true},
+	}
+
+	for _, tc := range testCases {
+		var filters rowFilters
+		err := filters.Set(tc.value)
+		if tc.expectError {
+			if err == nil {
+				t.Errorf("Set(%q): error expected, got %v", tc.value, filters)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Set(%q): unexpected error occured: %v", tc.value, err)
+			continue
+		}
+		if !reflect.DeepEqual(filters, rowFilters{tc.expected}) {
+			t.Errorf("Set(%q): filters %v, expected: %v", tc.value, filters, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// isGlob reports whether the -input path is a glob pattern rather than a
+// file name or URL.
+func isGlob(path string) bool {
+	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return false
+	}
+	return strings.ContainsAny(path, "*?[")
+}
+
+// expandGlob returns the files matching pattern in lexical order. A pattern
+// matching nothing is an error, as it is most likely a typo.
+func expandGlob(pattern string) ([]string, error) {
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -input pattern %q: %v", pattern, err)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("-input pattern %q matches no files", pattern)
+	}
+	return paths, nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestIsGlob(t *testing.T) {
+	testCases := []struct {
+		path     string
+		expected bool
+	}{
+		{"customers.csv", false},
+		{"exports/2024-*.csv", true},
+		{"exports/2024-0?.csv", true},
+		{"exports/[ab].csv", true},
+		{"https://example.com/export.csv?page=2", false},
+	}
+
+	for _, tc := range testCases {
+		if glob := isGlob(tc.path); glob != tc.expected {
+			t.Errorf("isGlob(%q) = %t; want %t", tc.path, glob, tc.expected)
+		}
+	}
+}
+
+func TestExpandGlob(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"2024-02.csv", "2024-01.csv", "2023-12.csv"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatalf("error writing input file: %v", err)
+		}
+	}
+
+	paths, err := expandGlob(filepath.Join(dir, "2024-*.csv"))
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	expected := []string{filepath.Join(dir, "2024-01.csv"), filepath.Join(dir, "2024-02.csv")}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("Paths: %v, expected: %v", paths, expected)
+	}
+
+	if _, err := expandGlob(filepath.Join(dir, "2025-*.csv")); err == nil {
+		t.Error("error expected for a pattern matching nothing, got nil")
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/mikarwacki/TeamworkGoTests/customerimporter"
+)
+
+// GROUP_SECTION_FORMAT heads the stdout section of each -group-by value.
+const GROUP_SECTION_FORMAT = "== Group by: %s ==\n"
+
+// parseGroupBys splits the comma-separated -group-by values, rejecting
+// unsupported, repeated and, among several, empty ones.
+func parseGroupBys(value string) ([]string, error) {
+	groupBys := strings.Split(value, ",")
+	for i, groupBy := range groupBys {
+		if groupBy == "" && len(groupBys) > 1 {
+			return nil, fmt.Errorf("-group-by has an empty value in %q", value)
+		}
+		if err := customerimporter.ValidateGroupBy(groupBy); err != nil {
+			return nil, err
+		}
+		if slices.Contains(groupBys[:i], groupBy) {
+			return nil, fmt.Errorf("-group-by %s given more than once", groupBy)
+		}
+	}
+	return groupBys, nil
+}
+
+// writeGroupedOutputs writes a report of domainsCount, imported by domain,
+// per grouping in groupBys. Without filePath the reports are sections of
+// stdout headed by GROUP_SECTION_FORMAT; otherwise each goes to filePath
+// named after its grouping, see groupedPath.
+func writeGroupedOutputs(importer *customerimporter.Importer, domainsCount customerimporter.DomainsCount, groupBys []string, filePath string, opts customerimporter.OutputOptions) error {
+	for _, groupBy := range groupBys {
+		grouped, err := importer.Regroup(domainsCount, groupBy)
+		if err != nil {
+			return fmt.Errorf("error grouping by %s: %v", groupBy, err)
+		}
+		if filePath == "" {
+			if _, err := fmt.Fprintf(opts.Stdout, GROUP_SECTION_FORMAT, groupBy); err != nil {
+				return err
+			}
+			if err := customerimporter.WriteOutput(*grouped, nil, opts); err != nil {
+				return err
+			}
+			continue
+		}
+		path := groupedPath(filePath, groupBy)
+		if err := customerimporter.WriteOutput(*grouped, &path, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// groupedPath inserts groupBy before the extension of filePath, keeping a
+// GZIP_EXTENSION last, e.g. result.json.gz becomes result.tld.json.gz.
+func groupedPath(filePath, groupBy string) string {
+	base, gz := strings.CutSuffix(filePath, customerimporter.GZIP_EXTENSION)
+	ext := filepath.Ext(base)
+	path := strings.TrimSuffix(base, ext) + "." + groupBy + ext
+	if gz {
+		path += customerimporter.GZIP_EXTENSION
+	}
+	return path
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mikarwacki/TeamworkGoTests/customerimporter"
+)
+
+func TestParseGroupBys(t *testing.T) {
+	testCases := []struct {
+		value       string
+		expected    []string
+		expectError bool
+	}{
+		{value: "domain", expected: []string{"domain"}},
+		{value: "domain,tld", expected: []string{"domain", "tld"}},
+		{value: "domain,country", expectError: true},
+		{value: "tld,tld", expectError: true},
+		{value: "domain,", expectError: true},
+	}
+
+	for _, tc := range testCases {
+		groupBys, err := parseGroupBys(tc.value)
+		if tc.expectError {
+			if err == nil {
+				t.Errorf("parseGroupBys(%q): error expected, got %v", tc.value, groupBys)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseGroupBys(%q): unexpected error occured: %v", tc.value, err)
+			continue
+		}
+		if !reflect.DeepEqual(groupBys, tc.expected) {
+			t.Errorf("parseGroupBys(%q) = %v, expected: %v", tc.value, groupBys, tc.expected)
+		}
+	}
+}
+
+func TestGroupedPath(t *testing.T) {
+	testCases := []struct {
+		path     string
+		expected string
+	}{
+		{path: "result.json", expected: "result.tld.json"},
+		{path: filepath.Join("out", "result.json.gz"), expected: filepath.Join("out", "result.tld.json.gz")},
+		{path: "result", expected: "result.tld"},
+	}
+
+	for _, tc := range testCases {
+		if actual := groupedPath(tc.path, "tld"); actual != tc.expected {
+			t.Errorf("groupedPath(%q) = %q, expected: %q", tc.path, actual, tc.expected)
+		}
+	}
+}
+
+func TestWriteGroupedOutputs(t *testing.T) {
+	importer := customerimporter.NewImporter(customerimporter.WithEmailColumn(0))
+	domainsCount, err := importer.Import(strings.NewReader("email\na@github.io\nb@eng.corp.com\nc@corp.com"))
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	groupBys := []string{customerimporter.GROUP_BY_DOMAIN, customerimporter.GROUP_BY_TLD}
+
+	var buf bytes.Buffer
+	if err := writeGroupedOutputs(importer, *domainsCount, groupBys, "", customerimporter.OutputOptions{NamesOnly: true, Stdout: &buf}); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	expected := "== Group by: domain ==\ncorp.com\neng.corp.com\ngithub.io\n== Group by: tld ==\ncom\nio\n"
+	if buf.String() != expected {
+		t.Errorf("output %q, expected: %q", buf.String(), expected)
+	}
+
+	filePath := filepath.Join(t.TempDir(), "result.txt")
+	if err := writeGroupedOutputs(importer, *domainsCount, groupBys, filePath, customerimporter.OutputOptions{NamesOnly: true}); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	content, err := os.ReadFile(groupedPath(filePath, customerimporter.GROUP_BY_TLD))
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	if string(content) != "com\nio\n" {
+		t.Errorf("tld report %q, expected: %q", content, "com\nio\n")
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"errors"
+	"strings"
+)
+
+// inputPaths collects the repeatable -input flag. Every -input adds to the
+// inputs instead of replacing the previous one, and all of them are counted
+// together.
+type inputPaths []string
+
+func (i *inputPaths) String() string {
+	return strings.Join(*i, ",")
+}
+
+func (i *inputPaths) Set(value string) error {
+	if value == "" {
+		return errors.New("-input must not be empty")
+	}
+	*i = append(*i, value)
+	return nil
+}
+
+// expand returns the inputs with their glob patterns expanded, in the order
+// they were given.
+func (i inputPaths) expand() ([]string, error) {
+	var paths []string
+	for _, input := range i {
+		if input == "-" && len(i) > 1 {
+			return nil, errors.New("-input - for stdin cannot be combined with other inputs")
+		}
+		if !isGlob(input) {
+			paths = append(paths, input)
+			continue
+		}
+		matches, err := expandGlob(input)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, matches...)
+	}
+	return paths, nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestInputPaths(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.csv", "b.csv"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("email\n"), 0644); err != nil {
+			t.Fatalf("unexpected error occured: %v", err)
+		}
+	}
+
+	testCases := []struct {
+		name        string
+		values      []string
+		expected    []string
+		expectError bool
+	}{
+		{name: "single", values: []string{"customers.csv"}, expected: []string{"customers.csv"}},
+		{name: "repeated", values: []string{"customers.csv", "more.csv"}, expected: []string{"customers.csv", "more.csv"}},
+		{name: "glob", values: []string{"first.csv", filepath.Join(dir, "*.csv")}, expected: []string{"first.csv", filepath.Join(dir, "a.csv"), filepath.Join(dir, "b.csv")}},
+		{name: "stdin", values: []string{"-"}, expected: []string{"-"}},
+		{name: "stdin_combined", values: []string{"-", "customers.csv"}, expectError: true},
+		{name: "no_match", values: []string{filepath.Join(dir, "*.tsv")}, expectError: true},
+	}
+
+	for _, tc := range testCases {
+		var inputs inputPaths
+		for _, value := range tc.values {
+			if err := inputs.Set(value); err != nil {
+				t.Fatalf("%s: unexpected error occured: %v", tc.name, err)
+			}
+		}
+		paths, err := inputs.expand()
+		if tc.expectError {
+			if err == nil {
+				t.Errorf("%s: error expected, got %q", tc.name, paths)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error occured: %v", tc.name, err)
+		}
+		if !slices.Equal(paths, tc.expected) {
+			t.Errorf("%s: paths %q, expected: %q", tc.name, paths, tc.expected)
+		}
+	}
+
+	var inputs inputPaths
+	if err := inputs.Set(""); err == nil {
+		t.Error("empty -input: error expected, got nil")
+	}
+}
@@ -1,30 +1,685 @@
 package main
 
 import (
-	"flag"
-	"log"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
 
 	"github.com/mikarwacki/TeamworkGoTests/customerimporter"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/language"
 )
 
 func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// runProcess is the process command: it imports the input and writes the
+// domain counts.
+func (c *cli) runProcess(args []string) error {
+	fs := c.flagSet(COMMAND_PROCESS)
 	var (
-		inputFilePath  = flag.String("input", "", "Input file path")
-		outputFilePath = flag.String("output", "", "Output file path (default stdout)")
+		inputFormat     = fs.String("input-format", "", "Input format: csv, csv.gz or jsonl for one JSON object per line (default: csv, gzip detected from the content)")
+		weightCol       = fs.Int("weight-col", -1, "Zero-based index of a column with the number of customers each row stands for, e.g. a quantity column (default: every row counts once)")
+		strictWeights   = fs.Bool("strict-weights", false, "Skip rows whose -weight-col value is missing or not a positive integer instead of counting them once")
+		listWarnings    = fs.Bool("warnings", false, "Log the skipped rows with their line and reason, and a summary by category")
+		trimChars       = fs.String("trim-chars", "", "Characters to strip from both ends of every email besides whitespace, e.g. \"'` for quoted exports")
+		stripMailto     = fs.Bool("strip-mailto", false, "Count emails stored as mailto: links, like mailto:user@x.com?subject=Hi, by their address")
+		maxEmailLength  = fs.Int("max-email-length", customerimporter.DEFAULT_MAX_EMAIL_LENGTH, "Skip emails longer than this many bytes as long_email, 0 for no limit")
+		explain         = fs.String("explain", "", "Log for every row of this email or domain whether it was counted and why not otherwise")
+		allowEmpty      = fs.Bool("allow-empty", false, "Report zero customers instead of failing when no row has a valid email, e.g. because of a wrong email column")
+		jsonKey         = fs.String("json-key", customerimporter.DEFAULT_JSON_KEY, "Field holding the email in -input-format jsonl objects")
+		outputFilePath  = fs.String("output", "", "Output file path (default stdout)")
+		fileMode        = fs.String("file-mode", "0644", "Octal permissions of a created output file, subject to the umask")
+		mkdir           = fs.Bool("mkdir", false, "Create missing parent directories of -output")
+		outputDir       = fs.String("output-dir", "", "Directory for one report per input file when -input is a directory")
+		verbose         = fs.Bool("verbose", false, "Enable debug log messages")
+		retries         = fs.Int("retries", 3, "Number of retries for transient errors when fetching a URL input")
+		delimiter       = fs.String("delimiter", ",", `Input field delimiter, a single character or "\t" for tab`)
+		detectDelimiter = fs.Bool("detect-delimiter", false, "Detect the input delimiter from the header line")
+		workerStats     = fs.Bool("worker-stats", false, "Log how many emails each worker processed")
+		workers         = fs.Int("workers", 0, "Number of domain extraction workers (default number of CPUs)")
+		emailCol        = fs.String("email-col", strconv.Itoa(customerimporter.EMAIL_IDX), `Zero-based index of the email column, negative to count from the end or "last"`)
+		emailHeader     = fs.String("email-header", "", "Header name of the email column, used instead of -email-col")
+		emailHeaders    = fs.String("email-headers", "", "Comma-separated candidate header names of the email column, the first one found is used, ignoring case and separators")
+		caseSensitive   = fs.Bool("case-sensitive", false, "Count domains differing only in case separately")
+		naturalSort     = fs.Bool("natural-sort", false, "Sort domains with embedded numbers in numeric order")
+		collation       = fs.String("collate", "", "Sort domains by the collation of a BCP 47 locale, e.g. und or es, instead of byte by byte, for internationalized domains")
+		failOnEmpty     = fs.Bool("fail-on-empty", false, "Exit with a non-zero status when no customers are found")
+		inputEncoding   = fs.String("input-encoding", "utf-8", "Character encoding of the input, e.g. latin1 or windows-1252")
+		matchRegex      = fs.String("match-regex", "", "Only count domains matching this regular expression, e.g. \\.edu$, matched against the lowercased domain")
+		excludeRegex    = fs.String("exclude-regex", "", "Skip domains matching this regular expression, e.g. ^mail\\., matched against the lowercased domain")
+		roleAccounts    = fs.String("role-accounts", "", "Comma-separated local parts counted as role accounts, e.g. info,support,noreply")
+		configFilePath  = fs.String("config", "", "JSON config file with column mappings, per-file email columns of glob inputs and options, overridden by flags")
+		format          = fs.String("format", customerimporter.FORMAT_TEXT, "Output format: "+strings.Join(customerimporter.FORMATS, ", "))
+		merge           = fs.Bool("merge", false, "Merge the sorted JSON results given as arguments instead of reading -input, like the merge command")
+		mergeFromStdin  = fs.Bool("merge-from-stdin", false, "Add the counts of a sorted JSON result read from stdin to those of -input")
+		syslogOut       = fs.Bool("syslog", false, "Also send the total and distinct domain count to the system log, for unattended runs")
+		syslogDomains   = fs.Bool("syslog-domains", false, "With -syslog, send a message per domain with its count too")
+		perFileReport   = fs.Bool("per-file-report", false, "Log a table of the customers, skipped rows and errors of every -input; failed files are left out of the counts instead of ending the run")
+		namesOnly       = fs.Bool("names-only", false, "Only output the sorted domain names, one per line")
+		tree            = fs.Bool("tree", false, "Output the domains as a tree of top-level domain, registered domain and subdomains, with the customers below each node")
+		align           = fs.Bool("align", false, "Pad the domain names and counts of text output to line up in columns")
+		bars            = fs.Bool("bar", false, "Draw a bar and the percentage of customers for every domain in text output")
+		barWidth        = fs.Int("bar-width", 0, "Line width -bar fits the bars to (default $COLUMNS or 80)")
+		histogram       = fs.Bool("histogram", false, "Output how many domains fall into each -histogram-buckets range of customer counts instead of the domains")
+		histogramBounds = fs.String("histogram-buckets", "1,10,100,1000", "Comma-separated ascending upper bounds of the -histogram buckets")
+		normalizePer    = fs.Int("normalize-per", 0, "Write each domain's count as customers per this many customers, e.g. 10000, instead of the absolute count")
+		topN            = fs.Int("top", 0, "Only output the N domains with the most customers, largest first, selected without sorting all domains; the output covers only these domains, in count order, so merge, diff and -merge-from-stdin don't accept it")
+		top1            = fs.Bool("top1", false, "Only output the domain with the most customers and its count, like \"github.io 3\"")
+		checksum        = fs.Bool("checksum", false, "Add the SHA-256 of the output as a last line of text output, or in a .sha256 file next to other output files")
+		gzipOutput      = fs.Bool("gzip-output", false, "Gzip-compress the output, implied by an -output path ending in .gz")
+		sortBy          = fs.String("sort-by", "", "Order the domains by comma-separated keys with optional directions, e.g. count:desc,name:desc; count defaults to desc and name to asc")
+		cumulative      = fs.Bool("cumulative", false, "Order the domains by count and add the running share of customers they cover")
+		tui             = fs.Bool("tui", false, "Browse the domains in an interactive table instead of writing the output, when run in a terminal")
+		singletons      = fs.Bool("singletons", false, "Only output domains with exactly one customer")
+		jsonFlat        = fs.Bool("json-flat", false, "Write -format json as the plain result struct instead of the summary report")
+		summaryOnly     = fs.Bool("summary-only", false, "Write -format json as just the total_customers and distinct_domains summary, without the domains")
+		progress        = fs.Bool("progress", false, "Log import progress as a percentage of the input size, or rows when the size is unknown")
+		unique          = fs.Bool("unique", false, "Count every distinct email address once")
+		stream          = fs.Bool("stream", false, "Write text or csv output one domain at a time in name order, text with the total last, without holding all domain stats in memory")
+		uniqueLimit     = fs.Int("unique-memory-limit", customerimporter.DEFAULT_UNIQUE_MEMORY_LIMIT, "Distinct emails held in memory by -unique before spilling to disk")
+		limit           = fs.Int("limit", 0, "Only process the first N data rows and report the result as a sample")
+		fastParse       = fs.Bool("fast-parse", false, "Split lines without CSV quoting support, faster on wide files known to lack quotes")
+		skipRows        = fs.Int("skip-rows", 0, "Number of preamble rows to discard before the header")
+		totalLabel      = fs.String("total-label", customerimporter.DEFAULT_TOTAL_LABEL, "Label of the total line in text output")
+		disposableList  = fs.String("disposable-list", "", "File listing disposable email domains, one per line, to mark in the output")
+		coverage        = fs.String("coverage", "", "File listing reference domains, one per line, to report as present with their counts or missing instead of the usual output")
+		excludeDisp     = fs.Bool("exclude-disposable", false, "Leave the domains of -disposable-list out of the counts instead of marking them")
+		metricsStatsd   = fs.String("metrics-statsd", "", "StatsD host:port to send the total and per-domain counts to as gauges")
+		memStats        = fs.Bool("mem-stats", false, "Log the peak heap usage at the end of the run")
+		throughput      = fs.Bool("throughput", false, "Log the rows and MB of input processed per second at the end of the run")
+		withMetadata    = fs.Bool("with-metadata", false, "Add a generated run ID and timestamp to the output")
+		groupBy         = fs.String("group-by", customerimporter.GROUP_BY_DOMAIN, "Count by full domain or by group with a per-domain breakdown: domain, registered, tld, sld or category, or at every level of the domain with hierarchy; several comma-separated values write a report each from one read of the input")
+		timeColumn      = fs.String("time-column", "", "Header name of a timestamp column to count domains per -time-window of")
+		timeWindow      = fs.String("time-window", customerimporter.WINDOW_DAY, "Window of the -time-column counts: hour or day")
+		dedupKey        = fs.String("dedup-key", "", "Header name of a column, like a customer ID, whose rows count once with the email of the first; every distinct key is held in memory")
+		categoryMap     = fs.String("category-map", "", "JSON file mapping provider categories to domains, counted with -group-by category (implied)")
 	)
-	flag.Parse()
+	var inputs inputPaths
+	fs.Var(&inputs, "input", "Input file path, glob pattern of files to count together, http(s) URL, s3://bucket/key URL (built with -tags s3), or - for stdin; repeatable, all inputs are counted together")
+	var outputs outputTargets
+	fs.Var(&outputs, "out", "Write the result as fmt:path, e.g. json:result.json or text:- for stdout, instead of -output and -format; repeatable")
+	var filters rowFilters
+	fs.Var(&filters, "filter", "Count only rows whose header column holds the value, as col=value ignoring case; repeatable, all must match")
+	fs.Usage = commandUsage(fs, "",
+		"-input customers.csv",
+		"-input customers.csv -workers 4 -format json -output result.json",
+		"-input - -limit 1000 < customers.csv",
+		"-input customers.csv -singletons -names-only",
+	)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	// Surface a closed stdout as an EPIPE write error, see outputError,
+	// instead of being killed by SIGPIPE.
+	signal.Ignore(syscall.SIGPIPE)
+
+	var fileColumns map[string]customerimporter.EmailColumn
+	if *configFilePath != "" {
+		var err error
+		fileColumns, err = applyConfigFile(fs, *configFilePath)
+		if err != nil {
+			return err
+		}
+	}
+
+	if *memStats {
+		defer trackMemStats(c.logger)()
+	}
+
+	if err := customerimporter.ValidateFormat(*format); err != nil {
+		return err
+	}
+
+	mode, err := parseFileMode(*fileMode)
+	if err != nil {
+		return err
+	}
+
+	var metadata *customerimporter.RunMetadata
+	if *withMetadata {
+		metadata, err = customerimporter.NewRunMetadata()
+		if err != nil {
+			return err
+		}
+		c.logger.Printf("Run ID: %s", metadata.RunID)
+	}
 
-	if inputFilePath == nil || *inputFilePath == "" {
-		log.Fatal("-input flag is required")
+	if *merge {
+		return mergeResults(fs.Args(), outputFilePath, customerimporter.OutputOptions{Format: *format, JSONFlat: *jsonFlat, FileMode: mode, MakeDirs: *mkdir, Metadata: metadata, Stdout: c.stdout, Logger: c.logger})
+	}
+
+	if len(inputs) == 0 {
+		return errors.New("-input flag is required")
+	}
+
+	comma, err := parseDelimiter(*delimiter)
+	if err != nil {
+		return err
+	}
+
+	emailIdx, err := parseColumn(*emailCol)
+	if err != nil {
+		return err
+	}
+
+	enc, err := parseEncoding(*inputEncoding)
+	if err != nil {
+		return err
+	}
+
+	opts := []customerimporter.Option{
+		customerimporter.WithLogger(c.logger),
+		customerimporter.WithWorkers(*workers),
+		customerimporter.WithEmailColumn(emailIdx),
+		customerimporter.WithEmailHeader(*emailHeader),
+		customerimporter.WithVerbose(*verbose),
+		customerimporter.WithRetries(*retries),
+		customerimporter.WithDelimiter(comma),
+		customerimporter.WithDetectDelimiter(*detectDelimiter),
+		customerimporter.WithWorkerStats(*workerStats),
+		customerimporter.WithNaturalSort(*naturalSort),
+		customerimporter.WithInputEncoding(enc),
+		customerimporter.WithUnique(*unique),
+		customerimporter.WithUniqueMemoryLimit(*uniqueLimit, ""),
+		customerimporter.WithGroupBy(*groupBy),
+		customerimporter.WithSkipRows(*skipRows),
+		customerimporter.WithLimit(*limit),
+		customerimporter.WithFastParse(*fastParse),
+		customerimporter.WithCaseSensitive(*caseSensitive),
+		customerimporter.WithMaxEmailLength(*maxEmailLength),
+		customerimporter.WithStripMailto(*stripMailto),
+	}
+	if *categoryMap != "" {
+		categories, err := loadCategoryMap(*categoryMap)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, customerimporter.WithCategoryMap(categories))
+		if *groupBy == customerimporter.GROUP_BY_DOMAIN {
+			opts = append(opts, customerimporter.WithGroupBy(customerimporter.GROUP_BY_CATEGORY))
+		}
+	}
+	if *disposableList != "" {
+		domains, err := loadDomainList(*disposableList)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, customerimporter.WithDisposableDomains(domains, *excludeDisp))
+	}
+	var tracker *throughputTracker
+	if *throughput {
+		tracker = newThroughputTracker()
+		defer func() {
+			c.logger.Print(tracker.summary(time.Since(tracker.start)))
+		}()
+	}
+	switch {
+	case *progress && tracker != nil:
+		opts = append(opts, customerimporter.WithProgress(func(p customerimporter.Progress) {
+			c.logProgress(p)
+			tracker.observe(p)
+		}))
+	case *progress:
+		opts = append(opts, customerimporter.WithProgress(c.logProgress))
+	case tracker != nil:
+		opts = append(opts, customerimporter.WithProgress(tracker.observe))
+	}
+	if *inputFormat != "" {
+		opts = append(opts, customerimporter.WithInputFormat(*inputFormat))
+	}
+	if *inputFormat == customerimporter.INPUT_FORMAT_JSONL {
+		opts = append(opts, customerimporter.WithJSONKey(*jsonKey))
+	}
+	if *top1 {
+		opts = append(opts, customerimporter.WithTopDomain(true))
+	}
+	if *topN > 0 {
+		if *top1 {
+			return errors.New("-top cannot be combined with -top1")
+		}
+		opts = append(opts, customerimporter.WithTopDomains(*topN))
+	}
+	if *timeColumn != "" {
+		opts = append(opts, customerimporter.WithTimeWindow(*timeColumn, *timeWindow))
+	}
+	if *dedupKey != "" {
+		opts = append(opts, customerimporter.WithDedupKey(*dedupKey))
+	}
+	if *emailHeaders != "" {
+		opts = append(opts, customerimporter.WithEmailHeaderCandidates(strings.Split(*emailHeaders, ",")...))
+	}
+	opts = append(opts, filters.options()...)
+	if fileColumns != nil {
+		opts = append(opts, customerimporter.WithFileEmailColumns(fileColumns))
+	}
+	if *weightCol < -1 {
+		return fmt.Errorf("invalid -weight-col %d: must be a column index, or -1 for none", *weightCol)
+	}
+	if *strictWeights && *weightCol < 0 {
+		return errors.New("-strict-weights requires -weight-col")
+	}
+	if *weightCol >= 0 {
+		opts = append(opts, customerimporter.WithWeightColumn(*weightCol), customerimporter.WithStrictWeights(*strictWeights))
+	}
+	if *collation != "" {
+		locale, err := language.Parse(*collation)
+		if err != nil {
+			return fmt.Errorf("unsupported -collate locale %q: %v", *collation, err)
+		}
+		opts = append(opts, customerimporter.WithCollation(locale))
+	}
+	if *trimChars != "" {
+		opts = append(opts, customerimporter.WithTrimChars(*trimChars))
+	}
+	if *explain != "" {
+		opts = append(opts, customerimporter.WithExplain(*explain))
+	}
+	if *allowEmpty {
+		opts = append(opts, customerimporter.WithAllowEmpty(true))
+	}
+	if *roleAccounts != "" {
+		opts = append(opts, customerimporter.WithRoleAccounts(strings.Split(*roleAccounts, ",")))
+	}
+	if *matchRegex != "" {
+		re, err := regexp.Compile(*matchRegex)
+		if err != nil {
+			return fmt.Errorf("invalid -match-regex: %v", err)
+		}
+		opts = append(opts, customerimporter.WithMatchRegex(re))
+	}
+	if *excludeRegex != "" {
+		re, err := regexp.Compile(*excludeRegex)
+		if err != nil {
+			return fmt.Errorf("invalid -exclude-regex: %v", err)
+		}
+		opts = append(opts, customerimporter.WithExcludeRegex(re))
 	}
 
-	domainsCount, err := customerimporter.ProcessFile(*inputFilePath)
+	outputOpts := customerimporter.OutputOptions{
+		Format:       *format,
+		JSONFlat:     *jsonFlat,
+		SummaryOnly:  *summaryOnly,
+		TotalLabel:   *totalLabel,
+		RoleCounts:   *roleAccounts != "",
+		NamesOnly:    *namesOnly,
+		Align:        *align,
+		Tree:         *tree,
+		Bars:         *bars,
+		NormalizePer: *normalizePer,
+		Top:          *top1,
+		Gzip:         *gzipOutput,
+		Checksum:     *checksum,
+		Cumulative:   *cumulative,
+		Width:        *barWidth,
+		FileMode:     mode,
+		MakeDirs:     *mkdir,
+		Metadata:     metadata,
+		Stdout:       c.stdout,
+		Logger:       c.logger,
+	}
+	if *bars && *barWidth <= 0 {
+		outputOpts.Width = terminalWidth()
+	}
+	if *histogram {
+		outputOpts.HistogramBounds, err = parseBuckets(*histogramBounds)
+		if err != nil {
+			return err
+		}
+	}
+	if *singletons {
+		outputOpts.MinCount, outputOpts.MaxCount = 1, 1
+	}
+	if *sortBy != "" {
+		outputOpts.SortKeys, err = customerimporter.ParseSortKeys(*sortBy)
+		if err != nil {
+			return err
+		}
+	}
+	if *coverage != "" {
+		outputOpts.Coverage, err = loadDomainList(*coverage)
+		if err != nil {
+			return err
+		}
+		if outputOpts.Coverage == nil {
+			outputOpts.Coverage = []string{}
+		}
+	}
+	outputOpts.Inputs, err = inputs.expand()
 	if err != nil {
-		log.Fatalf("Error processing file: %v", err)
+		return err
 	}
 
-	err = customerimporter.WriteOutput(*domainsCount, outputFilePath)
+	if *tree && *groupBy != customerimporter.GROUP_BY_DOMAIN {
+		return errors.New("-tree only supports -group-by domain")
+	}
+	if *tree && *format == customerimporter.FORMAT_CSV {
+		return errors.New("-tree does not support -format csv")
+	}
+	groupBys, err := parseGroupBys(*groupBy)
 	if err != nil {
-		log.Fatalf("Error writing ouput: %v", err)
+		return err
+	}
+	if slices.Contains(groupBys, customerimporter.GROUP_BY_HIERARCHY) && *format == customerimporter.FORMAT_CSV {
+		return errors.New("-group-by hierarchy does not support -format csv")
+	}
+	if len(groupBys) > 1 {
+		if *stream || *tui || len(outputs) > 0 {
+			return errors.New("-group-by with several values does not support -stream, -tui or -out")
+		}
+		if *top1 || *topN > 0 || *timeColumn != "" {
+			return errors.New("-group-by with several values does not support -top, -top1 or -time-column")
+		}
+		if *outputFilePath == "" && *format != customerimporter.FORMAT_TEXT {
+			return errors.New("-group-by with several values writes only -format text to stdout, use -output for other formats")
+		}
+		if slices.Contains(groupBys, customerimporter.GROUP_BY_CATEGORY) && *categoryMap == "" {
+			return errors.New("-group-by category requires -category-map")
+		}
+		// The input is counted by domain once and regrouped per value.
+		opts = append(opts, customerimporter.WithGroupBy(customerimporter.GROUP_BY_DOMAIN))
+	}
+	if *normalizePer > 0 && (*align || *roleAccounts != "") {
+		return errors.New("-normalize-per does not support -align or -role-accounts")
+	}
+	if *summaryOnly && (*format != customerimporter.FORMAT_JSON || *jsonFlat) {
+		return errors.New("-summary-only requires -format json without -json-flat")
+	}
+
+	if len(outputs) > 0 && *outputFilePath != "" {
+		return errors.New("-out cannot be combined with -output")
+	}
+
+	var streamOut *streamOutput
+	if *stream {
+		if *format != customerimporter.FORMAT_TEXT && *format != customerimporter.FORMAT_CSV {
+			return errors.New("-stream only supports -format text or csv")
+		}
+		if *histogram {
+			return errors.New("-stream does not support -histogram")
+		}
+		if len(outputs) > 0 {
+			return errors.New("-stream does not support -out")
+		}
+		if *tui {
+			return errors.New("-stream does not support -tui")
+		}
+		if *normalizePer > 0 || *cumulative {
+			return errors.New("-stream does not support -normalize-per or -cumulative")
+		}
+		if *gzipOutput || strings.HasSuffix(*outputFilePath, customerimporter.GZIP_EXTENSION) {
+			return errors.New("-stream does not support gzip output")
+		}
+		if *checksum {
+			return errors.New("-stream does not support -checksum")
+		}
+		if *sortBy != "" {
+			return errors.New("-stream does not support -sort-by")
+		}
+		if *align || *tree {
+			return errors.New("-stream does not support -align or -tree")
+		}
+		if *coverage != "" {
+			return errors.New("-stream does not support -coverage")
+		}
+		streamOut, err = newStreamOutput(*outputFilePath, outputOpts)
+		if err != nil {
+			return err
+		}
+		defer streamOut.discard()
+		opts = append(opts, customerimporter.WithStream(streamOut.writeStat))
+	}
+
+	importer := customerimporter.NewImporter(opts...)
+
+	inputFilePath := inputs[0]
+	if info, err := os.Stat(inputFilePath); err == nil && info.IsDir() {
+		if len(inputs) > 1 {
+			return errors.New("a directory -input cannot be combined with other inputs")
+		}
+		if *outputDir == "" {
+			return errors.New("-output-dir is required when -input is a directory")
+		}
+		if *outputFilePath != "" {
+			return errors.New("-output does not support a directory -input, the reports go to -output-dir")
+		}
+		if *stream {
+			return errors.New("-stream does not support a directory -input")
+		}
+		if len(outputs) > 0 {
+			return errors.New("-out does not support a directory -input")
+		}
+		if *mergeFromStdin {
+			return errors.New("-merge-from-stdin does not support a directory -input")
+		}
+		if *perFileReport {
+			return errors.New("-per-file-report does not support a directory -input")
+		}
+		if len(groupBys) > 1 {
+			return errors.New("-group-by with several values does not support a directory -input")
+		}
+		total, err := importDir(c.logger, importer, inputFilePath, *outputDir, outputOpts)
+		if err != nil {
+			return err
+		}
+		if *failOnEmpty && total == 0 {
+			return errors.New("no customers found")
+		}
+		return nil
+	}
+
+	var partial *customerimporter.DomainsCount
+	if *mergeFromStdin {
+		if slices.Contains(inputs, "-") {
+			return errors.New("-merge-from-stdin cannot be combined with -input -")
+		}
+		if *stream {
+			return errors.New("-stream does not support -merge-from-stdin")
+		}
+		if *groupBy != customerimporter.GROUP_BY_DOMAIN {
+			return errors.New("-merge-from-stdin only supports -group-by domain")
+		}
+		partial, err = customerimporter.MergeResults(c.stdin)
+		if err != nil {
+			return fmt.Errorf("error merging results from stdin: %v", err)
+		}
+	}
+
+	var domainsCount *customerimporter.DomainsCount
+	var reports []customerimporter.FileReport
+	if *perFileReport {
+		if inputFilePath == "-" {
+			return errors.New("-per-file-report does not support -input -")
+		}
+		if *stream {
+			return errors.New("-stream does not support -per-file-report")
+		}
+		var paths []string
+		paths, err = inputs.expand()
+		if err != nil {
+			return err
+		}
+		domainsCount, reports, err = importer.ImportFilesWithReport(paths...)
+		if err == nil {
+			err = writeFileReports(c.stderr, reports)
+		}
+	} else if len(inputs) > 1 || isGlob(inputFilePath) {
+		var paths []string
+		paths, err = inputs.expand()
+		if err != nil {
+			return err
+		}
+		domainsCount, err = importer.ImportFiles(paths...)
+	} else if inputFilePath == "-" {
+		domainsCount, err = importer.Import(c.stdin)
+	} else {
+		domainsCount, err = importer.ImportFile(inputFilePath)
+	}
+	if err != nil {
+		return fmt.Errorf("error processing file: %v", err)
+	}
+
+	for i, processed := range domainsCount.WorkerCounts {
+		c.logger.Printf("Worker %d processed %d emails", i, processed)
+	}
+	if partial != nil {
+		domainsCount = importer.MergeCounts(*partial, *domainsCount)
+	}
+	if *listWarnings {
+		if err := writeWarnings(c.stderr, *domainsCount); err != nil {
+			return err
+		}
+	}
+
+	showTUI := *tui && isTerminal()
+	if *tui && !showTUI {
+		c.logger.Print("Warning: -tui needs a terminal, writing the output instead")
+	}
+
+	if showTUI {
+		err = runTUI(*domainsCount)
+		if err != nil {
+			return fmt.Errorf("error running the table view: %v", err)
+		}
+	} else if streamOut != nil {
+		err = streamOut.finish(domainsCount.TotalCount)
+	} else if len(groupBys) > 1 {
+		err = writeGroupedOutputs(importer, *domainsCount, groupBys, *outputFilePath, outputOpts)
+	} else if len(outputs) > 0 {
+		err = writeOutputs(*domainsCount, outputs, outputOpts)
+	} else {
+		err = customerimporter.WriteOutput(*domainsCount, outputFilePath, outputOpts)
+	}
+	if err != nil {
+		return outputError(err)
+	}
+
+	if *syslogOut {
+		if err := writeSyslog(*domainsCount, *syslogDomains); err != nil {
+			return fmt.Errorf("error writing to syslog: %v", err)
+		}
+	}
+
+	if *metricsStatsd != "" {
+		if err := emitStatsd(*metricsStatsd, domainsCount); err != nil {
+			c.logger.Printf("Error emitting metrics: %v", err)
+		}
+	}
+
+	if failed := failedReports(reports); failed > 0 {
+		return fmt.Errorf("%d of %d inputs failed", failed, len(reports))
+	}
+	if *failOnEmpty && domainsCount.TotalCount == 0 {
+		return errors.New("no customers found")
+	}
+	return nil
+}
+
+// writeSyslog sends domainsCount to the system log for -syslog.
+func writeSyslog(domainsCount customerimporter.DomainsCount, domains bool) error {
+	sink, err := customerimporter.NewSyslogSink(customerimporter.SYSLOG_TAG, domains)
+	if err != nil {
+		return err
+	}
+	defer sink.Close()
+	return sink.Write(domainsCount)
+}
+
+func parseDelimiter(delimiter string) (rune, error) {
+	if delimiter == `\t` {
+		return '\t', nil
+	}
+	if utf8.RuneCountInString(delimiter) != 1 {
+		return 0, fmt.Errorf("-delimiter must be a single character, got %q", delimiter)
+	}
+	r, _ := utf8.DecodeRuneInString(delimiter)
+	return r, nil
+}
+
+// parseColumn parses a column index, accepting "last" for the last column.
+func parseColumn(column string) (int, error) {
+	if strings.EqualFold(column, "last") {
+		return customerimporter.LAST_COLUMN, nil
+	}
+	idx, err := strconv.Atoi(column)
+	if err != nil {
+		return 0, fmt.Errorf(`-email-col must be a column index or "last", got %q`, column)
+	}
+	return idx, nil
+}
+
+// parseBuckets parses comma-separated, strictly ascending, positive bucket
+// bounds.
+func parseBuckets(buckets string) ([]int, error) {
+	var bounds []int
+	for _, field := range strings.Split(buckets, ",") {
+		bound, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || bound <= 0 || (len(bounds) > 0 && bound <= bounds[len(bounds)-1]) {
+			return nil, fmt.Errorf("-histogram-buckets must be ascending positive integers, got %q", buckets)
+		}
+		bounds = append(bounds, bound)
+	}
+	return bounds, nil
+}
+
+// parseFileMode parses an octal permission like 0600. Other mode bits, such as
+// setuid, are rejected.
+func parseFileMode(mode string) (os.FileMode, error) {
+	perm, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil || perm == 0 || perm > 0777 {
+		return 0, fmt.Errorf("-file-mode must be an octal permission between 0001 and 0777, got %q", mode)
+	}
+	return os.FileMode(perm), nil
+}
+
+// parseEncoding looks up an encoding by its web name or alias. UTF-8 input is
+// read as is, so it maps to a nil encoding.
+func parseEncoding(name string) (encoding.Encoding, error) {
+	enc, err := htmlindex.Get(name)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported -input-encoding %q: %v", name, err)
+	}
+	if canonical, _ := htmlindex.Name(enc); canonical == "utf-8" {
+		return nil, nil
+	}
+	return enc, nil
+}
+
+// terminalWidth returns the width shells export in $COLUMNS, or 0, the
+// default width, when it isn't set.
+func terminalWidth() int {
+	width, err := strconv.Atoi(os.Getenv("COLUMNS"))
+	if err != nil {
+		return 0
+	}
+	return width
+}
+
+// outputError wraps an output error. A reader that went away, like head
+// after its last line, closes the pipe; that ends the run quietly and
+// successfully, as is usual for Unix tools.
+func outputError(err error) error {
+	if errors.Is(err, syscall.EPIPE) {
+		return exitError{code: 0}
+	}
+	return fmt.Errorf("error writing output: %v", err)
+}
+
+func (c *cli) logProgress(progress customerimporter.Progress) {
+	if percent, ok := progress.Percent(); ok {
+		c.logger.Printf("Progress: %.1f%% (%d rows)", percent, progress.Rows)
+	} else {
+		c.logger.Printf("Progress: %d rows", progress.Rows)
 	}
 }
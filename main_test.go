@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/mikarwacki/TeamworkGoTests/customerimporter"
+)
+
+func TestParseFileMode(t *testing.T) {
+	testCases := []struct {
+		mode        string
+		expected    os.FileMode
+		expectError bool
+	}{
+		{mode: "0644", expected: 0644},
+		{mode: "600", expected: 0600},
+		{mode: "0777", expected: 0777},
+		{mode: "0000", expectError: true},
+		{mode: "4755", expectError: true},
+		{mode: "0648", expectError: true},
+		{mode: "rw-r--r--", expectError: true},
+	}
+
+	for _, tc := range testCases {
+		mode, err := parseFileMode(tc.mode)
+		if tc.expectError {
+			if err == nil {
+				t.Errorf("parseFileMode(%q): error expected, got %v", tc.mode, mode)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseFileMode(%q): unexpected error occured: %v", tc.mode, err)
+		}
+		if mode != tc.expected {
+			t.Errorf("parseFileMode(%q) = %v; want %v", tc.mode, mode, tc.expected)
+		}
+	}
+}
+
+func TestParseColumn(t *testing.T) {
+	testCases := []struct {
+		column      string
+		expected    int
+		expectError bool
+	}{
+		{column: "2", expected: 2},
+		{column: "-1", expected: -1},
+		{column: "last", expected: customerimporter.LAST_COLUMN},
+		{column: "LAST", expected: customerimporter.LAST_COLUMN},
+		{column: "email", expectError: true},
+	}
+
+	for _, tc := range testCases {
+		idx, err := parseColumn(tc.column)
+		if tc.expectError {
+			if err == nil {
+				t.Errorf("parseColumn(%q): error expected, got %d", tc.column, idx)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseColumn(%q): unexpected error occured: %v", tc.column, err)
+		}
+		if idx != tc.expected {
+			t.Errorf("parseColumn(%q) = %d; want %d", tc.column, idx, tc.expected)
+		}
+	}
+}
+
+func TestParseBuckets(t *testing.T) {
+	testCases := []struct {
+		buckets     string
+		expected    []int
+		expectError bool
+	}{
+		{buckets: "1,10,100", expected: []int{1, 10, 100}},
+		{buckets: "5, 50", expected: []int{5, 50}},
+		{buckets: "3", expected: []int{3}},
+		{buckets: "10,10", expectError: true},
+		{buckets: "10,5", expectError: true},
+		{buckets: "0,10", expectError: true},
+		{buckets: "1,ten", expectError: true},
+		{buckets: "", expectError: true},
+	}
+
+	for _, tc := range testCases {
+		bounds, err := parseBuckets(tc.buckets)
+		if tc.expectError {
+			if err == nil {
+				t.Errorf("parseBuckets(%q): error expected, got %v", tc.buckets, bounds)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseBuckets(%q): unexpected error occured: %v", tc.buckets, err)
+		}
+		if !slices.Equal(bounds, tc.expected) {
+			t.Errorf("parseBuckets(%q) = %v; want %v", tc.buckets, bounds, tc.expected)
+		}
+	}
+}
+
+func TestRun_FailOnEmpty(t *testing.T) {
+	testCases := []struct {
+		name           string
+		args           []string
+		stdin          string
+		expectedCode   int
+		expectedStderr string
+	}{
+		{
+			name:  "customers",
+			args:  []string{"-input", "-", "-fail-on-empty"},
+			stdin: "first_name,last_name,email\nA,B,a@github.io\n",
+		},
+		{
+			name:  "no_customers_without_flag",
+			args:  []string{"-input", "-", "-allow-empty"},
+			stdin: "first_name,last_name,email\n",
+		},
+		{
+			name:           "no_customers",
+			args:           []string{"-input", "-", "-allow-empty", "-fail-on-empty"},
+			stdin:          "first_name,last_name,email\n",
+			expectedCode:   1,
+			expectedStderr: "no customers found",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := run(tc.args, strings.NewReader(tc.stdin), &stdout, &stderr)
+			if code != tc.expectedCode {
+				t.Errorf("exit code: %d, expected: %d (stderr: %s)", code, tc.expectedCode, stderr.String())
+			}
+			if !strings.Contains(stderr.String(), tc.expectedStderr) {
+				t.Errorf("stderr %q, expected it to contain: %q", stderr.String(), tc.expectedStderr)
+			}
+		})
+	}
+}
+
+// closedPipe fails writes like stdout after the reader of the pipe went away.
+type closedPipe struct{}
+
+func (closedPipe) Write(p []byte) (int, error) {
+	return 0, &os.PathError{Op: "write", Path: "/dev/stdout", Err: syscall.EPIPE}
+}
+
+func TestOutputError(t *testing.T) {
+	testCases := []struct {
+		name         string
+		err          error
+		expectedCode int
+		expectedErr  string
+	}{
+		{name: "closed_pipe", err: &os.PathError{Op: "write", Path: "/dev/stdout", Err: syscall.EPIPE}},
+		{name: "wrapped_closed_pipe", err: fmt.Errorf("error flushing: %w", syscall.EPIPE)},
+		{name: "disk_full", err: syscall.ENOSPC, expectedErr: "error writing output: no space left on device"},
+	}
+
+	for _, tc := range testCases {
+		err := outputError(tc.err)
+		var exit exitError
+		if tc.expectedErr == "" {
+			if !errors.As(err, &exit) || exit.code != tc.expectedCode {
+				t.Errorf("%s: error %v, expected exit code: %d", tc.name, err, tc.expectedCode)
+			}
+			continue
+		}
+		if err == nil || err.Error() != tc.expectedErr {
+			t.Errorf("%s: error %v, expected: %s", tc.name, err, tc.expectedErr)
+		}
+	}
+
+	var stderr bytes.Buffer
+	code := run([]string{"-input", "-"}, strings.NewReader("first_name,last_name,email\nA,B,a@github.io\n"), closedPipe{}, &stderr)
+	if code != 0 || stderr.Len() != 0 {
+		t.Errorf("exit code: %d, stderr: %q, expected: 0 and no stderr", code, stderr.String())
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"runtime"
+	"time"
+)
+
+const MEM_STATS_INTERVAL = 10 * time.Millisecond
+
+// trackMemStats samples the heap every MEM_STATS_INTERVAL until the returned
+// function is called, which logs the peak heap usage along with the totals
+// of the run to logger.
+func trackMemStats(logger *log.Logger) func() {
+	done := make(chan struct{})
+	peak := make(chan uint64)
+
+	go func() {
+		var stats runtime.MemStats
+		var maxHeap uint64
+		ticker := time.NewTicker(MEM_STATS_INTERVAL)
+		defer ticker.Stop()
+		for {
+			runtime.ReadMemStats(&stats)
+			maxHeap = max(maxHeap, stats.HeapAlloc)
+			select {
+			case <-done:
+				peak <- maxHeap
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		maxHeap := <-peak
+
+		var stats runtime.MemStats
+		runtime.ReadMemStats(&stats)
+		maxHeap = max(maxHeap, stats.HeapAlloc)
+		logger.Printf("Memory: peak heap %s, heap obtained from the OS %s, total allocated %s, %d GC cycles",
+			formatBytes(maxHeap), formatBytes(stats.HeapSys), formatBytes(stats.TotalAlloc), stats.NumGC)
+	}
+}
+
+func formatBytes(bytes uint64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := uint64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,23 @@
+package main
+
+import "testing"
+
+func TestFormatBytes(t *testing.T) {
+	testCases := []struct {
+		bytes    uint64
+		expected string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KiB"},
+		{1536, "1.5 KiB"},
+		{5 * 1024 * 1024, "5.0 MiB"},
+		{3 * 1024 * 1024 * 1024, "3.0 GiB"},
+	}
+
+	for _, tc := range testCases {
+		if formatted := formatBytes(tc.bytes); formatted != tc.expected {
+			t.Errorf("formatBytes(%d) = %q; want %q", tc.bytes, formatted, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mikarwacki/TeamworkGoTests/customerimporter"
+)
+
+// importDir imports every regular file in dir and writes one report per file
+// into outputDir, named after the source file, e.g. customers.csv becomes
+// customers.report.txt. outputDir is created if missing. It returns the
+// total number of customers across all files.
+func importDir(logger *log.Logger, importer *customerimporter.Importer, dir, outputDir string, opts customerimporter.OutputOptions) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, fmt.Errorf("error reading input directory: %v", err)
+	}
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return 0, fmt.Errorf("error creating output directory: %v", err)
+	}
+
+	total := 0
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		domainsCount, err := importer.ImportFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return total, fmt.Errorf("error processing file %s: %v", entry.Name(), err)
+		}
+		total += domainsCount.TotalCount
+
+		reportPath := filepath.Join(outputDir, reportName(entry.Name(), opts.Format))
+		if opts.Gzip {
+			reportPath += customerimporter.GZIP_EXTENSION
+		}
+		if err := customerimporter.WriteOutput(*domainsCount, &reportPath, opts); err != nil {
+			return total, err
+		}
+		logger.Printf("Wrote report for %s to %s", entry.Name(), reportPath)
+	}
+	return total, nil
+}
+
+// reportName derives the report file name from the input file name and the
+// output format.
+func reportName(inputName, format string) string {
+	extension := "txt"
+	if format != "" && format != customerimporter.FORMAT_TEXT {
+		extension = format
+	}
+	return strings.TrimSuffix(inputName, filepath.Ext(inputName)) + ".report." + extension
+}
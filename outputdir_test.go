@@ -0,0 +1,71 @@
+package main
+
+import (
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mikarwacki/TeamworkGoTests/customerimporter"
+)
+
+func TestImportDir(t *testing.T) {
+	inputDir := t.TempDir()
+	files := map[string]string{
+		"first.csv":  "first_name,last_name,email\nMildred,Hernandez,mhernandez0@github.io\n",
+		"second.tsv": "first_name,last_name,email\nBonnie,Ortiz,bortiz1@cyberchimps.com\nDennis,Henry,dhenry2@hubpages.com\n",
+		".hidden":    "not,a,report\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(inputDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("error writing input file: %v", err)
+		}
+	}
+	outputDir := filepath.Join(t.TempDir(), "reports")
+
+	importer := customerimporter.NewImporter(customerimporter.WithLogger(log.New(io.Discard, "", 0)))
+	total, err := importDir(log.New(io.Discard, "", 0), importer, inputDir, outputDir, customerimporter.OutputOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	if total != 3 {
+		t.Errorf("Total: %d, expected: 3", total)
+	}
+
+	entries, err := os.ReadDir(outputDir)
+	if err != nil {
+		t.Fatalf("error reading output directory: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Reports: %d, expected: 2", len(entries))
+	}
+
+	report, err := os.ReadFile(filepath.Join(outputDir, "second.report.txt"))
+	if err != nil {
+		t.Fatalf("error reading report: %v", err)
+	}
+	expected := "Total number of customers: 2\nDomain: cyberchimps.com, Customers: 1\nDomain: hubpages.com, Customers: 1\n"
+	if string(report) != expected {
+		t.Errorf("Report: %q, expected: %q", report, expected)
+	}
+}
+
+func TestReportName(t *testing.T) {
+	testCases := []struct {
+		input    string
+		format   string
+		expected string
+	}{
+		{"customers.csv", "", "customers.report.txt"},
+		{"customers.csv", customerimporter.FORMAT_JSON, "customers.report.json"},
+		{"export.2024.tsv", customerimporter.FORMAT_TEXT, "export.2024.report.txt"},
+		{"customers", "", "customers.report.txt"},
+	}
+
+	for _, tc := range testCases {
+		if name := reportName(tc.input, tc.format); name != tc.expected {
+			t.Errorf("reportName(%q, %q) = %q; want %q", tc.input, tc.format, name, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mikarwacki/TeamworkGoTests/customerimporter"
+)
+
+// outputTarget is one -out destination, written to stdout when path is empty.
+type outputTarget struct {
+	format string
+	path   string
+}
+
+// outputTargets collects the repeatable -out fmt:path flag.
+type outputTargets []outputTarget
+
+func (o *outputTargets) String() string {
+	var targets []string
+	for _, target := range *o {
+		targets = append(targets, target.format+":"+target.path)
+	}
+	return strings.Join(targets, ",")
+}
+
+// Set parses a fmt:path destination. A path of "-" is stdout.
+func (o *outputTargets) Set(value string) error {
+	format, path, ok := strings.Cut(value, ":")
+	if !ok || format == "" || path == "" {
+		return fmt.Errorf("-out must be fmt:path, got %q", value)
+	}
+	if err := customerimporter.ValidateFormat(format); err != nil {
+		return err
+	}
+	if path == "-" {
+		path = ""
+	}
+	*o = append(*o, outputTarget{format: format, path: path})
+	return nil
+}
+
+// writeOutputs renders domainsCount once per target, in the order given.
+func writeOutputs(domainsCount customerimporter.DomainsCount, targets outputTargets, opts customerimporter.OutputOptions) error {
+	sinks := make([]customerimporter.Sink, 0, len(targets))
+	for _, target := range targets {
+		opts.Format = target.format
+		sinks = append(sinks, customerimporter.NewOutputSink(target.path, opts))
+	}
+	return customerimporter.WriteSinks(domainsCount, sinks...)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mikarwacki/TeamworkGoTests/customerimporter"
+)
+
+func TestOutputTargets_Set(t *testing.T) {
+	testCases := []struct {
+		value       string
+		expected    outputTarget
+		expectError bool
+	}{
+		{value: "json:result.json", expected: outputTarget{format: "json", path: "result.json"}},
+		{value: "text:-", expected: outputTarget{format: "text"}},
+		{value: `csv:C:\out.csv`, expected: outputTarget{format: "csv", path: `C:\out.csv`}},
+		{value: "result.json", expectError: true},
+		{value: ":result.json", expectError: true},
+		{value: "json:", expectError: true},
+		{value: "xml:result.xml", expectError: true},
+	}
+
+	for _, tc := range testCases {
+		var targets outputTargets
+		err := targets.Set(tc.value)
+		if tc.expectError {
+			if err == nil {
+				t.Errorf("Set(%q): error expected, got %v", tc.value, targets)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Set(%q): unexpected error occured: %v", tc.value, err)
+			continue
+		}
+		if !reflect.DeepEqual(targets, outputTargets{tc.expected}) {
+			t.Errorf("Set(%q) = %v; want %v", tc.value, targets, tc.expected)
+		}
+	}
+}
+
+func TestWriteOutputs(t *testing.T) {
+	dir := t.TempDir()
+	textPath := filepath.Join(dir, "result.txt")
+	jsonPath := filepath.Join(dir, "result.json")
+	targets := outputTargets{
+		{format: customerimporter.FORMAT_TEXT, path: textPath},
+		{format: customerimporter.FORMAT_JSON, path: jsonPath},
+	}
+	domainsCount := customerimporter.DomainsCount{
+		DomainStats: []customerimporter.DomainStat{{Name: "github.io", Count: 2}},
+		TotalCount:  2,
+	}
+
+	if err := writeOutputs(domainsCount, targets, customerimporter.OutputOptions{}); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+
+	text, err := os.ReadFile(textPath)
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	if !strings.Contains(string(text), "Domain: github.io, Customers: 2") {
+		t.Errorf("text output %s, expected the domain line", text)
+	}
+	jsonOut, err := os.ReadFile(jsonPath)
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	if !strings.Contains(string(jsonOut), `"total_customers": 2`) {
+		t.Errorf("json output %s, expected the summary", jsonOut)
+	}
+}
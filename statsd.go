@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/mikarwacki/TeamworkGoTests/customerimporter"
+)
+
+const STATSD_PREFIX = "customerimporter"
+
+// STATSD_MAX_PACKET keeps datagrams below the common 1500 byte MTU.
+const STATSD_MAX_PACKET = 1432
+
+// emitStatsd sends the total and every domain's customer count as StatsD
+// gauges over UDP to addr. Dots in domains are replaced, as StatsD treats them
+// as hierarchy separators. Every domain becomes its own metric, so large
+// customer bases produce a high cardinality.
+func emitStatsd(addr string, domainsCount *customerimporter.DomainsCount) error {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return fmt.Errorf("error connecting to statsd: %v", err)
+	}
+	defer conn.Close()
+
+	var packet []byte
+	send := func(line string) error {
+		if len(packet) > 0 && len(packet)+1+len(line) > STATSD_MAX_PACKET {
+			if _, err := conn.Write(packet); err != nil {
+				return fmt.Errorf("error sending statsd metrics: %v", err)
+			}
+			packet = packet[:0]
+		}
+		if len(packet) > 0 {
+			packet = append(packet, '\n')
+		}
+		packet = append(packet, line...)
+		return nil
+	}
+
+	if err := send(fmt.Sprintf("%s.total_customers:%d|g", STATSD_PREFIX, domainsCount.TotalCount)); err != nil {
+		return err
+	}
+	domainsCount.ForEach(func(domain string, count int) {
+		if err == nil {
+			err = send(fmt.Sprintf("%s.domains.%s:%d|g", STATSD_PREFIX, statsdName(domain), count))
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if len(packet) > 0 {
+		if _, err := conn.Write(packet); err != nil {
+			return fmt.Errorf("error sending statsd metrics: %v", err)
+		}
+	}
+	return nil
+}
+
+// statsdName replaces the characters with a meaning in the StatsD line
+// protocol.
+func statsdName(domain string) string {
+	return strings.NewReplacer(".", "_", ":", "_", "|", "_", "@", "_").Replace(domain)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mikarwacki/TeamworkGoTests/customerimporter"
+)
+
+func TestEmitStatsd(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("udp not available: %v", err)
+	}
+	defer conn.Close()
+
+	domainStats := []customerimporter.DomainStat{{Name: "cnet.com", Count: 1}, {Name: "github.io", Count: 3}}
+	for i := range 100 {
+		domainStats = append(domainStats, customerimporter.DomainStat{Name: fmt.Sprintf("domain%d.example.org", i), Count: i})
+	}
+	domainsCount := &customerimporter.DomainsCount{DomainStats: domainStats, TotalCount: 4}
+
+	if err := emitStatsd(conn.LocalAddr().String(), domainsCount); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+
+	var lines []string
+	buf := make([]byte, 65536)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	for len(lines) < len(domainStats)+1 {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("error reading statsd packets: %v", err)
+		}
+		if n > STATSD_MAX_PACKET {
+			t.Errorf("Packet size: %d, expected at most: %d", n, STATSD_MAX_PACKET)
+		}
+		lines = append(lines, strings.Split(string(buf[:n]), "\n")...)
+	}
+
+	expected := []string{
+		"customerimporter.total_customers:4|g",
+		"customerimporter.domains.cnet_com:1|g",
+		"customerimporter.domains.github_io:3|g",
+		"customerimporter.domains.domain0_example_org:0|g",
+	}
+	for i, line := range expected {
+		if lines[i] != line {
+			t.Errorf("Line %d: %s, expected: %s", i, lines[i], line)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mikarwacki/TeamworkGoTests/customerimporter"
+)
+
+// streamOutput writes the text lines or csv records of a -stream run as the
+// domains arrive. The total is only known at the end, so text output writes
+// it last; csv output has none.
+type streamOutput struct {
+	writer *bufio.Writer
+	file   *customerimporter.OutputFile
+	opts   customerimporter.OutputOptions
+	csv    *csv.Writer
+}
+
+// csvWriter returns the csv writer of -format csv, writing the header the
+// first time.
+func (s *streamOutput) csvWriter() (*csv.Writer, error) {
+	if s.csv == nil {
+		s.csv = csv.NewWriter(s.writer)
+		if err := s.csv.Write(customerimporter.CSVHeader()); err != nil {
+			return nil, err
+		}
+	}
+	return s.csv, nil
+}
+
+// newStreamOutput writes to the file at path, replacing it only on finish, or
+// to opts.Stdout when path is empty.
+func newStreamOutput(path string, opts customerimporter.OutputOptions) (*streamOutput, error) {
+	if path == "" {
+		var stdout io.Writer = os.Stdout
+		if opts.Stdout != nil {
+			stdout = opts.Stdout
+		}
+		return &streamOutput{writer: bufio.NewWriter(stdout), opts: opts}, nil
+	}
+
+	file, err := customerimporter.CreateOutputFile(path, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error opening output file: %v", err)
+	}
+	return &streamOutput{writer: bufio.NewWriter(file), file: file, opts: opts}, nil
+}
+
+// writeStat writes the line of one domain, unless the -singletons style
+// count limits exclude it.
+func (s *streamOutput) writeStat(domainStat customerimporter.DomainStat) error {
+	if !s.opts.InCountRange(domainStat.Count) {
+		return nil
+	}
+	if s.opts.Format != customerimporter.FORMAT_CSV {
+		return customerimporter.WriteStatLine(s.writer, domainStat, s.opts)
+	}
+	writer, err := s.csvWriter()
+	if err != nil {
+		return err
+	}
+	return writer.Write(customerimporter.CSVRecord(domainStat))
+}
+
+// finish writes the total line, flushes the output and commits an output file.
+func (s *streamOutput) finish(total int) error {
+	if s.opts.Format == customerimporter.FORMAT_CSV {
+		writer, err := s.csvWriter()
+		if err != nil {
+			return err
+		}
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return err
+		}
+	} else if !s.opts.NamesOnly {
+		label := s.opts.TotalLabel
+		if label == "" {
+			label = customerimporter.DEFAULT_TOTAL_LABEL
+		}
+		if _, err := fmt.Fprintf(s.writer, customerimporter.OUTPUT_HEADER_FORMAT, label, total); err != nil {
+			return err
+		}
+	}
+	if err := s.writer.Flush(); err != nil {
+		return err
+	}
+	if s.file != nil {
+		return s.file.Commit()
+	}
+	return nil
+}
+
+// discard removes an output file that was not committed by finish, leaving
+// the previous file at the path untouched.
+func (s *streamOutput) discard() {
+	if s.file != nil {
+		s.file.Discard()
+	}
+}
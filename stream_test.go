@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mikarwacki/TeamworkGoTests/customerimporter"
+)
+
+func TestStreamOutput(t *testing.T) {
+	testCases := []struct {
+		name           string
+		opts           customerimporter.OutputOptions
+		expectedOutput string
+	}{
+		{
+			name:           "text",
+			expectedOutput: "Domain: cnet.com, Customers: 1\nDomain: github.io, Customers: 2\nTotal number of customers: 3\n",
+		},
+		{
+			name:           "singletons",
+			opts:           customerimporter.OutputOptions{MinCount: 1, MaxCount: 1},
+			expectedOutput: "Domain: cnet.com, Customers: 1\nTotal number of customers: 3\n",
+		},
+		{
+			name:           "csv",
+			opts:           customerimporter.OutputOptions{Format: customerimporter.FORMAT_CSV},
+			expectedOutput: "domain,customers\ncnet.com,1\ngithub.io,2\n",
+		},
+		{
+			name:           "names_only",
+			opts:           customerimporter.OutputOptions{NamesOnly: true},
+			expectedOutput: "cnet.com\ngithub.io\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			out := &streamOutput{writer: bufio.NewWriter(&buf), opts: tc.opts}
+			for _, domainStat := range []customerimporter.DomainStat{{Name: "cnet.com", Count: 1}, {Name: "github.io", Count: 2}} {
+				if err := out.writeStat(domainStat); err != nil {
+					t.Fatalf("unexpected error occured: %v", err)
+				}
+			}
+			if err := out.finish(3); err != nil {
+				t.Fatalf("unexpected error occured: %v", err)
+			}
+			if buf.String() != tc.expectedOutput {
+				t.Errorf("output %q, expected: %q", buf.String(), tc.expectedOutput)
+			}
+		})
+	}
+}
+
+func TestStreamOutput_File(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "result.txt")
+	if err := os.WriteFile(path, []byte("previous\n"), 0644); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	domainStat := customerimporter.DomainStat{Name: "cnet.com", Count: 1}
+
+	// A run that fails before finish leaves the previous file in place.
+	out, err := newStreamOutput(path, customerimporter.OutputOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	if err := out.writeStat(domainStat); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	out.discard()
+	assertStreamFile(t, dir, path, "previous\n")
+
+	out, err = newStreamOutput(path, customerimporter.OutputOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	if err := out.writeStat(domainStat); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	if err := out.finish(1); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	out.discard()
+	assertStreamFile(t, dir, path, "Domain: cnet.com, Customers: 1\nTotal number of customers: 1\n")
+}
+
+// assertStreamFile checks that path holds expected and is the only file in dir.
+func assertStreamFile(t *testing.T, dir, path, expected string) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	if string(data) != expected {
+		t.Errorf("output %q, expected: %q", data, expected)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("files: %d, expected: 1", len(entries))
+	}
+}
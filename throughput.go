@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mikarwacki/TeamworkGoTests/customerimporter"
+)
+
+const THROUGHPUT_FORMAT = "Throughput: %d rows, %s in %s, %.0f rows/sec, %.2f MB/sec"
+
+// throughputTracker sums the rows and bytes of the finished imports of a run
+// for -throughput. Imports run one after the other, so it isn't locked.
+type throughputTracker struct {
+	start time.Time
+	rows  int
+	bytes int64
+}
+
+func newThroughputTracker() *throughputTracker {
+	return &throughputTracker{start: time.Now()}
+}
+
+// observe is the WithProgress callback counting every import once it is done.
+func (t *throughputTracker) observe(progress customerimporter.Progress) {
+	if progress.Done {
+		t.rows += progress.Rows
+		t.bytes += progress.BytesRead
+	}
+}
+
+// summary returns the rows and megabytes of input per second over elapsed.
+func (t *throughputTracker) summary(elapsed time.Duration) string {
+	seconds := max(elapsed.Seconds(), 1e-9)
+	return fmt.Sprintf(THROUGHPUT_FORMAT, t.rows, formatBytes(uint64(t.bytes)), elapsed.Round(time.Microsecond),
+		float64(t.rows)/seconds, float64(t.bytes)/1e6/seconds)
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mikarwacki/TeamworkGoTests/customerimporter"
+)
+
+func TestThroughputTracker(t *testing.T) {
+	tracker := newThroughputTracker()
+	tracker.observe(customerimporter.Progress{Rows: 500, BytesRead: 1_000_000})
+	tracker.observe(customerimporter.Progress{Rows: 1000, BytesRead: 2_000_000, Done: true})
+	tracker.observe(customerimporter.Progress{Rows: 3000, BytesRead: 4_000_000, Done: true})
+
+	expected := "Throughput: 4000 rows, 5.7 MiB in 2s, 2000 rows/sec, 3.00 MB/sec"
+	if summary := tracker.summary(2 * time.Second); summary != expected {
+		t.Errorf("summary: %q, expected: %q", summary, expected)
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mikarwacki/TeamworkGoTests/customerimporter"
+	"golang.org/x/term"
+)
+
+const TUI_HELP = "↑/↓ scroll  PgUp/PgDn page  n/c sort by name/count  / filter  esc clear  q quit"
+
+// tuiView is the state of the -tui table: the domains, how they are sorted
+// and filtered, and which of them are scrolled into view.
+type tuiView struct {
+	domains     []customerimporter.DomainStat
+	total       int
+	sortByCount bool
+	filter      string
+	filtering   bool
+	offset      int
+	height      int
+	width       int
+}
+
+func newTUIView(domainsCount customerimporter.DomainsCount, width, height int) *tuiView {
+	return &tuiView{domains: domainsCount.DomainStats, total: domainsCount.TotalCount, width: width, height: height}
+}
+
+// rows returns the domains matching the filter in the chosen order.
+func (v *tuiView) rows() []customerimporter.DomainStat {
+	var rows []customerimporter.DomainStat
+	for _, domainStat := range v.domains {
+		if strings.Contains(strings.ToLower(domainStat.Name), strings.ToLower(v.filter)) {
+			rows = append(rows, domainStat)
+		}
+	}
+	if v.sortByCount {
+		slices.SortStableFunc(rows, func(a, b customerimporter.DomainStat) int {
+			return b.Count - a.Count
+		})
+	}
+	return rows
+}
+
+// pageSize is the number of table rows that fit between the status and the
+// help line.
+func (v *tuiView) pageSize() int {
+	return max(v.height-2, 1)
+}
+
+// handleKey applies the key read from the terminal and reports whether the
+// view should close.
+func (v *tuiView) handleKey(key string) bool {
+	if v.filtering {
+		switch key {
+		case "\r", "\n", "\x1b":
+			v.filtering = false
+		case "\x7f", "\b":
+			if len(v.filter) > 0 {
+				_, size := utf8.DecodeLastRuneInString(v.filter)
+				v.filter = v.filter[:len(v.filter)-size]
+			}
+		case "\x03":
+			return true
+		default:
+			if utf8.ValidString(key) && !strings.ContainsAny(key, "\x1b\x00") {
+				v.filter += key
+			}
+		}
+		v.offset = 0
+		return false
+	}
+
+	switch key {
+	case "q", "\x03":
+		return true
+	case "k", "\x1b[A":
+		v.offset--
+	case "j", "\x1b[B":
+		v.offset++
+	case "\x1b[5~":
+		v.offset -= v.pageSize()
+	case "\x1b[6~", " ":
+		v.offset += v.pageSize()
+	case "n":
+		v.sortByCount = false
+		v.offset = 0
+	case "c":
+		v.sortByCount = true
+		v.offset = 0
+	case "/":
+		v.filtering = true
+	case "\x1b":
+		v.filter = ""
+		v.offset = 0
+	}
+	v.offset = max(min(v.offset, len(v.rows())-v.pageSize()), 0)
+	return false
+}
+
+// render draws the status line, the visible rows and the help line.
+func (v *tuiView) render(w io.Writer) error {
+	rows := v.rows()
+	order := "name"
+	if v.sortByCount {
+		order = "count"
+	}
+	status := fmt.Sprintf("Customers: %d  Domains: %d/%d  Sort: %s  Filter: %s", v.total, len(rows), len(v.domains), order, v.filter)
+	if v.filtering {
+		status += "_"
+	}
+
+	var b strings.Builder
+	// Clear the screen and move to its top left corner.
+	b.WriteString("\x1b[H\x1b[2J")
+	b.WriteString(v.fit(status) + "\r\n")
+
+	nameWidth := 0
+	for _, domainStat := range rows {
+		nameWidth = max(nameWidth, utf8.RuneCountInString(domainStat.Name))
+	}
+	end := min(v.offset+v.pageSize(), len(rows))
+	for _, domainStat := range rows[v.offset:end] {
+		b.WriteString(v.fit(fmt.Sprintf("%-*s  %d", nameWidth, domainStat.Name, domainStat.Count)) + "\r\n")
+	}
+	for range v.pageSize() - (end - v.offset) {
+		b.WriteString("\r\n")
+	}
+	b.WriteString(v.fit(TUI_HELP))
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// fit cuts line to the terminal width.
+func (v *tuiView) fit(line string) string {
+	if v.width <= 0 || utf8.RuneCountInString(line) <= v.width {
+		return line
+	}
+	return string([]rune(line)[:v.width])
+}
+
+// isTerminal reports whether both stdin and stdout are terminals, as -tui
+// needs to read keys and draw the table.
+func isTerminal() bool {
+	return term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// runTUI shows domainsCount as a table on the terminal until q is pressed.
+func runTUI(domainsCount customerimporter.DomainsCount) error {
+	width, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil {
+		return fmt.Errorf("error getting the terminal size: %v", err)
+	}
+	state, err := term.MakeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		return fmt.Errorf("error switching the terminal to raw mode: %v", err)
+	}
+	defer term.Restore(int(os.Stdin.Fd()), state)
+
+	out := bufio.NewWriter(os.Stdout)
+	// Switch to the alternate screen and hide the cursor, and back on exit.
+	fmt.Fprint(out, "\x1b[?1049h\x1b[?25l")
+	defer func() {
+		fmt.Fprint(out, "\x1b[?25h\x1b[?1049l")
+		out.Flush()
+	}()
+
+	view := newTUIView(domainsCount, width, height)
+	key := make([]byte, 16)
+	for {
+		if err := view.render(out); err != nil {
+			return err
+		}
+		if err := out.Flush(); err != nil {
+			return err
+		}
+		n, err := os.Stdin.Read(key)
+		if err != nil {
+			return err
+		}
+		if view.handleKey(string(key[:n])) {
+			return nil
+		}
+	}
+}
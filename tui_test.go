@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/mikarwacki/TeamworkGoTests/customerimporter"
+)
+
+func newTestView(height int) *tuiView {
+	return newTUIView(customerimporter.DomainsCount{
+		DomainStats: []customerimporter.DomainStat{
+			{Name: "cnet.com", Count: 2},
+			{Name: "github.io", Count: 5},
+			{Name: "hubpages.com", Count: 1},
+			{Name: "zoho.com", Count: 3},
+		},
+		TotalCount: 11,
+	}, 80, height)
+}
+
+func rowNames(view *tuiView) []string {
+	var names []string
+	for _, row := range view.rows() {
+		names = append(names, row.Name)
+	}
+	return names
+}
+
+func TestTUIView_Keys(t *testing.T) {
+	testCases := []struct {
+		name           string
+		keys           []string
+		expectedRows   string
+		expectedOffset int
+	}{
+		{name: "by_name", expectedRows: "cnet.com,github.io,hubpages.com,zoho.com"},
+		{name: "by_count", keys: []string{"c"}, expectedRows: "github.io,zoho.com,cnet.com,hubpages.com"},
+		{name: "back_to_name", keys: []string{"c", "n"}, expectedRows: "cnet.com,github.io,hubpages.com,zoho.com"},
+		{name: "filter", keys: []string{"/", "o", ".", "c", "\r"}, expectedRows: "zoho.com"},
+		{name: "filter_backspace", keys: []string{"/", "h", "x", "\x7f", "\r"}, expectedRows: "github.io,hubpages.com,zoho.com"},
+		{name: "clear_filter", keys: []string{"/", "zoho", "\r", "\x1b"}, expectedRows: "cnet.com,github.io,hubpages.com,zoho.com"},
+		{name: "scroll", keys: []string{"j"}, expectedRows: "cnet.com,github.io,hubpages.com,zoho.com", expectedOffset: 1},
+		{name: "scroll_past_end", keys: []string{"j", "j", "j", "\x1b[B"}, expectedRows: "cnet.com,github.io,hubpages.com,zoho.com", expectedOffset: 2},
+		{name: "scroll_past_top", keys: []string{"j", "k", "\x1b[A"}, expectedRows: "cnet.com,github.io,hubpages.com,zoho.com"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Two of the four rows fit.
+			view := newTestView(4)
+			for _, key := range tc.keys {
+				if view.handleKey(key) {
+					t.Fatalf("key %q closed the view", key)
+				}
+			}
+			if rows := strings.Join(rowNames(view), ","); rows != tc.expectedRows {
+				t.Errorf("rows: %s, expected: %s", rows, tc.expectedRows)
+			}
+			if view.offset != tc.expectedOffset {
+				t.Errorf("offset: %d, expected: %d", view.offset, tc.expectedOffset)
+			}
+		})
+	}
+}
+
+func TestTUIView_Quit(t *testing.T) {
+	view := newTestView(10)
+	if !view.handleKey("q") {
+		t.Error("q expected to close the view")
+	}
+
+	view.handleKey("/")
+	if view.handleKey("q") {
+		t.Error("q expected to be typed into the filter")
+	}
+	if !view.handleKey("\x03") {
+		t.Error("ctrl-c expected to close the view while filtering")
+	}
+}
+
+func TestTUIView_Render(t *testing.T) {
+	view := newTestView(4)
+	view.handleKey("c")
+
+	var buf bytes.Buffer
+	if err := view.render(&buf); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	expected := "\x1b[H\x1b[2J" +
+		"Customers: 11  Domains: 4/4  Sort: count  Filter: \r\n" +
+		"github.io     5\r\n" +
+		"zoho.com      3\r\n" +
+		TUI_HELP
+	if buf.String() != expected {
+		t.Errorf("render %q, expected: %q", buf.String(), expected)
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/mikarwacki/TeamworkGoTests/customerimporter"
+)
+
+// writeWarnings writes a line per warning of domainsCount for -warnings,
+// followed by how many were left out and the summary by category.
+func writeWarnings(w io.Writer, domainsCount customerimporter.DomainsCount) error {
+	total := 0
+	for _, count := range domainsCount.WarningCounts {
+		total += count
+	}
+	if total == 0 {
+		return nil
+	}
+
+	for _, warning := range domainsCount.Warnings {
+		if _, err := fmt.Fprintf(w, "Warning: %v\n", warning); err != nil {
+			return err
+		}
+	}
+	if left := total - len(domainsCount.Warnings); left > 0 {
+		if _, err := fmt.Fprintf(w, "Warning: %d more not listed\n", left); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "Warnings: %s\n", domainsCount.WarningSummary())
+	return err
+}
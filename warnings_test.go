@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/mikarwacki/TeamworkGoTests/customerimporter"
+)
+
+func TestWriteWarnings(t *testing.T) {
+	testCases := []struct {
+		name         string
+		domainsCount customerimporter.DomainsCount
+		expected     string
+	}{
+		{name: "none", expected: ""},
+		{
+			name: "listed",
+			domainsCount: customerimporter.DomainsCount{
+				Warnings: []customerimporter.Warning{
+					{Category: customerimporter.WARNING_BAD_EMAIL, Line: 3, Value: "not-an-email"},
+					{Category: customerimporter.WARNING_SHORT_ROW, Line: 7, Input: "b.csv"},
+				},
+				WarningCounts: map[customerimporter.WarningCategory]int{customerimporter.WARNING_BAD_EMAIL: 3, customerimporter.WARNING_SHORT_ROW: 1},
+			},
+			expected: "Warning: line 3: bad_email \"not-an-email\"\nWarning: b.csv:7: short_row\nWarning: 2 more not listed\nWarnings: 3 bad_email, 1 short_row\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		var buf bytes.Buffer
+		if err := writeWarnings(&buf, tc.domainsCount); err != nil {
+			t.Fatalf("%s: unexpected error occured: %v", tc.name, err)
+		}
+		if buf.String() != tc.expected {
+			t.Errorf("%s: output %q, expected: %q", tc.name, buf.String(), tc.expected)
+		}
+	}
+}